@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHostIsAllowed(t *testing.T) {
+	allowlist := []string{"example.com", "Example.org"}
+	if !hostIsAllowed("example.com", allowlist) {
+		t.Error("expected example.com to be allowed")
+	}
+	if !hostIsAllowed("example.org", allowlist) {
+		t.Error("expected case-insensitive match for example.org")
+	}
+	if hostIsAllowed("evil.com", allowlist) {
+		t.Error("expected evil.com to be rejected")
+	}
+}
+
+func TestIsDisallowedSSRFTarget(t *testing.T) {
+	disallowed := []string{"127.0.0.1", "::1", "10.0.0.1", "192.168.1.1", "169.254.1.1", "0.0.0.0"}
+	for _, addr := range disallowed {
+		if !isDisallowedSSRFTarget(net.ParseIP(addr)) {
+			t.Errorf("expected %s to be disallowed", addr)
+		}
+	}
+
+	if isDisallowedSSRFTarget(net.ParseIP("93.184.216.34")) {
+		t.Error("expected a public IP to be allowed")
+	}
+}
+
+func TestPipelineRun_AllowHostRejectsDisallowedHost(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:        srv.URL,
+		Out:        out,
+		Timeout:    5 * time.Second,
+		UserAgent:  defaultUserAgent,
+		AllowHosts: []string{"example.com"},
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to reject a host not in -allow-host (httptest server's own host)")
+	}
+}
+
+func TestPipelineRun_AllowHostStillRejectsLoopbackEvenWhenNameAllowlisted(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:        srv.URL,
+		Out:        out,
+		Timeout:    5 * time.Second,
+		UserAgent:  defaultUserAgent,
+		AllowHosts: []string{u.Hostname()},
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to reject a loopback address even when allowlisted by name")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// antiBotBodyMarkers are substrings that appear near the start of a
+// Cloudflare (or similarly-behaved) anti-bot interstitial page's HTML, as
+// opposed to the actual image or API response a source is expected to
+// return. They're checked against only a small prefix of the body (see
+// sniffAndValidate and processSpooledImage), since a challenge page puts
+// this text in its <title> or opening <body> content.
+var antiBotBodyMarkers = []string{
+	"Just a moment",
+	"Checking your browser before accessing",
+	"Enable JavaScript and cookies to continue",
+}
+
+// looksLikeAntiBotChallengeStatus reports whether a non-2xx response looks
+// like a Cloudflare challenge rather than an ordinary error: a 403 or 503
+// alongside a "Server: cloudflare" header or Cloudflare's "cf-mitigated"
+// response header (set on a request that was actually challenged, as
+// opposed to cloudflare merely fronting a healthy origin).
+func looksLikeAntiBotChallengeStatus(statusCode int, header http.Header) bool {
+	if statusCode != http.StatusForbidden && statusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	if header.Get("cf-mitigated") != "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(header.Get("Server")), "cloudflare")
+}
+
+// looksLikeAntiBotChallengeBody reports whether body (or a leading prefix of
+// it) contains one of antiBotBodyMarkers.
+func looksLikeAntiBotChallengeBody(body []byte) bool {
+	for _, marker := range antiBotBodyMarkers {
+		if bytes.Contains(body, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
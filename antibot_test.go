@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLooksLikeAntiBotChallengeStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		header http.Header
+		want   bool
+	}{
+		{"cloudflare 403", http.StatusForbidden, http.Header{"Server": {"cloudflare"}}, true},
+		{"cloudflare 503 cf-mitigated", http.StatusServiceUnavailable, http.Header{"Cf-Mitigated": {"challenge"}}, true},
+		{"plain 403", http.StatusForbidden, http.Header{}, false},
+		{"non-cloudflare 503", http.StatusServiceUnavailable, http.Header{"Server": {"nginx"}}, false},
+		{"cloudflare 200", http.StatusOK, http.Header{"Server": {"cloudflare"}}, false},
+	}
+	for _, c := range cases {
+		if got := looksLikeAntiBotChallengeStatus(c.status, c.header); got != c.want {
+			t.Errorf("%s: looksLikeAntiBotChallengeStatus() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestLooksLikeAntiBotChallengeBody(t *testing.T) {
+	if !looksLikeAntiBotChallengeBody([]byte("<html><title>Just a moment...</title></html>")) {
+		t.Error("expected a 'Just a moment' body to be detected")
+	}
+	if looksLikeAntiBotChallengeBody([]byte("plain text response")) {
+		t.Error("expected an ordinary body not to be detected")
+	}
+}
+
+func TestPipelineRun_CloudflareChallengeStatusReturnsErrAntiBot(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "cloudflare")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       t.TempDir() + "/README.md",
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); !errors.Is(err, ErrAntiBot) {
+		t.Fatalf("Run() error = %v, want ErrAntiBot", err)
+	}
+}
+
+func TestPipelineRun_HTMLChallengeBodyReturnsErrAntiBot(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><title>Just a moment...</title></head><body></body></html>"))
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       t.TempDir() + "/README.md",
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); !errors.Is(err, ErrAntiBot) {
+		t.Fatalf("Run() error = %v, want ErrAntiBot", err)
+	}
+}
+
+func TestPipelineRun_CookieHeaderSent(t *testing.T) {
+	png := makePNG(1, 1)
+	var gotCookie string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCookie = r.Header.Get("Cookie")
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       t.TempDir() + "/README.md",
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Cookie:    "cf_clearance=abc123",
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if gotCookie != "cf_clearance=abc123" {
+		t.Errorf("Cookie header = %q, want %q", gotCookie, "cf_clearance=abc123")
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// shieldsBadgeURL builds a shields.io static badge URL
+// (https://shields.io/badges/static-badge) of the form
+// "https://img.shields.io/badge/<label>-<message>-<color>". label and
+// message are percent-encoded since shields.io reads them from the path
+// itself rather than as query parameters.
+func shieldsBadgeURL(label, message, color string) string {
+	return fmt.Sprintf("https://img.shields.io/badge/%s-%s-%s",
+		url.PathEscape(label), url.PathEscape(message), url.PathEscape(color))
+}
+
+// updatedBadgeURL builds the -updated-badge shields.io URL for a run
+// generated at now: a static "updated | <date>" badge, blue like most
+// shields.io defaults.
+//
+// shields.io's own dynamic badges (e.g. img.shields.io/github/last-commit)
+// compute "2h ago"-style ages live, at image-serve time, by querying an API
+// themselves; a static badge URL like this one has no server behind it to
+// do that math on every view, so baking in a relative age (e.g. "2h ago")
+// would go stale the moment it's rendered and stay stale until the next
+// run. Baking in the absolute UTC generation date instead means the badge
+// is always honest about what it shows; pair -updated-badge with -watch or
+// a scheduled CI run to keep it current.
+func updatedBadgeURL(now time.Time) string {
+	return shieldsBadgeURL("updated", now.UTC().Format("Jan 2, 2006"), "blue")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShieldsBadgeURL_EscapesSegments(t *testing.T) {
+	got := shieldsBadgeURL("updated", "Aug 9, 2026", "blue")
+	want := "https://img.shields.io/badge/updated-Aug%209%2C%202026-blue"
+	if got != want {
+		t.Errorf("shieldsBadgeURL() = %q, want %q", got, want)
+	}
+}
+
+func TestUpdatedBadgeURL_UsesUTCDate(t *testing.T) {
+	now := time.Date(2026, 8, 9, 23, 30, 0, 0, time.FixedZone("UTC-5", -5*3600))
+	got := updatedBadgeURL(now)
+	if !strings.Contains(got, "Aug%2010%2C%202026") {
+		t.Errorf("updatedBadgeURL() = %q, want it to contain the UTC date (Aug 10, 2026)", got)
+	}
+}
+
+func TestPipelineRun_UpdatedBadgePopulatesTemplateData(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	for _, enabled := range []bool{false, true} {
+		dir := t.TempDir()
+		tmplPath := filepath.Join(dir, "out.tmpl")
+		if err := os.WriteFile(tmplPath, []byte(`{{.UpdatedBadgeURL}}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		out := filepath.Join(dir, "README.md")
+		p, err := NewPipeline(Config{
+			URL:          srv.URL,
+			Out:          out,
+			Timeout:      5 * time.Second,
+			UserAgent:    defaultUserAgent,
+			Template:     tmplPath,
+			UpdatedBadge: enabled,
+		})
+		if err != nil {
+			t.Fatalf("NewPipeline() error = %v", err)
+		}
+		if err := p.Run(); err != nil {
+			t.Fatalf("Run() (enabled=%v) error = %v", enabled, err)
+		}
+
+		contents, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hasBadge := strings.Contains(string(contents), "https://img.shields.io/badge/updated-")
+		if hasBadge != enabled {
+			t.Errorf("(enabled=%v) output contains badge URL = %v, want %v", enabled, hasBadge, enabled)
+		}
+	}
+}
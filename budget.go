@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// enforceMaxTotalBytes implements -max-total-bytes for any multi-image
+// fetch path (the default fetch plus -compare-url, -sources-config,
+// -grid-config and -stack all build a []fetchResult before collapsing it
+// into TemplateData, so this runs against all of them the same way). When
+// the combined size of every successful result's data URI exceeds
+// maxTotalBytes, and optimize is true (-optimize), the largest decodable
+// result is progressively re-quantized to fewer colors via
+// quantizeToPalette and re-measured, repeating until the total fits or no
+// further reduction helps; results is mutated in place with any
+// re-quantized images. The final total is always reported to stderr, and
+// ErrMaxTotalBytes is returned if it's still over budget.
+func enforceMaxTotalBytes(results []fetchResult, maxTotalBytes int64, optimize bool) error {
+	if maxTotalBytes <= 0 {
+		return nil
+	}
+
+	total := totalDataURIBytes(results)
+
+	if optimize {
+		for colors := 64; int64(total) > maxTotalBytes && colors >= 8; colors /= 2 {
+			i := largestDecodableResult(results)
+			if i == -1 {
+				break
+			}
+
+			quantized, err := quantizeToPalette(results[i].body, colors)
+			if err != nil {
+				break
+			}
+			dataURI, _, _, err := encodeImageToBase64(quantized, "image/png", "", false)
+			if err != nil {
+				break
+			}
+
+			results[i].body = quantized
+			results[i].mime = "image/png"
+			results[i].dataURI = dataURI
+			total = totalDataURIBytes(results)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "eagleusb: embedded images total %d bytes (budget %d)\n", total, maxTotalBytes)
+
+	if int64(total) > maxTotalBytes {
+		return fmt.Errorf("%w: %d bytes over a %d byte budget", ErrMaxTotalBytes, int64(total)-maxTotalBytes, maxTotalBytes)
+	}
+	return nil
+}
+
+// totalDataURIBytes sums the data URI length of every successful result.
+func totalDataURIBytes(results []fetchResult) int {
+	total := 0
+	for _, r := range results {
+		if r.err == nil {
+			total += len(r.dataURI)
+		}
+	}
+	return total
+}
+
+// largestDecodableResult returns the index of the successful result with
+// the largest data URI whose format can be decoded to pixels (so it's a
+// candidate for quantizeToPalette), or -1 if none qualify.
+func largestDecodableResult(results []fetchResult) int {
+	best, bestSize := -1, 0
+	for i, r := range results {
+		if r.err != nil || len(r.body) == 0 || !canDecodeToPixels(r.mime) {
+			continue
+		}
+		if len(r.dataURI) > bestSize {
+			best, bestSize = i, len(r.dataURI)
+		}
+	}
+	return best
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun_MaxTotalBytesFailsWithoutOptimize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(makePNG(200, 200))
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:           srv.URL,
+		Out:           filepath.Join(t.TempDir(), "README.md"),
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+		MaxTotalBytes: 100,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail over a tiny -max-total-bytes budget")
+	} else if !errors.Is(err, ErrMaxTotalBytes) {
+		t.Errorf("error = %v, want it to wrap ErrMaxTotalBytes", err)
+	}
+}
+
+func TestPipelineRun_MaxTotalBytesPassesUnderBudget(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:           srv.URL,
+		Out:           filepath.Join(t.TempDir(), "README.md"),
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+		MaxTotalBytes: 1 << 20,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want nil under a generous budget", err)
+	}
+}
+
+func TestPipelineRun_MaxTotalBytesWithOptimizeShrinksToFit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(makeNoisyPNG(64, 64))
+	}))
+	defer srv.Close()
+
+	// A budget too tight for the original noisy PNG but reachable once
+	// quantizeToPalette has shrunk it.
+	p, err := NewPipeline(Config{
+		URL:           srv.URL,
+		Out:           filepath.Join(t.TempDir(), "README.md"),
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+		MaxTotalBytes: 4000,
+		Optimize:      true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want -optimize to shrink the image under budget", err)
+	}
+}
+
+func TestEnforceMaxTotalBytes_Disabled(t *testing.T) {
+	results := []fetchResult{{dataURI: "data:image/png;base64,AAAA"}}
+	if err := enforceMaxTotalBytes(results, 0, false); err != nil {
+		t.Errorf("enforceMaxTotalBytes() error = %v, want nil when maxTotalBytes is 0", err)
+	}
+}
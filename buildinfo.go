@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// buildInfo is the JSON payload -embed-buildinfo appends to the rendered
+// output as an HTML comment: a machine-readable provenance record for
+// reproducibility audits, richer than -cache-bust's bare content hash, that
+// tooling can locate and json.Unmarshal without it rendering visibly in the
+// markdown.
+type buildInfo struct {
+	Version   string `json:"version"`
+	SourceURL string `json:"source_url"`
+	ImageHash string `json:"image_hash"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Format    string `json:"format"`
+	Timestamp string `json:"timestamp"`
+}
+
+// buildInfoComment renders the given provenance fields as a single-line
+// HTML comment, `<!-- eagleusb:buildinfo {...} -->`. sourceURL should
+// already be redacted (see redactURL); buildInfoComment doesn't redact it
+// itself.
+func buildInfoComment(sourceURL, imageHash, format string, width, height int, timestamp time.Time) (string, error) {
+	encoded, err := json.Marshal(buildInfo{
+		Version:   defaultUserAgent,
+		SourceURL: sourceURL,
+		ImageHash: imageHash,
+		Width:     width,
+		Height:    height,
+		Format:    format,
+		Timestamp: timestamp.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return "", fmt.Errorf("embed-buildinfo: %w", err)
+	}
+	return fmt.Sprintf("<!-- eagleusb:buildinfo %s -->", encoded), nil
+}
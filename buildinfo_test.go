@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildInfoComment_RoundTripsAsJSON(t *testing.T) {
+	comment, err := buildInfoComment("https://example.com/collage.png", "abc123", "image/png", 300, 300, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("buildInfoComment() error = %v", err)
+	}
+
+	if !strings.HasPrefix(comment, "<!-- eagleusb:buildinfo ") || !strings.HasSuffix(comment, " -->") {
+		t.Fatalf("comment = %q, want an HTML comment wrapper", comment)
+	}
+
+	inner := strings.TrimSuffix(strings.TrimPrefix(comment, "<!-- eagleusb:buildinfo "), " -->")
+	var info buildInfo
+	if err := json.Unmarshal([]byte(inner), &info); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, want the comment body to be valid JSON", err)
+	}
+	if info.SourceURL != "https://example.com/collage.png" || info.ImageHash != "abc123" || info.Format != "image/png" || info.Width != 300 || info.Height != 300 {
+		t.Errorf("buildInfo = %+v, want the fields passed in", info)
+	}
+	if info.Version == "" {
+		t.Error("buildInfo.Version is empty")
+	}
+	if info.Timestamp != "2026-01-02T03:04:05Z" {
+		t.Errorf("buildInfo.Timestamp = %q, want an RFC3339 UTC timestamp", info.Timestamp)
+	}
+}
+
+func TestPipelineRun_EmbedBuildInfoAppendsParsableComment(t *testing.T) {
+	png := makePNG(20, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:            srv.URL,
+		Out:            out,
+		Timeout:        5 * time.Second,
+		UserAgent:      defaultUserAgent,
+		EmbedBuildInfo: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx := strings.Index(string(contents), "<!-- eagleusb:buildinfo ")
+	if idx < 0 {
+		t.Fatalf("output = %q, want a buildinfo comment appended", contents)
+	}
+	end := strings.Index(string(contents)[idx:], " -->")
+	if end < 0 {
+		t.Fatalf("output = %q, want a closed buildinfo comment", contents)
+	}
+	inner := string(contents)[idx+len("<!-- eagleusb:buildinfo ") : idx+end]
+
+	var info buildInfo
+	if err := json.Unmarshal([]byte(inner), &info); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, want valid JSON in the comment", err)
+	}
+	if info.Width != 20 || info.Height != 10 {
+		t.Errorf("buildInfo dimensions = %dx%d, want 20x10", info.Width, info.Height)
+	}
+	if info.Format != "image/png" {
+		t.Errorf("buildInfo.Format = %q, want image/png", info.Format)
+	}
+	if info.ImageHash == "" {
+		t.Error("buildInfo.ImageHash is empty")
+	}
+}
+
+func TestPipelineRun_WithoutEmbedBuildInfoOmitsComment(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(contents), "eagleusb:buildinfo") {
+		t.Errorf("output = %q, want no buildinfo comment without -embed-buildinfo", contents)
+	}
+}
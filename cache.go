@@ -0,0 +1,76 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// imageCache remembers the last validated image fetched from a URL across
+// watch-mode cycles, keyed by the server's ETag. When a conditional request
+// comes back 304 Not Modified, the cached, already-decoded result is reused
+// and the (potentially expensive) decode step is skipped entirely.
+type imageCache struct {
+	mu       sync.Mutex
+	etag     string
+	result   fetchResult
+	storedAt time.Time
+
+	Hits   int
+	Misses int
+}
+
+// get returns the cached result and the ETag to send as If-None-Match, if
+// any validated result has been stored yet.
+func (c *imageCache) get() (etag string, result fetchResult, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.etag, c.result, c.etag != ""
+}
+
+// hit records a cache hit (a 304 response) and returns the cached result.
+func (c *imageCache) hit() fetchResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Hits++
+	return c.result
+}
+
+// store records a freshly decoded result and the ETag it was served with,
+// counting a cache miss.
+func (c *imageCache) store(etag string, result fetchResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Misses++
+	if etag != "" {
+		c.etag = etag
+		c.result = result
+		c.storedAt = time.Now()
+	}
+}
+
+// seed pre-loads a persisted ETag/result pair - e.g. from the manifest
+// sidecar a previous process invocation left behind, for -incremental -
+// without counting it as a hit or miss, so the first fetchAndEncode call in
+// a new process can issue a conditional request against a previous run's
+// result the same way an in-process -watch cycle would.
+func (c *imageCache) seed(etag string, result fetchResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if etag != "" {
+		c.etag = etag
+		c.result = result
+		c.storedAt = time.Now()
+	}
+}
+
+// stale reports whether the cached result is older than maxAge. maxAge <= 0
+// disables the check (the cache never goes stale on its own). An empty
+// cache is never stale: there's nothing to force a refetch of yet.
+func (c *imageCache) stale(maxAge time.Duration) bool {
+	if maxAge <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.etag != "" && time.Since(c.storedAt) > maxAge
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchAndEncode_CacheHitSkipsDecode(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("not a real png but bytes suffice for the cache test"))
+	}))
+	defer srv.Close()
+
+	// First response isn't a decodable PNG, so stub out validation isn't
+	// possible here; instead exercise the cache bookkeeping directly via a
+	// pre-seeded cache to avoid depending on a real image payload.
+	cache := &imageCache{}
+	cache.store(`"v1"`, fetchResult{dataURI: "data:image/png;base64,Zm9v"})
+
+	p, err := NewPipeline(Config{UserAgent: defaultUserAgent})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	result := p.fetchAndEncode(context.Background(), srv.URL, cache)
+
+	if result.err != nil {
+		t.Fatalf("fetchAndEncode() error = %v", result.err)
+	}
+	if result.dataURI != "data:image/png;base64,Zm9v" {
+		t.Fatalf("dataURI = %q, want cached value", result.dataURI)
+	}
+	if cache.Hits != 1 || cache.Misses != 1 {
+		t.Fatalf("Hits=%d Misses=%d, want Hits=1 Misses=1", cache.Hits, cache.Misses)
+	}
+	if requests != 1 {
+		t.Fatalf("requests = %d, want 1 (a single conditional GET)", requests)
+	}
+}
+
+func TestImageCache_Stale(t *testing.T) {
+	cache := &imageCache{}
+	if cache.stale(time.Minute) {
+		t.Fatal("an empty cache should never be stale")
+	}
+
+	cache.store(`"v1"`, fetchResult{})
+	if cache.stale(time.Hour) {
+		t.Fatal("a freshly stored cache should not be stale")
+	}
+	if cache.stale(0) {
+		t.Fatal("stale-after 0 should disable the check")
+	}
+
+	cache.storedAt = time.Now().Add(-time.Hour)
+	if !cache.stale(time.Minute) {
+		t.Fatal("a cache older than maxAge should be stale")
+	}
+}
+
+func TestFetchAndEncode_StaleCacheForcesRefetchIgnoringETag(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") != "" {
+			t.Fatalf("expected no If-None-Match header once the cache is stale, got %q", r.Header.Get("If-None-Match"))
+		}
+		w.Header().Set("ETag", `"v2"`)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fresh bytes"))
+	}))
+	defer srv.Close()
+
+	cache := &imageCache{}
+	cache.store(`"v1"`, fetchResult{dataURI: "data:image/png;base64,b2xk"})
+	cache.storedAt = time.Now().Add(-time.Hour)
+
+	p, err := NewPipeline(Config{UserAgent: defaultUserAgent, StaleAfter: time.Minute})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	p.fetchAndEncode(context.Background(), srv.URL, cache)
+
+	if requests != 1 {
+		t.Fatalf("requests = %d, want 1 (an unconditional refetch)", requests)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// cacheBustHashLength is how many hex characters of the content hash to
+// keep - enough to change whenever the image does, short enough to stay
+// out of the way in a README diff.
+const cacheBustHashLength = 12
+
+// contentHash returns a short, stable hash of data, for -cache-bust.
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:cacheBustHashLength]
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContentHash_IsStableAndSized(t *testing.T) {
+	h1 := contentHash([]byte("hello"))
+	h2 := contentHash([]byte("hello"))
+	if h1 != h2 {
+		t.Fatalf("contentHash() not stable: %q != %q", h1, h2)
+	}
+	if len(h1) != cacheBustHashLength {
+		t.Fatalf("len(hash) = %d, want %d", len(h1), cacheBustHashLength)
+	}
+	if contentHash([]byte("goodbye")) == h1 {
+		t.Fatal("expected different content to hash differently")
+	}
+}
+
+func TestPipelineRun_CacheBustEmbedsCommentWhenEnabled(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		CacheBust: true,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), "<!-- cache-bust:") {
+		t.Fatalf("output = %q, want a cache-bust comment", contents)
+	}
+}
+
+func TestPipelineRun_CacheBustOmittedByDefault(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(contents), "cache-bust") {
+		t.Fatalf("output = %q, want no cache-bust comment by default", contents)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import "log/slog"
+
+// imageCapability describes how fully this binary can handle a given MIME
+// type: decodeToPixels is true when image.Decode (or an XML parse, for SVG)
+// can produce something transforms like -png-colors, -thumbnail-width,
+// -strip-exif and -image-diff-threshold can operate on; it's false when
+// only container-level validation is possible (see webp.go). Unlike most
+// "build capability" systems, none of this varies by build tag in this
+// tree - there is no optional WebP decoder to compile in or out, so every
+// build reports the same capabilities. The registry exists to give
+// features one place to check "can I actually decode this?" instead of
+// repeating mime == "image/webp" checks, and to log what's available at
+// startup.
+type imageCapability struct {
+	Mime           string
+	DecodeToPixels bool
+	ValidationNote string
+}
+
+var imageCapabilities = []imageCapability{
+	{Mime: "image/png", DecodeToPixels: true, ValidationNote: "full pixel decode via image/png"},
+	{Mime: "image/jpeg", DecodeToPixels: true, ValidationNote: "full pixel decode via image/jpeg"},
+	{Mime: "image/gif", DecodeToPixels: true, ValidationNote: "full pixel decode via image/gif"},
+	{Mime: "image/webp", DecodeToPixels: false, ValidationNote: "RIFF/WEBP container validation only, no pixel decode (see webp.go)"},
+	{Mime: "image/svg+xml", DecodeToPixels: false, ValidationNote: "XML structure validation only, not a raster format"},
+}
+
+// canDecodeToPixels reports whether mime is one imageCapabilities says can
+// be decoded to pixels, for features (quantization, thumbnailing, EXIF
+// stripping, image hashing) that need real pixel data rather than just a
+// validated container.
+func canDecodeToPixels(mime string) bool {
+	for _, c := range imageCapabilities {
+		if c.Mime == mime {
+			return c.DecodeToPixels
+		}
+	}
+	return false
+}
+
+// logCapabilities emits one debug-level event per known MIME type
+// describing what this build can do with it, so -debug output makes clear
+// up front why, say, -png-colors silently has no effect on a WebP source.
+func logCapabilities(logger *slog.Logger) {
+	if logger == nil {
+		return
+	}
+	for _, c := range imageCapabilities {
+		logger.Debug("image capability",
+			slog.String("mime", c.Mime),
+			slog.Bool("decode_to_pixels", c.DecodeToPixels),
+			slog.String("note", c.ValidationNote),
+		)
+	}
+}
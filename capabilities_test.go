@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCanDecodeToPixels(t *testing.T) {
+	cases := map[string]bool{
+		"image/png":     true,
+		"image/jpeg":    true,
+		"image/gif":     true,
+		"image/webp":    false,
+		"image/svg+xml": false,
+		"image/unknown": false,
+	}
+	for mime, want := range cases {
+		if got := canDecodeToPixels(mime); got != want {
+			t.Errorf("canDecodeToPixels(%q) = %v, want %v", mime, got, want)
+		}
+	}
+}
+
+func TestPipelineRun_PNGColorsOnWebPReturnsClearError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/webp")
+		w.Write(makeWebP())
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		PNGColors: 32,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	runErr := p.Run()
+	if runErr == nil {
+		t.Fatal("expected Run() to fail when -png-colors is paired with a WebP source")
+	}
+	if !errors.Is(runErr, ErrDecode) {
+		t.Fatalf("error = %v, want it to wrap ErrDecode", runErr)
+	}
+}
+
+func TestPipelineRun_PNGColorsOnPNGStillQuantizes(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		PNGColors: 32,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want nil for a PNG source", err)
+	}
+}
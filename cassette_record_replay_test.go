@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCassette_RecordThenReplay(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	cassettePath := filepath.Join(t.TempDir(), "fetch.json")
+
+	record, err := openCassette(cassettePath)
+	if err != nil {
+		t.Fatalf("openCassette() error = %v", err)
+	}
+	if !record.record {
+		t.Fatal("expected a fresh cassette path to open in record mode")
+	}
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	p.Transports = []RoundTripperMiddleware{record.middleware()}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() (recording) error = %v", err)
+	}
+	if err := record.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	replay, err := openCassette(cassettePath)
+	if err != nil {
+		t.Fatalf("openCassette() error = %v", err)
+	}
+	if replay.record {
+		t.Fatal("expected an existing cassette path to open in replay mode")
+	}
+	if len(replay.entries) == 0 {
+		t.Fatal("expected at least one recorded interaction")
+	}
+
+	srv.Close() // prove replay never touches the network
+
+	p2, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	p2.Transports = []RoundTripperMiddleware{replay.middleware()}
+	if err := p2.Run(); err != nil {
+		t.Fatalf("Run() (replaying) error = %v", err)
+	}
+}
+
+func TestCassette_ReplayExhaustedReturnsError(t *testing.T) {
+	c := &cassette{path: filepath.Join(t.TempDir(), "empty.json")}
+	mw := c.middleware()
+	rt := mw(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatal("replay should never reach the wrapped RoundTripper")
+		return nil, nil
+	}))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatal("expected an error when replaying past the last recorded interaction")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// cassette is a minimal record/replay fixture for HTTP round trips, in the
+// spirit of go-vcr: tests that want a real end-to-end Pipeline.Run against a
+// remote service (songstitch, in practice) record its responses once to a
+// JSON file, then replay them from disk afterward so CI never depends on
+// that service's uptime or rate limits. It lives in a _test.go file rather
+// than behind a build tag, since that already keeps it out of the production
+// binary without adding a flag nobody but tests would set.
+type cassette struct {
+	path    string
+	record  bool
+	entries []cassetteEntry
+	next    int
+}
+
+// cassetteEntry is one recorded request/response pair. Bodies are stored as
+// plain bytes rather than base64-by-hand; encoding/json already base64s a
+// []byte field for us.
+type cassetteEntry struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// openCassette loads path's recorded interactions for replay, or, if path
+// doesn't exist yet, returns a cassette in record mode that will write path
+// once closed. This mirrors imageCache's get-or-miss shape: callers don't
+// need to ask which mode they're in, just use the cassette and close it.
+func openCassette(path string) (*cassette, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &cassette{path: path, record: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("openCassette: %w", err)
+	}
+	var entries []cassetteEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("openCassette: %w", err)
+	}
+	return &cassette{path: path, entries: entries}, nil
+}
+
+// close writes out any recorded interactions. It's a no-op in replay mode.
+func (c *cassette) close() error {
+	if !c.record {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cassette close: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		return fmt.Errorf("cassette close: %w", err)
+	}
+	return nil
+}
+
+// middleware returns a RoundTripperMiddleware that records c's owner's
+// requests against next, or replays them from c's entries in the order they
+// were recorded, never touching the network either way once replaying.
+func (c *cassette) middleware() RoundTripperMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if !c.record {
+				return c.replay(req)
+			}
+			return c.captureRoundTrip(req, next)
+		})
+	}
+}
+
+// replay returns the next recorded interaction as an *http.Response,
+// without caring whether it matches req - cassettes in this tree are
+// recorded and replayed against the same linear request sequence a single
+// test issues, the same assumption recordingMiddleware's order-tracking
+// tests make about call ordering.
+func (c *cassette) replay(req *http.Request) (*http.Response, error) {
+	if c.next >= len(c.entries) {
+		return nil, fmt.Errorf("cassette %s: no recorded interaction left for %s %s", c.path, req.Method, req.URL)
+	}
+	entry := c.entries[c.next]
+	c.next++
+	return &http.Response{
+		StatusCode: entry.StatusCode,
+		Header:     entry.Header,
+		Body:       io.NopCloser(bytes.NewReader(entry.Body)),
+		Request:    req,
+	}, nil
+}
+
+func (c *cassette) captureRoundTrip(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette record: %w", err)
+	}
+	c.entries = append(c.entries, cassetteEntry{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	})
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// iccProfileAPP2Signature is the fixed 12-byte identifier that opens an
+// ICC profile's APP2 segment payload in a JPEG, per the ICC spec's
+// "Embedding ICC Profiles in JPEG Files" appendix.
+const iccProfileAPP2Signature = "ICC_PROFILE\x00"
+
+// hasColorProfile reports whether body embeds an ICC color profile:
+// an APP2 segment carrying the ICC_PROFILE signature for JPEG, or an iCCP
+// chunk for PNG. Other formats (GIF, WebP, SVG) don't carry ICC profiles
+// this package recognizes, so it always reports false for them - the same
+// honest per-format gap StripEXIF documents for non-JPEG sources.
+func hasColorProfile(body []byte, mime string) bool {
+	switch mime {
+	case "image/jpeg":
+		return jpegICCSegment(body) >= 0
+	case "image/png":
+		return pngChunkOffset(body, "iCCP") >= 0
+	default:
+		return false
+	}
+}
+
+// stripColorProfile returns a copy of body with its ICC profile removed,
+// preserving every other byte (pixel data is never touched). It returns
+// body unchanged, with saved 0, if mime isn't "image/jpeg" or "image/png",
+// or if the image doesn't embed a profile in the first place.
+func stripColorProfile(body []byte, mime string) (stripped []byte, saved int, err error) {
+	switch mime {
+	case "image/jpeg":
+		return stripJPEGColorProfile(body)
+	case "image/png":
+		return stripPNGColorProfile(body)
+	default:
+		return body, 0, nil
+	}
+}
+
+// jpegICCSegment returns the offset of the APP2 marker byte (0xff) of the
+// first ICC profile segment in a JPEG, or -1 if none is present or body
+// isn't a well-formed JPEG up to that point.
+func jpegICCSegment(body []byte) int {
+	if len(body) < 4 || body[0] != 0xff || body[1] != 0xd8 {
+		return -1
+	}
+	for i := 2; i+4 <= len(body); {
+		if body[i] != 0xff {
+			return -1
+		}
+		marker := body[i+1]
+		if marker == 0xd9 || marker == 0xda {
+			return -1
+		}
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			i += 2
+			continue
+		}
+		segLen := int(body[i+2])<<8 | int(body[i+3])
+		if i+2+segLen > len(body) {
+			return -1
+		}
+		if marker == 0xe2 && segLen >= 2+len(iccProfileAPP2Signature) &&
+			string(body[i+4:i+4+len(iccProfileAPP2Signature)]) == iccProfileAPP2Signature {
+			return i
+		}
+		i += 2 + segLen
+	}
+	return -1
+}
+
+// stripJPEGColorProfile removes every APP2/ICC_PROFILE segment from body,
+// the same verbatim-copy approach stripJPEGEXIF uses for APP1.
+func stripJPEGColorProfile(body []byte) ([]byte, int, error) {
+	if len(body) < 4 || body[0] != 0xff || body[1] != 0xd8 {
+		return nil, 0, fmt.Errorf("not a JPEG: missing SOI marker")
+	}
+
+	out := make([]byte, 0, len(body))
+	out = append(out, body[0], body[1])
+	saved := 0
+
+	for i := 2; i < len(body); {
+		if body[i] != 0xff {
+			return nil, 0, fmt.Errorf("malformed JPEG: expected marker at offset %d", i)
+		}
+		marker := body[i+1]
+
+		switch {
+		case marker == 0xd9: // EOI
+			out = append(out, body[i], body[i+1])
+			return out, saved, nil
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7): // markers with no payload
+			out = append(out, body[i], body[i+1])
+			i += 2
+		case marker == 0xda: // start of scan: the rest is entropy-coded data, copy verbatim
+			out = append(out, body[i:]...)
+			return out, saved, nil
+		default:
+			if i+4 > len(body) {
+				return nil, 0, fmt.Errorf("malformed JPEG: truncated segment header at offset %d", i)
+			}
+			segLen := int(body[i+2])<<8 | int(body[i+3])
+			if i+2+segLen > len(body) {
+				return nil, 0, fmt.Errorf("malformed JPEG: truncated segment at offset %d", i)
+			}
+			isICC := marker == 0xe2 && segLen >= 2+len(iccProfileAPP2Signature) &&
+				string(body[i+4:i+4+len(iccProfileAPP2Signature)]) == iccProfileAPP2Signature
+			if isICC {
+				saved += 2 + segLen
+			} else {
+				out = append(out, body[i:i+2+segLen]...)
+			}
+			i += 2 + segLen
+		}
+	}
+
+	return nil, 0, fmt.Errorf("malformed JPEG: missing EOI marker")
+}
+
+// pngSignature is the 8-byte magic every PNG file starts with.
+var pngSignature = []byte{0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a}
+
+// pngChunkOffset returns the offset of chunkType's length field (the start
+// of the chunk) in a PNG byte stream, or -1 if body isn't a well-formed PNG
+// or has no chunk of that type.
+func pngChunkOffset(body []byte, chunkType string) int {
+	if len(body) < len(pngSignature) || string(body[:len(pngSignature)]) != string(pngSignature) {
+		return -1
+	}
+	for i := len(pngSignature); i+8 <= len(body); {
+		length := binary.BigEndian.Uint32(body[i : i+4])
+		typ := string(body[i+4 : i+8])
+		end := i + 12 + int(length)
+		if end > len(body) {
+			return -1
+		}
+		if typ == chunkType {
+			return i
+		}
+		if typ == "IEND" {
+			return -1
+		}
+		i = end
+	}
+	return -1
+}
+
+// stripPNGColorProfile removes the iCCP chunk (length + type + data + CRC)
+// from body, copying every other chunk verbatim.
+func stripPNGColorProfile(body []byte) ([]byte, int, error) {
+	offset := pngChunkOffset(body, "iCCP")
+	if offset < 0 {
+		return body, 0, nil
+	}
+	length := binary.BigEndian.Uint32(body[offset : offset+4])
+	chunkSize := 12 + int(length)
+
+	out := make([]byte, 0, len(body)-chunkSize)
+	out = append(out, body[:offset]...)
+	out = append(out, body[offset+chunkSize:]...)
+	return out, chunkSize, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+// minimalJPEGWithICC builds on minimalJPEG (see exif_test.go), inserting an
+// APP2/ICC_PROFILE segment ahead of the APP0/JFIF segment when withICC.
+func minimalJPEGWithICC(withICC bool) []byte {
+	var b []byte
+	b = append(b, 0xff, 0xd8) // SOI
+
+	if withICC {
+		iccPayload := append([]byte(iccProfileAPP2Signature), []byte("fake-icc-profile-bytes")...)
+		segLen := len(iccPayload) + 2
+		b = append(b, 0xff, 0xe2, byte(segLen>>8), byte(segLen))
+		b = append(b, iccPayload...)
+	}
+
+	jfifPayload := []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+	segLen := len(jfifPayload) + 2
+	b = append(b, 0xff, 0xe0, byte(segLen>>8), byte(segLen))
+	b = append(b, jfifPayload...)
+
+	b = append(b, 0xff, 0xda) // SOS marker
+	b = append(b, 0x00, 0x0c, 0x03, 0x01, 0x00, 0x02, 0x11, 0x00, 0x03, 0x11, 0x00, 0x00, 0x3f, 0x00)
+	b = append(b, 0xab, 0xcd, 0xef) // fake entropy-coded data
+	b = append(b, 0xff, 0xd9)       // EOI
+	return b
+}
+
+// pngChunk builds a well-formed PNG chunk (length, type, data, CRC32).
+func pngChunk(typ string, data []byte) []byte {
+	var chunk []byte
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(data)))
+	chunk = append(chunk, length...)
+	chunk = append(chunk, []byte(typ)...)
+	chunk = append(chunk, data...)
+	crc := crc32.ChecksumIEEE(append([]byte(typ), data...))
+	crcBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(crcBytes, crc)
+	chunk = append(chunk, crcBytes...)
+	return chunk
+}
+
+// minimalPNGWithICC builds a tiny but structurally valid PNG byte stream:
+// signature, IHDR, an optional iCCP chunk, IDAT, IEND.
+func minimalPNGWithICC(withICC bool) []byte {
+	var b []byte
+	b = append(b, pngSignature...)
+	b = append(b, pngChunk("IHDR", make([]byte, 13))...)
+	if withICC {
+		b = append(b, pngChunk("iCCP", []byte("fake-icc-profile-name\x00\x00compressed-profile-data"))...)
+	}
+	b = append(b, pngChunk("IDAT", []byte("fake-pixel-data"))...)
+	b = append(b, pngChunk("IEND", nil)...)
+	return b
+}
+
+func TestHasColorProfile_JPEGDetectsAPP2ICC(t *testing.T) {
+	if hasColorProfile(minimalJPEGWithICC(true), "image/jpeg") != true {
+		t.Error("expected ICC profile to be detected")
+	}
+	if hasColorProfile(minimalJPEGWithICC(false), "image/jpeg") != false {
+		t.Error("expected no ICC profile to be detected")
+	}
+}
+
+func TestHasColorProfile_PNGDetectsICCPChunk(t *testing.T) {
+	if hasColorProfile(minimalPNGWithICC(true), "image/png") != true {
+		t.Error("expected ICC profile to be detected")
+	}
+	if hasColorProfile(minimalPNGWithICC(false), "image/png") != false {
+		t.Error("expected no ICC profile to be detected")
+	}
+}
+
+func TestHasColorProfile_UnsupportedFormatAlwaysFalse(t *testing.T) {
+	if hasColorProfile(minimalJPEGWithICC(true), "image/gif") != false {
+		t.Error("expected GIF to never report a color profile")
+	}
+}
+
+func TestStripColorProfile_JPEGRemovesAPP2ICCAndReportsSavedBytes(t *testing.T) {
+	in := minimalJPEGWithICC(true)
+	out, saved, err := stripColorProfile(in, "image/jpeg")
+	if err != nil {
+		t.Fatalf("stripColorProfile() error = %v", err)
+	}
+	if saved <= 0 {
+		t.Fatalf("saved = %d, want > 0", saved)
+	}
+	if len(out) != len(in)-saved {
+		t.Fatalf("len(out) = %d, want %d", len(out), len(in)-saved)
+	}
+	if hasColorProfile(out, "image/jpeg") {
+		t.Fatal("expected no ICC profile to remain")
+	}
+}
+
+func TestStripColorProfile_PNGRemovesICCPChunkAndReportsSavedBytes(t *testing.T) {
+	in := minimalPNGWithICC(true)
+	out, saved, err := stripColorProfile(in, "image/png")
+	if err != nil {
+		t.Fatalf("stripColorProfile() error = %v", err)
+	}
+	if saved <= 0 {
+		t.Fatalf("saved = %d, want > 0", saved)
+	}
+	if len(out) != len(in)-saved {
+		t.Fatalf("len(out) = %d, want %d", len(out), len(in)-saved)
+	}
+	if hasColorProfile(out, "image/png") {
+		t.Fatal("expected no iCCP chunk to remain")
+	}
+}
+
+func TestStripColorProfile_NoProfileIsUnchanged(t *testing.T) {
+	in := minimalJPEGWithICC(false)
+	out, saved, err := stripColorProfile(in, "image/jpeg")
+	if err != nil {
+		t.Fatalf("stripColorProfile() error = %v", err)
+	}
+	if saved != 0 {
+		t.Errorf("saved = %d, want 0", saved)
+	}
+	if string(out) != string(in) {
+		t.Error("expected output identical to input when there's no profile")
+	}
+}
+
+func TestStripColorProfile_UnsupportedFormatIsNoop(t *testing.T) {
+	in := []byte("not an image this package touches")
+	out, saved, err := stripColorProfile(in, "image/gif")
+	if err != nil {
+		t.Fatalf("stripColorProfile() error = %v", err)
+	}
+	if saved != 0 || string(out) != string(in) {
+		t.Error("expected a no-op for an unsupported format")
+	}
+}
+
+func TestParseFlags_ColorProfileRejectsUnknownValue(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "https://example.com", "-color-profile", "delete"}); err == nil {
+		t.Fatal("expected -color-profile validation to fail at config-parse time")
+	}
+}
+
+func TestParseFlags_ColorProfileDefaultsToPreserve(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ColorProfile != "preserve" {
+		t.Errorf("cfg.ColorProfile = %q, want \"preserve\"", cfg.ColorProfile)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+)
+
+// runCompareWithRemote implements -compare-with-remote: it regenerates the
+// README locally with the normal pipeline, fetches the published version at
+// -compare-with-remote, and reports whether they match, for catching drift
+// between a profile repo's committed README and what current source data
+// would produce. When -compare-ignore-regex is set, every match is stripped
+// from both sides first, so a template's own "generated at"-style region
+// that legitimately differs every run doesn't cause a false mismatch; this
+// tool has no built-in timestamp of its own, so the pattern is up to the
+// caller. Like -self-test and -healthcheck, it prints a one-line verdict and
+// reports success/failure rather than erroring, since a mismatch is an
+// expected outcome for CI to catch, not a tool failure.
+func runCompareWithRemote(cfg Config) bool {
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "eagleusb:", err)
+		return false
+	}
+
+	_, local, err := p.renderOutput()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "eagleusb: regenerating locally:", err)
+		return false
+	}
+
+	remote, err := fetchRemoteReadme(p.Client, cfg.CompareWithRemote, cfg.UserAgent)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "eagleusb: fetching remote README:", err)
+		return false
+	}
+
+	if cfg.CompareIgnoreRegex != "" {
+		re, err := regexp.Compile(cfg.CompareIgnoreRegex)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "eagleusb: -compare-ignore-regex:", err)
+			return false
+		}
+		local = re.ReplaceAll(local, nil)
+		remote = re.ReplaceAll(remote, nil)
+	}
+
+	if !bytes.Equal(local, remote) {
+		fmt.Println("MISMATCH: the published README does not match what eagleusb would generate now")
+		fmt.Println(renderDiff(remote, local, cfg.DiffContext))
+		return false
+	}
+	fmt.Println("MATCH: the published README matches what eagleusb would generate now")
+	return true
+}
+
+func fetchRemoteReadme(client *http.Client, url, userAgent string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
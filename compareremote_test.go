@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func captureCompareWithRemoteOutput(t *testing.T, cfg Config) (string, bool) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	matched := runCompareWithRemote(cfg)
+	w.Close()
+	os.Stdout = oldStdout
+
+	var captured bytes.Buffer
+	if _, err := captured.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+	return captured.String(), matched
+}
+
+func TestRunCompareWithRemote_MatchReturnsTrue(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	cfg := Config{
+		URL:       imgSrv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	_, local, err := p.renderOutput()
+	if err != nil {
+		t.Fatalf("renderOutput() error = %v", err)
+	}
+
+	remoteSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(local)
+	}))
+	defer remoteSrv.Close()
+	cfg.CompareWithRemote = remoteSrv.URL
+
+	if !runCompareWithRemote(cfg) {
+		t.Error("runCompareWithRemote() = false, want true for an identical remote README")
+	}
+}
+
+func TestRunCompareWithRemote_MismatchReturnsFalse(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	remoteSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("this does not match"))
+	}))
+	defer remoteSrv.Close()
+
+	cfg := Config{
+		URL:               imgSrv.URL,
+		Out:               filepath.Join(t.TempDir(), "README.md"),
+		Timeout:           5 * time.Second,
+		UserAgent:         defaultUserAgent,
+		CompareWithRemote: remoteSrv.URL,
+	}
+
+	if runCompareWithRemote(cfg) {
+		t.Error("runCompareWithRemote() = true, want false for a differing remote README")
+	}
+}
+
+func TestRunCompareWithRemote_IgnoreRegexMasksExpectedDifference(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	cfg := Config{
+		URL:       imgSrv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	}
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	_, local, err := p.renderOutput()
+	if err != nil {
+		t.Fatalf("renderOutput() error = %v", err)
+	}
+
+	remoteSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(append([]byte("generated 2026-01-01\n"), local...))
+	}))
+	defer remoteSrv.Close()
+	cfg.CompareWithRemote = remoteSrv.URL
+	cfg.CompareIgnoreRegex = `generated \d{4}-\d{2}-\d{2}\n`
+
+	if !runCompareWithRemote(cfg) {
+		t.Error("runCompareWithRemote() = false, want true once the timestamp-like region is ignored")
+	}
+}
+
+func TestRunCompareWithRemote_MismatchPrintsDiff(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	remoteSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("line one\nthis does not match\nline three\n"))
+	}))
+	defer remoteSrv.Close()
+
+	cfg := Config{
+		URL:               imgSrv.URL,
+		Out:               filepath.Join(t.TempDir(), "README.md"),
+		Timeout:           5 * time.Second,
+		UserAgent:         defaultUserAgent,
+		CompareWithRemote: remoteSrv.URL,
+		DiffContext:       3,
+	}
+
+	output, matched := captureCompareWithRemoteOutput(t, cfg)
+	if matched {
+		t.Fatal("runCompareWithRemote() = true, want false for a differing remote README")
+	}
+	if !strings.Contains(output, "MISMATCH") {
+		t.Errorf("output = %q, want a MISMATCH verdict", output)
+	}
+	if !strings.Contains(output, "-this does not match") {
+		t.Errorf("output = %q, want the diff to show the removed remote line", output)
+	}
+}
+
+func TestRunCompareWithRemote_DiffContextControlsSurroundingLines(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	local := "line one\nline two\nCHANGED\nline four\nline five\n"
+	remote := "line one\nline two\noriginal\nline four\nline five\n"
+
+	remoteSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(remote))
+	}))
+	defer remoteSrv.Close()
+
+	tmpl := filepath.Join(t.TempDir(), "tmpl.txt")
+	if err := os.WriteFile(tmpl, []byte(local), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		URL:               imgSrv.URL,
+		Out:               filepath.Join(t.TempDir(), "README.md"),
+		Timeout:           5 * time.Second,
+		UserAgent:         defaultUserAgent,
+		Template:          tmpl,
+		CompareWithRemote: remoteSrv.URL,
+		DiffContext:       0,
+	}
+
+	output, matched := captureCompareWithRemoteOutput(t, cfg)
+	if matched {
+		t.Fatal("runCompareWithRemote() = true, want false")
+	}
+	if strings.Contains(output, "line one") || strings.Contains(output, "line five") {
+		t.Errorf("output = %q, want distant unchanged lines omitted at -diff-context 0", output)
+	}
+
+	cfg.DiffContext = 2
+	output, matched = captureCompareWithRemoteOutput(t, cfg)
+	if matched {
+		t.Fatal("runCompareWithRemote() = true, want false")
+	}
+	if !strings.Contains(output, "line one") || !strings.Contains(output, "line five") {
+		t.Errorf("output = %q, want surrounding lines shown at -diff-context 2", output)
+	}
+}
+
+func TestParseFlags_DiffContextRejectsNegative(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-diff-context", "-1"})
+	if err == nil {
+		t.Fatal("expected an error for a negative -diff-context")
+	}
+}
+
+func TestParseFlags_CompareIgnoreRegexRequiresCompareWithRemote(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-compare-ignore-regex", "foo"})
+	if err == nil {
+		t.Fatal("expected an error when -compare-ignore-regex is set without -compare-with-remote")
+	}
+}
+
+func TestParseFlags_CompareIgnoreRegexRejectsInvalidPattern(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-compare-with-remote", "https://example.com/README.md", "-compare-ignore-regex", "("})
+	if err == nil {
+		t.Fatal("expected an error for an invalid -compare-ignore-regex")
+	}
+}
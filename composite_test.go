@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompositeImages_VerticalStacksHeights(t *testing.T) {
+	bodies := [][]byte{makePNG(4, 2), makePNG(4, 3)}
+
+	out, err := compositeImages(bodies, "vertical", 0)
+	if err != nil {
+		t.Fatalf("compositeImages() error = %v", err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode composite: %v", err)
+	}
+	b := img.Bounds()
+	if b.Dx() != 4 || b.Dy() != 5 {
+		t.Fatalf("composite size = %dx%d, want 4x5", b.Dx(), b.Dy())
+	}
+}
+
+func TestCompositeImages_HorizontalAddsSpacing(t *testing.T) {
+	bodies := [][]byte{makePNG(2, 4), makePNG(3, 4)}
+
+	out, err := compositeImages(bodies, "horizontal", 10)
+	if err != nil {
+		t.Fatalf("compositeImages() error = %v", err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode composite: %v", err)
+	}
+	b := img.Bounds()
+	if b.Dx() != 2+10+3 || b.Dy() != 4 {
+		t.Fatalf("composite size = %dx%d, want %dx4", b.Dx(), b.Dy(), 2+10+3)
+	}
+}
+
+func TestCompositeImages_ErrorsOnUndecodableBody(t *testing.T) {
+	if _, err := compositeImages([][]byte{[]byte("not an image")}, "vertical", 0); err == nil {
+		t.Fatal("expected an error for an undecodable image")
+	}
+}
+
+func TestValidateCompositeDirection(t *testing.T) {
+	if err := validateCompositeDirection(""); err != nil {
+		t.Errorf("empty direction should be valid, got %v", err)
+	}
+	if err := validateCompositeDirection("vertical"); err != nil {
+		t.Errorf("vertical should be valid, got %v", err)
+	}
+	if err := validateCompositeDirection("diagonal"); err == nil {
+		t.Fatal("expected an error for an unrecognized direction")
+	}
+}
+
+func TestPipelineRun_StackCompositeEmbedsSingleImage(t *testing.T) {
+	png := makePNG(4, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:            srv.URL,
+		Stack:          "albums,artists",
+		StackComposite: true,
+		StackDirection: "horizontal",
+		StackSpacing:   2,
+		Out:            filepath.Join(t.TempDir(), "README.md"),
+		Timeout:        5 * time.Second,
+		UserAgent:      defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v", err)
+	}
+
+	if data.ImageURL == "" {
+		t.Fatal("expected a single composited ImageURL")
+	}
+	if len(data.StackImages) != 0 {
+		t.Errorf("StackImages = %v, want none when -stack-composite is set", data.StackImages)
+	}
+}
+
+func TestParseFlags_StackCompositeRequiresStack(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-stack-composite"})
+	if err == nil {
+		t.Fatal("expected an error when -stack-composite is set without -stack")
+	}
+}
+
+func TestParseFlags_StackDirectionRejectsUnknownValue(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-stack", "albums", "-stack-direction", "diagonal"})
+	if err == nil {
+		t.Fatal("expected an error for an unrecognized -stack-direction")
+	}
+}
+
+func TestParseFlags_StackSpacingRejectsNegative(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-stack", "albums", "-stack-spacing", "-1"})
+	if err == nil {
+		t.Fatal("expected an error for a negative -stack-spacing")
+	}
+}
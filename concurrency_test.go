@@ -0,0 +1,80 @@
+package main
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+)
+
+// TestPipelineRun_ConcurrentCallsAreRaceFree runs many Run calls against a
+// single shared Pipeline at once. It exists to be run under -race; it
+// doesn't assert much beyond "every call succeeded", since the point is
+// the race detector, not the output.
+func TestPipelineRun_ConcurrentCallsAreRaceFree(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	const concurrency = 16
+	var wg sync.WaitGroup
+	errs := make([]error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = p.runOnce()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("run %d: Run() error = %v", i, err)
+		}
+	}
+}
+
+// TestPipelineCompiledTemplate_ParsesOnceUnderConcurrentCalls exercises the
+// sync.Once-guarded template cache directly under concurrency.
+func TestPipelineCompiledTemplate_ParsesOnceUnderConcurrentCalls(t *testing.T) {
+	p, err := NewPipeline(Config{UserAgent: defaultUserAgent})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	const concurrency = 16
+	var wg sync.WaitGroup
+	tmpls := make([]*template.Template, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tmpl, _, err := p.compiledTemplate()
+			if err != nil {
+				t.Errorf("compiledTemplate() error = %v", err)
+				return
+			}
+			tmpls[i] = tmpl
+		}(i)
+	}
+	wg.Wait()
+
+	for i, tmpl := range tmpls {
+		if tmpl != tmpls[0] {
+			t.Fatalf("goroutine %d got a different *template.Template instance, want the same cached one", i)
+		}
+	}
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds all settings controlling a single collage-embedding run.
+type Config struct {
+	URL                           string
+	CompareURL                    string
+	Template                      string
+	Out                           string
+	ImageOut                      string
+	ForceMime                     string
+	Timeout                       time.Duration
+	UserAgent                     string
+	ConnectRetries                int
+	StatusRetries                 int
+	Partial                       bool
+	Watch                         bool
+	WatchInterval                 time.Duration
+	PNGColors                     int
+	TimeoutPerImage               time.Duration
+	TimeoutBudget                 string
+	Accept                        string
+	FormatFallback                string
+	Redact                        string
+	SelfTest                      bool
+	HealthCheck                   bool
+	ValidateOnly                  bool
+	Interactive                   bool
+	Rows                          int
+	Columns                       int
+	UserAgentFile                 string
+	ThumbnailWidth                int
+	Debug                         bool
+	DecodeConcurrency             int
+	OutputDir                     string
+	Templates                     []string
+	ClientCert                    string
+	ClientKey                     string
+	Picture                       bool
+	GitCommit                     bool
+	CommitMessageTemplate         string
+	SourcesConfig                 string
+	StaleAfter                    time.Duration
+	MinInterval                   time.Duration
+	Force                         bool
+	StripEXIF                     bool
+	TargetFile                    string
+	ReplaceToken                  string
+	Since                         time.Duration
+	CacheBust                     bool
+	Lang                          string
+	FastValidate                  bool
+	OutputStdoutJSON              bool
+	GridConfig                    string
+	Normalize                     bool
+	LineEnding                    string
+	DownloadOnly                  string
+	FromCache                     string
+	AllowHosts                    []string
+	OGOut                         string
+	OGText                        string
+	OGSubtext                     string
+	OGTextX                       int
+	OGTextY                       int
+	OGFontSize                    int
+	Preset                        string
+	Trace                         bool
+	FailOnWarning                 bool
+	ImageDiffThreshold            int
+	ListPeriods                   bool
+	ListMethods                   bool
+	GistToken                     string
+	GistID                        string
+	GistFilename                  string
+	WatchJitter                   float64
+	Cron                          string
+	Optimize                      bool
+	TemplateDir                   string
+	TemplateName                  string
+	CompareWithRemote             string
+	CompareIgnoreRegex            string
+	FallbackLink                  bool
+	Responsive                    bool
+	Serve                         string
+	ServeCacheTTL                 time.Duration
+	ServeCacheSize                int
+	NoLock                        bool
+	LockTimeout                   time.Duration
+	Explain                       bool
+	ImageBase64                   string
+	ImageBase64Env                string
+	Jitter                        string
+	PostCommand                   string
+	Srcset                        bool
+	DateLayout                    string
+	PruneKeep                     int
+	PruneOlderThan                time.Duration
+	ValidateMarkdown              bool
+	Stack                         string
+	StackPeriod                   string
+	MaxTotalBytes                 int64
+	TextLocation                  string
+	NoText                        bool
+	TimeTemplateConfig            string
+	MaxBytes                      int64
+	DiffContext                   int
+	OGJPEGQuality                 int
+	OGJPEGChromaSubsampling       string
+	OGJPEGProgressive             bool
+	LogFile                       string
+	LogFormat                     string
+	LogFileMaxBytes               int64
+	EmbedBuildInfo                bool
+	AuthTokenFile                 string
+	AuthRefreshCommand            string
+	Spool                         bool
+	StrictTemplate                bool
+	UpdatedBadge                  bool
+	RunSummary                    bool
+	URLTemplate                   string
+	Username                      string
+	Period                        string
+	ParallelPeriods               string
+	Cookie                        string
+	SampleConfig                  bool
+	SampleConfigText              string
+	TargetBytes                   int64
+	RunID                         string
+	StackComposite                bool
+	StackDirection                string
+	StackSpacing                  int
+	StatsFile                     string
+	DataFile                      string
+	Dedupe                        bool
+	Incremental                   bool
+	ColorProfile                  string
+	ExternalOut                   string
+	WarnOnRedirectToDifferentHost bool
+	Seed                          int64
+	Sinks                         []string
+	SinkFailFast                  bool
+	Grace                         time.Duration
+	Watermark                     string
+	WatermarkOpacity              float64
+	WatermarkPosition             string
+	WatermarkFontSize             int
+	MinPlaycount                  int
+	PlaceholderTemplate           string
+}
+
+const defaultUserAgent = "eagleusb-collage/1.0"
+
+// templateFlag implements flag.Value for a repeatable -template: each
+// occurrence appends to Config.Templates, while Config.Template tracks the
+// most recent one so single-template callers keep working unmodified.
+type templateFlag struct {
+	cfg *Config
+}
+
+func newTemplateFlag(cfg *Config) *templateFlag {
+	return &templateFlag{cfg: cfg}
+}
+
+func (f *templateFlag) String() string {
+	if f.cfg == nil {
+		return ""
+	}
+	return f.cfg.Template
+}
+
+func (f *templateFlag) Set(value string) error {
+	f.cfg.Template = value
+	f.cfg.Templates = append(f.cfg.Templates, value)
+	return nil
+}
+
+// parseFlags builds a Config from the command-line arguments.
+func parseFlags(args []string) (Config, error) {
+	fs := flag.NewFlagSet("eagleusb", flag.ContinueOnError)
+
+	cfg := Config{}
+	fs.StringVar(&cfg.URL, "url", "", "source URL for the collage image (required)")
+	fs.StringVar(&cfg.CompareURL, "compare-url", "", "second collage URL to fetch alongside -url, for A/B comparison in the output template")
+	fs.Var(newTemplateFlag(&cfg), "template", "path to the README template, \"-\" for stdin, or empty for the built-in default; repeatable with -output-dir to batch-render several templates")
+	fs.StringVar(&cfg.Out, "out", "README.md", "path to write the rendered output, or \"-\" for stdout")
+	fs.StringVar(&cfg.ImageOut, "image-out", "", "also write the fetched image to this external file (gzip-compressed when the format isn't already compressed)")
+	fs.StringVar(&cfg.ExternalOut, "external-out", "", "also render -out's template to this second path, with the image embedded as a link to -image-out instead of a base64 data URI, for distribution channels (e.g. a GitHub profile mirror) that strip data URIs; requires -image-out")
+	fs.StringVar(&cfg.ForceMime, "mime", "", "force this MIME type instead of trusting the response header or sniffer (e.g. when a server reports application/octet-stream)")
+	fs.DurationVar(&cfg.Timeout, "timeout", 30*time.Second, "overall timeout for fetching and encoding the image")
+	fs.StringVar(&cfg.UserAgent, "user-agent", defaultUserAgent, "User-Agent header sent when fetching the collage")
+	fs.IntVar(&cfg.ConnectRetries, "connect-retries", 3, "number of times to retry a fetch that fails to establish a connection (DNS/dial/TLS)")
+	fs.IntVar(&cfg.StatusRetries, "status-retries", 2, "number of times to retry a fetch that gets back a 5xx or 429 response")
+	fs.BoolVar(&cfg.Partial, "partial", false, "tolerate individual image failures instead of aborting the whole run")
+	fs.BoolVar(&cfg.Watch, "watch", false, "keep running, regenerating the output every -watch-interval")
+	fs.DurationVar(&cfg.WatchInterval, "watch-interval", 10*time.Minute, "how often to regenerate in -watch mode")
+	fs.IntVar(&cfg.PNGColors, "png-colors", 0, "quantize the image to this many colors and re-encode as a paletted PNG (0 disables)")
+	fs.DurationVar(&cfg.TimeoutPerImage, "timeout-per-image", 0, "per-image timeout for multi-image runs, independent of -timeout (0 disables, using only the overall deadline)")
+	fs.StringVar(&cfg.TimeoutBudget, "timeout-budget", defaultTimeoutBudget, "comma-separated stage=percent pairs splitting -timeout between \"fetch\" and \"write\" (e.g. \"fetch=70,write=30\"), so a fetch that runs right up to its own slice can't also starve the final write of -out; must sum to 100")
+	fs.StringVar(&cfg.Accept, "accept", "image/webp,image/png,image/jpeg", "Accept header sent when fetching the collage, for content-negotiating sources")
+	fs.StringVar(&cfg.FormatFallback, "format-fallback", "", "comma-separated formats to try in order against the main -url fetch (e.g. \"webp,png\"), setting a \"format\" query parameter and re-requesting until one validates, for sources whose content negotiation is unreliable; disables conditional (ETag) revalidation for that fetch since a 304 isn't meaningful across formats")
+	fs.StringVar(&cfg.Redact, "redact", defaultRedact, "comma-separated substrings of query parameter names to mask as REDACTED wherever a URL is logged, -explain'd or embedded in -embed-buildinfo's provenance comment")
+	fs.BoolVar(&cfg.SelfTest, "self-test", false, "check the template, output directory and source host, then exit without writing anything")
+	fs.BoolVar(&cfg.HealthCheck, "healthcheck", false, "probe -url with a HEAD request and exit 0 if it returns 200 with an image content type, for use by uptime monitors")
+	fs.BoolVar(&cfg.ValidateOnly, "validate-only", false, "fetch -url and decode/validate it like a real run would, then print its format and dimensions and exit, without base64-encoding or rendering a template; heavier than -healthcheck (exercises the real decode path) but far lighter than a full run")
+	fs.BoolVar(&cfg.Interactive, "interactive", false, "ask for confirmation before overwriting an existing -out file (skipped when CI is set)")
+	fs.IntVar(&cfg.Rows, "rows", 3, "number of collage rows requested from the source, used to approximate the rendered album count")
+	fs.IntVar(&cfg.Columns, "columns", 3, "number of collage columns requested from the source, used to approximate the rendered album count")
+	fs.StringVar(&cfg.UserAgentFile, "user-agent-file", "", "file with one User-Agent string per line to round-robin through, instead of -user-agent")
+	fs.IntVar(&cfg.ThumbnailWidth, "thumbnail-width", 0, "embed a thumbnail this many pixels wide as the visible image, linking to the full collage written by -image-out (0 disables)")
+	fs.BoolVar(&cfg.Debug, "debug", false, "emit structured JSON logs of each retry decision to stderr")
+	fs.StringVar(&cfg.LogFile, "log-file", "", "also append structured logs to this file (parent directories created as needed), in whichever -log-format style; unset logs to stderr only")
+	fs.StringVar(&cfg.LogFormat, "log-format", "json", "structured log style, for both stderr and -log-file: \"json\" or \"text\"")
+	fs.Int64Var(&cfg.LogFileMaxBytes, "log-file-max-bytes", defaultLogFileMaxBytes, "rotate -log-file to a single \".1\" backup once it would grow past this many bytes (0 disables rotation, letting it grow unbounded)")
+	fs.BoolVar(&cfg.EmbedBuildInfo, "embed-buildinfo", false, "append a <!-- eagleusb:buildinfo {...} --> HTML comment with the eagleusb version, redacted source URL, image content hash, dimensions and format, and a generation timestamp; invisible in rendered markdown, for tooling to parse as a machine-readable provenance record (richer than -cache-bust's bare hash)")
+	fs.IntVar(&cfg.DecodeConcurrency, "decode-concurrency", runtime.GOMAXPROCS(0), "maximum number of images decoded/encoded at once, independent of fetch concurrency, to bound CPU use on multi-image runs")
+	fs.StringVar(&cfg.OutputDir, "output-dir", "", "with repeated -template, render each into this directory, named after the template with its \".tmpl\" suffix stripped")
+	fs.StringVar(&cfg.ClientCert, "client-cert", "", "client certificate file for mutual TLS against -url (must be paired with -client-key)")
+	fs.StringVar(&cfg.ClientKey, "client-key", "", "client private key file for mutual TLS against -url (must be paired with -client-cert)")
+	fs.BoolVar(&cfg.Picture, "picture", false, "embed the image as a <picture> element with a WebP source and a PNG fallback, instead of a plain markdown image (degrades to a single <img> when only one format is available)")
+	fs.BoolVar(&cfg.GitCommit, "git-commit", false, "after writing -out, stage and commit it in its git repository")
+	fs.StringVar(&cfg.CommitMessageTemplate, "commit-message-template", "", "text/template for the -git-commit message, given {{.OldSize}}, {{.NewSize}}, {{.Format}} and {{.Timestamp}}; empty uses a built-in default")
+	fs.StringVar(&cfg.SourcesConfig, "sources-config", "", "path to a JSON array of {\"name\", \"url\"} sources to fetch, exposed to the template as {{.Images.name}}; overrides -url when set")
+	fs.DurationVar(&cfg.StaleAfter, "stale-after", 0, "in -watch mode, force a full refetch (ignoring conditional-request caching) once the cached image is older than this, erroring rather than serving stale content if the refetch fails (0 disables)")
+	fs.DurationVar(&cfg.MinInterval, "min-interval", 0, "refuse to run (exiting 0) if less than this has passed since the last successful run, tracked in a manifest next to -out; guards against cron misconfigurations firing too often (0 disables)")
+	fs.BoolVar(&cfg.Force, "force", false, "run even if -min-interval hasn't elapsed yet")
+	fs.BoolVar(&cfg.StripEXIF, "strip-exif", false, "remove APP1 (EXIF/XMP) metadata segments from JPEG images before embedding, for privacy when the source is a personal photo (GPS, camera); off by default since most sources aren't")
+	fs.StringVar(&cfg.TargetFile, "target-file", "", "instead of rendering -template, replace every occurrence of -replace-token in this file with the data URI, in place (atomically)")
+	fs.StringVar(&cfg.ReplaceToken, "replace-token", "", "token to replace in -target-file; required when -target-file is set, errors if not found")
+	fs.DurationVar(&cfg.Since, "since", 0, "skip regeneration (exiting 0) if -url's Last-Modified header is older than this; sources that don't send Last-Modified are never skipped here and rely on ETag-based caching instead (0 disables)")
+	fs.BoolVar(&cfg.CacheBust, "cache-bust", false, "embed an HTML comment with a short content hash next to the image, so tooling that caches the surrounding markup (not just the data URI) notices when the image changes")
+	fs.StringVar(&cfg.Lang, "lang", defaultLang, "language for generated alt text/captions, and forwarded as the Accept-Language header (in case the source localizes its response)")
+	fs.BoolVar(&cfg.FastValidate, "fast-validate", false, "for PNG/JPEG/GIF, only check the format's magic-byte signature instead of fully decoding, to fail fast on an obviously corrupt header on large images (SVG and WebP are unaffected, they're already header-level checks)")
+	fs.BoolVar(&cfg.OutputStdoutJSON, "output-stdout-json", false, "print a {changed, bytes, mimeType, outputPath, durationMs, error} JSON run report to stdout instead of the usual messages; all logging still goes to stderr")
+	fs.StringVar(&cfg.GridConfig, "grid-config", "", "path to a JSON {baseURL, methods, periods} matrix of collages (e.g. albums/artists x 7day/overall), fetched concurrently and exposed to the template as a 2D {{.Grid}}; overrides -url and -sources-config when set")
+	fs.BoolVar(&cfg.Normalize, "normalize", false, "trim trailing whitespace from each line of the rendered output and ensure exactly one trailing newline, to keep diffs clean across template edits (never touches the data URI payload, which never contains a newline)")
+	fs.StringVar(&cfg.LineEnding, "line-ending", lineEndingLF, "line ending to write the rendered output with, \"lf\" or \"crlf\"; applied uniformly regardless of platform, to avoid gitattributes churn (the data URI payload is unaffected either way)")
+	fs.StringVar(&cfg.DownloadOnly, "download-only", "", "fetch, validate and cache -url's image plus a manifest at this path, then exit without rendering anything; pair with -from-cache on a machine without network access")
+	fs.StringVar(&cfg.FromCache, "from-cache", "", "generate using the image and manifest previously written by -download-only at this path, without making any network request; overrides -url, -sources-config and -grid-config when set")
+	fs.Var(newAllowHostsFlag(&cfg), "allow-host", "repeatable; when given at least once, refuse to connect (including across redirects) to any host not in this list, and to any host resolving to a private, loopback or link-local address, for SSRF protection against user-supplied URLs")
+	fs.BoolVar(&cfg.WarnOnRedirectToDifferentHost, "warn-on-redirect-to-different-host", false, "warn (or, with -fail-on-warning, fail) when -url's final response came from a different host than requested, recording both hosts in the manifest next to -out; passive monitoring for a hijacked redirect or a surprise CDN change, complementing -allow-host's hard enforcement")
+	fs.StringVar(&cfg.OGOut, "og-out", "", "also composite -og-text/-og-subtext onto a 1200x630 copy of the collage and write it here (.jpg/.jpeg for JPEG, otherwise PNG), for use as an Open Graph/social preview image")
+	fs.StringVar(&cfg.OGText, "og-text", "", "primary text to overlay on the -og-out image (e.g. a username); supports uppercase letters, digits and basic punctuation only, via a small bundled bitmap font")
+	fs.StringVar(&cfg.OGSubtext, "og-subtext", "", "secondary text drawn below -og-text on the -og-out image (e.g. the listening period)")
+	fs.IntVar(&cfg.OGTextX, "og-text-x", 60, "x position of the top-left of the -og-text block on the -og-out image")
+	fs.IntVar(&cfg.OGTextY, "og-text-y", 480, "y position of the top-left of the -og-text block on the -og-out image")
+	fs.IntVar(&cfg.OGFontSize, "og-font-size", 6, "pixels per bitmap-font dot when drawing -og-text/-og-subtext")
+	fs.StringVar(&cfg.Preset, "preset", "", "named -png-colors shortcut: \"small\" (32 colors), \"balanced\" (128 colors) or \"quality\" (0, lossless); an explicit -png-colors always wins over the preset")
+	fs.BoolVar(&cfg.Trace, "trace", false, "log DNS/connect/TLS-handshake/TTFB timing breakdowns for each fetch at debug level and include them in the run-report JSON")
+	fs.BoolVar(&cfg.FailOnWarning, "fail-on-warning", false, "exit non-zero if any non-fatal warning (e.g. an animated WebP) was emitted during the run, for strict CI; warnings remain non-fatal by default. Not meaningful with -watch, which never exits on its own")
+	fs.IntVar(&cfg.ImageDiffThreshold, "image-diff-threshold", 0, "warn (or, with -fail-on-warning, fail) if the primary image's average hash differs from the previous run's, stored in the manifest next to -out, by more than this many bits out of 64 (0 disables; PNG/JPEG/GIF only, WebP and SVG can't be decoded to pixels here)")
+	fs.BoolVar(&cfg.ListPeriods, "list-periods", false, "print the conventional Last.fm collage period values (7day, 1month, ..., overall) and exit; -url isn't required")
+	fs.BoolVar(&cfg.ListMethods, "list-methods", false, "print the conventional Last.fm collage method values (albums, artists, tracks) and exit; -url isn't required")
+	fs.StringVar(&cfg.GistToken, "gist-token", "", "GitHub personal access token with gist scope; when set, the rendered output is uploaded to a GitHub Gist instead of written to -out (-out is never touched)")
+	fs.StringVar(&cfg.GistID, "gist-id", "", "ID of an existing gist to update; empty creates a new gist and reports its ID, for a profile's first run (requires -gist-token)")
+	fs.StringVar(&cfg.GistFilename, "gist-filename", "README.md", "filename the rendered output is uploaded under within the gist")
+	fs.Float64Var(&cfg.WatchJitter, "watch-jitter", 0, "randomize each -watch cycle's sleep by up to this percentage (0-100) in either direction, so multiple deployments sharing a -watch-interval don't all fetch at once (0 disables)")
+	fs.StringVar(&cfg.Cron, "cron", "", "a 5-field cron expression (minute hour day-of-month month day-of-week) to align -watch runs to instead of a fixed -watch-interval, e.g. \"0 * * * *\" for the top of every hour; -watch-jitter still applies to the computed wait")
+	fs.BoolVar(&cfg.Optimize, "optimize", false, "down-convert 16-bit-per-channel PNGs to 8-bit during encoding to shrink the embed, without quantizing colors like -png-colors does; a no-op for already-8-bit sources and non-PNG formats")
+	fs.StringVar(&cfg.TemplateDir, "template-dir", "", "parse every file in this directory as one associated template set via template.ParseGlob, so the entry template can {{ template \"partial\" . }} the others; pair with -template-name, and use instead of -template")
+	fs.StringVar(&cfg.TemplateName, "template-name", "", "name of the entry template to execute within -template-dir (its {{define \"name\"}} or filename); required when -template-dir is set")
+	fs.StringVar(&cfg.CompareWithRemote, "compare-with-remote", "", "fetch the published README at this URL, regenerate locally from current source data, and report whether they match, exiting non-zero on mismatch; -url and -template still apply")
+	fs.StringVar(&cfg.CompareIgnoreRegex, "compare-ignore-regex", "", "regexp matched against both the local and remote README before -compare-with-remote compares them, so a region expected to differ every run (e.g. a hand-written timestamp) doesn't cause a false mismatch")
+	fs.BoolVar(&cfg.FallbackLink, "fallback-link", false, "expose a redacted {{.SourceURL}} pointing at the live collage, and have the built-in default template link to it beneath the embedded image, for viewers that don't render inline data URIs (e.g. some RSS readers); custom templates decide for themselves whether to use {{.SourceURL}}")
+	fs.BoolVar(&cfg.Responsive, "responsive", false, "have the built-in default template render the embedded image as <img style=\"max-width:100%;height:auto\">, with intrinsic width/height attributes when the source format decodes to pixels, to avoid layout shift; custom templates decide for themselves whether to use {{.ImageWidth}}/{{.ImageHeight}}")
+	fs.StringVar(&cfg.Serve, "serve", "", "run as an HTTP server on this address (e.g. \":8080\") instead of a one-shot run, rendering the README at \"/\", the raw collage image at \"/image\" and cache counters at \"/metrics\" on demand; query params url/rows/columns/lang override the corresponding option per request, ?nocache=1 bypasses the cache, and -url becomes optional (but still sets the default)")
+	fs.DurationVar(&cfg.ServeCacheTTL, "serve-cache-ttl", 30*time.Second, "how long -serve caches a rendered response in memory per distinct (sorted) set of query params before re-running the pipeline (0 disables caching)")
+	fs.IntVar(&cfg.ServeCacheSize, "serve-cache-size", 100, "maximum number of distinct query-param combinations -serve keeps cached at once, evicting the least recently used entry once full")
+	fs.BoolVar(&cfg.NoLock, "no-lock", false, "skip the exclusive file lock normally taken on -out (or -target-file/-output-dir) before running, for callers that already guarantee non-overlapping runs themselves")
+	fs.DurationVar(&cfg.LockTimeout, "lock-timeout", 0, "how long to wait for another run's lock on the output to clear before giving up (0 fails immediately if it's already held)")
+	fs.BoolVar(&cfg.Explain, "explain", false, "print the resolved URL, timeouts, template and output target, and the conversions that would be applied, then exit without making any network request")
+	fs.StringVar(&cfg.ImageBase64, "image-base64", "", "base64-encoded image data to use instead of fetching -url, for pipelines that produce the image in an earlier stage; overrides -url, -sources-config, -grid-config and -from-cache when set")
+	fs.StringVar(&cfg.ImageBase64Env, "image-base64-env", "", "name of an environment variable holding base64-encoded image data, as an alternative to -image-base64 for values too large to pass as a command-line argument")
+	fs.StringVar(&cfg.DateLayout, "date-layout", "2006-01-02", "Go reference-time layout used to format the \"{date}\" placeholder in -out (e.g. \"-out archive/README-{date}.md\" writes a distinctly named file each run)")
+	fs.IntVar(&cfg.PruneKeep, "prune-keep", 0, "with a \"{date}\" placeholder in -out, delete older dated outputs beyond the newest this many (by modification time) after writing a new one (0 disables); a no-op when -out has no \"{date}\" placeholder")
+	fs.DurationVar(&cfg.PruneOlderThan, "prune-older-than", 0, "with a \"{date}\" placeholder in -out, delete dated outputs older than this after writing a new one (0 disables); combines with -prune-keep if both are set, and is a no-op when -out has no \"{date}\" placeholder")
+	fs.BoolVar(&cfg.Srcset, "srcset", false, "embed both a half-width \"1x\" and full-resolution \"2x\" data URI in an <img srcset=\"...\"> for Retina displays, decoded and re-encoded as PNG from one fetch; roughly 1.25x's the embedded size over a single image (PNG/JPEG/GIF only, WebP and SVG can't be decoded to pixels here)")
+	fs.StringVar(&cfg.PostCommand, "post-command", "", "pipe the rendered output through this shell command (run via \"sh -c\") before writing it, and use its stdout as the final content; a non-zero exit fails the run with the command's stderr (e.g. \"prettier --parser markdown\")")
+	fs.StringVar(&cfg.Jitter, "jitter", jitterFull, "retry backoff jitter strategy: \"full\" (uniformly random up to the backoff cap), \"equal\" (half the cap, plus up to half more at random) or \"none\" (the backoff cap unchanged); full and equal jitter decorrelate many instances retrying the same flaky source on the same schedule")
+	fs.BoolVar(&cfg.ValidateMarkdown, "validate-markdown", false, "after rendering (and -post-command, if set), check that the result is well-formed Markdown -- specifically, that every image/link reference has balanced \"[...]\"/\"(...)\" and every <picture> tag is closed -- and fail the run with line numbers on mismatches instead of writing it")
+	fs.StringVar(&cfg.Stack, "stack", "", "comma-separated list of collage methods (e.g. \"albums,artists\") to fetch from -url via buildCollageURL and render vertically stacked; an ergonomic shortcut over -sources-config for the common \"top albums over top artists\" layout, overriding -url's single fetch when set")
+	fs.StringVar(&cfg.StackPeriod, "stack-period", "overall", "period query parameter held fixed across every -stack method (see -list-periods for conventional values)")
+	fs.Int64Var(&cfg.MaxTotalBytes, "max-total-bytes", 0, "fail the run if the combined base64 payload of every embedded image exceeds this many bytes (0 disables); with -optimize, the largest images are progressively re-quantized to fewer colors first to try to fit")
+	fs.StringVar(&cfg.TextLocation, "text-location", "", "set the \"textlocation\" query parameter on every URL buildCollageURL builds for -grid-config/-stack, controlling where the collage service draws its baked-in labels (one of \"top\", \"topleft\", \"topcentre\", \"topright\", \"bottom\", \"bottomleft\", \"bottomcentre\", \"bottomright\"); unset leaves the parameter off entirely, mutually exclusive with -no-text")
+	fs.BoolVar(&cfg.NoText, "no-text", false, "omit the \"textlocation\" query parameter from every -grid-config/-stack URL, for a source that draws no labels when it's absent; mutually exclusive with -text-location")
+	fs.StringVar(&cfg.TimeTemplateConfig, "time-template-config", "", "path to a JSON array of {\"start\", \"end\", \"template\"} time-of-day windows (24h local \"HH:MM\"); the window containing the current local time picks -template for this run, falling back to -template itself outside every window; re-evaluated on each run, so -watch can switch templates as the day goes on; mutually exclusive with -template-dir")
+	fs.Int64Var(&cfg.MaxBytes, "max-bytes", 0, "reject a single fetched response larger than this many bytes, enforced against the actual bytes read rather than a declared Content-Length, so it still catches an oversized chunked response with no such header; 0 disables the check")
+	fs.IntVar(&cfg.DiffContext, "diff-context", 3, "number of unchanged context lines -compare-with-remote shows around each change on a MISMATCH, like diff -U; the embedded image's data URI line is always collapsed to an \"image data changed (NKB -> MKB)\" summary regardless of this setting")
+	fs.IntVar(&cfg.OGJPEGQuality, "og-jpeg-quality", 0, "JPEG quality (1-100) for a .jpg/.jpeg -og-out; 0 uses the stdlib encoder's own default (around 75); higher trades file size for fidelity")
+	fs.StringVar(&cfg.OGJPEGChromaSubsampling, "og-jpeg-chroma-subsampling", "", "chroma subsampling for a .jpg/.jpeg -og-out: \"4:2:0\" (smaller, the usual choice for photos) or \"4:4:4\" (larger, keeps full color detail, better for the sharp text/edges on an OG card); Go's stdlib image/jpeg encoder can only write 4:2:0, so \"4:4:4\" is accepted but has no effect - see encodeOGJPEG; unset behaves like \"4:2:0\"")
+	fs.BoolVar(&cfg.OGJPEGProgressive, "og-jpeg-progressive", false, "write a progressive rather than baseline .jpg/.jpeg -og-out, which renders as a blurry preview that sharpens while loading; Go's stdlib image/jpeg encoder only writes baseline JPEGs, so this is accepted but has no effect - see encodeOGJPEG")
+	fs.StringVar(&cfg.AuthTokenFile, "auth-token-file", "", "path to a JSON {access_token, refresh_token, expires_at} file (see tokenstore.go) sent as an \"Authorization: Bearer\" header on every fetch; there is no OS keyring integration here (this tree has no cgo or third-party dependencies), so the file itself is the whole store - keep it somewhere already locked down, it's written 0600")
+	fs.StringVar(&cfg.AuthRefreshCommand, "auth-refresh-command", "", "shell command (run via \"sh -c\", with the current refresh token as its argument) run to refresh an expired -auth-token-file; must print {access_token, refresh_token, expires_in} JSON to stdout, which is persisted back to -auth-token-file; requires -auth-token-file, and failures fail the run (re-run the command, or re-authenticate and overwrite -auth-token-file by hand) rather than fetching unauthenticated")
+	fs.BoolVar(&cfg.Spool, "spool", false, "stream the fetched image to a temp file instead of buffering it in memory, validating and base64-encoding from the file (always removed afterward, even on error), to bound peak memory on very large images; only takes effect when no other option needs the raw bytes in memory anyway (-strip-exif, -optimize, -png-colors, -thumbnail-width, -responsive, -embed-buildinfo, -srcset, -picture, -cache-bust, -image-out, -og-out, -image-diff-threshold, or an ImageProcessor) - with any of those set, the normal in-memory path runs instead")
+	fs.BoolVar(&cfg.StrictTemplate, "strict-template", true, "fail the render if the template references an undefined key on a map-typed field (currently only TemplateData.Images, e.g. {{ .Images.typo }} for a name absent from -sources-config), via Go's text/template Option(\"missingkey=error\"); a struct field typo always fails regardless of this setting. Default to strict to prevent silent breakage; set false to fall back to the stdlib default of rendering \"<no value>\"")
+	fs.BoolVar(&cfg.UpdatedBadge, "updated-badge", false, "populate TemplateData.UpdatedBadgeURL with a shields.io \"updated | <date>\" badge URL (see badge.go), for a small profile-README adornment; the date is baked in at generation time (shields.io has no server of ours to compute a live \"2h ago\" age against), so pair this with -watch or a scheduled run to keep it current. Off by default")
+	fs.BoolVar(&cfg.RunSummary, "run-summary", false, "after a -grid-config, -stack, -sources-config or -parallel-periods run, print a table to stderr naming each period/method/source, whether it succeeded, its size and fetch duration; with -partial, a failed item still appears with its error reason instead of silently vanishing from the output. Off by default")
+	fs.StringVar(&cfg.URLTemplate, "url-template", "", "build -url by substituting {username}, {period}, {rows} and {columns} placeholders (URL-query-escaped) into this template instead of requiring songstitch's exact query parameter names, for an arbitrary collage provider; mutually exclusive with -url, and every placeholder used must be one of the four supported ones")
+	fs.StringVar(&cfg.Username, "username", "", "value substituted for {username} in -url-template")
+	fs.StringVar(&cfg.Period, "period", "", "value substituted for {period} in -url-template")
+	fs.StringVar(&cfg.ParallelPeriods, "parallel-periods", "", "comma-separated period values (see -list-periods) to fetch concurrently, substituting each for -url-template's {period} placeholder in turn; the resulting images are exposed to the template as TemplateData.Images keyed by period, the same map -sources-config populates, and -run-summary's table covers them too. Requires -url-template, and is mutually exclusive with -url, -stack, -grid-config and -sources-config")
+	fs.StringVar(&cfg.Cookie, "cookie", "", "literal Cookie header value sent with every fetch, e.g. a session cookie copied from a browser that already solved a Cloudflare challenge (see antibot.go and ErrAntiBot) for a source otherwise stuck behind one")
+	fs.BoolVar(&cfg.SampleConfig, "sample-config", false, "print a fully-commented sample listing every flag and its default value, then exit; generated directly from the registered flag set (see sampleconfig.go) so it can't drift out of sync as flags are added or changed; -url and everything else below is skipped")
+	fs.Int64Var(&cfg.TargetBytes, "target-bytes", 0, "re-encode the image as JPEG, binary-searching quality (1-100, capped at a handful of attempts) for the highest value whose output still fits this many bytes, and report the quality and size found; there's no WebP encoder in this tree to search over instead (0 disables, leaving the image exactly as fetched)")
+	fs.StringVar(&cfg.RunID, "run-id", "", "request/run ID to correlate this run's structured log lines and run-report JSON, e.g. across a server deployment's logs; empty generates a random one per run (in -serve mode, an incoming request header takes precedence over this default - see serve.go)")
+	fs.BoolVar(&cfg.StackComposite, "stack-composite", false, "composite -stack's images into a single sprite via image/draw and embed just that one data URI, instead of one data URI per method; see -stack-direction and -stack-spacing")
+	fs.StringVar(&cfg.StackDirection, "stack-direction", "vertical", "layout direction for -stack-composite: \"vertical\" (top to bottom) or \"horizontal\" (left to right)")
+	fs.IntVar(&cfg.StackSpacing, "stack-spacing", 0, "pixels of transparent spacing between images for -stack-composite")
+	fs.StringVar(&cfg.StatsFile, "stats-file", "", "append one CSV row per run (timestamp, width, height, bytes, format, fetch_duration_ms) to this path, creating it with a header if it doesn't exist yet; for charting a collage's characteristics over time. Only covers the default single-image/-compare-url fetch path, not -sources-config/-grid-config/-stack, which don't have one well-defined image to report on")
+	fs.StringVar(&cfg.DataFile, "data-file", "", "path to a JSON object merged into the template data as .Custom (e.g. {\"tagline\": \"...\"} lets a template use {{.Custom.tagline}}), read fresh every run so editing it doesn't require restarting -watch; there's no YAML decoder in the standard library and this tree takes no third-party dependencies, so despite the name only JSON is supported")
+	fs.BoolVar(&cfg.Dedupe, "dedupe", false, "when fetching more than one image (-stack), collapse byte-identical results so a duplicate is noted in the template instead of re-embedding the same data URI; useful when sparse listening history makes e.g. a 7-day and 1-month collage come out identical")
+	fs.BoolVar(&cfg.Incremental, "incremental", false, "persist each -stack method's ETag and encoded data URI in the manifest next to -out, issue a conditional request per method on the next run, and reuse the cached data URI for any that come back 304; skips writing -out entirely if no method changed. Requires -stack and is incompatible with -stack-composite, which needs every image's raw bytes to rebuild the sprite")
+	fs.StringVar(&cfg.ColorProfile, "color-profile", "preserve", "\"preserve\" (default) leaves an embedded ICC color profile alone, or \"strip\" removes it (JPEG and PNG only) before embedding, reporting the bytes saved to stderr; stripping can shrink photographic collages but may shift color rendering in viewers that relied on the profile")
+	fs.Int64Var(&cfg.Seed, "seed", 0, "seed for every randomized behavior in this run (-jitter, -watch-jitter), for reproducible tests and debugging; 0 (the default) seeds from the current time and process instead, so runs are non-deterministic as before")
+	fs.Var(newSinksFlag(&cfg), "sink", "repeatable; additional destination for the rendered output alongside -out: \"file:<path>\", \"stdout\", \"gist\" (reuses -gist-token/-gist-id/-gist-filename) or \"http-post:<url>\"; e.g. \"-sink gist -sink http-post:https://example.com/hook\" writes -out locally and also pushes a gist and notifies a webhook from the same run")
+	fs.BoolVar(&cfg.SinkFailFast, "sink-fail-fast", false, "abort the run on the first -sink failure instead of reporting it to stderr and continuing with the rest")
+	fs.DurationVar(&cfg.Grace, "grace", 0, "on a failed run, keep retrying the whole fetch/decode/render pipeline with backoff until it succeeds or this long has passed since the first failure, only then failing the run; separate from -connect-retries/-status-retries, which bound individual HTTP requests inside one attempt, not the run as a whole. For scheduled monitoring, so one bad cycle doesn't immediately read as downtime. 0 (the default) disables this and fails on the first error, as before")
+	fs.StringVar(&cfg.Watermark, "watermark", "", "overlay this text (e.g. a handle or URL) faintly onto the collage image before embedding, to deter casual scraping; drawn with the same bundled bitmap font -og-text uses (see font5x7 in ogimage.go), so only uppercase ASCII letters, digits, space and a handful of punctuation render. Empty (the default) disables it, leaving the image byte-for-byte untouched - implemented as a built-in ImageProcessor (see watermark.go), so setting it forces a decode/re-encode the same way an ImageProcessor set from library code would")
+	fs.Float64Var(&cfg.WatermarkOpacity, "watermark-opacity", 0.15, "opacity (0-1) of -watermark's text; lower is fainter")
+	fs.StringVar(&cfg.WatermarkPosition, "watermark-position", "bottom-right", "corner (or \"center\") -watermark's text is anchored to: \"top-left\", \"top-right\", \"bottom-left\", \"bottom-right\" or \"center\"")
+	fs.IntVar(&cfg.WatermarkFontSize, "watermark-font-size", 2, "pixels per bitmap-font dot for -watermark's text, same scale -og-font-size uses for -og-text")
+	fs.IntVar(&cfg.MinPlaycount, "min-playcount", 0, "skip embedding the collage and render -placeholder-template instead when the source reports, via an X-Total-Playcount response header, a total playcount below this - for a brand new Last.fm profile with no scrobbles, where the collage itself would just be an empty grid. A source that doesn't send the header is never gated. 0 (the default) disables the check entirely")
+	fs.StringVar(&cfg.PlaceholderTemplate, "placeholder-template", "", "template to render instead of the normal one when -min-playcount triggers; empty (the default) uses a small built-in placeholder message. Ignored unless -min-playcount is also set")
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	expandEnvConfigPaths(&cfg)
+
+	if cfg.ListPeriods || cfg.ListMethods {
+		return cfg, nil
+	}
+
+	if cfg.SampleConfig {
+		cfg.SampleConfigText = generateSampleConfig(fs)
+		return cfg, nil
+	}
+
+	if err := validatePreset(cfg.Preset); err != nil {
+		return Config{}, err
+	}
+	if cfg.Preset != "" {
+		explicitPNGColors := false
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "png-colors" {
+				explicitPNGColors = true
+			}
+		})
+		if !explicitPNGColors {
+			cfg.PNGColors = presetPNGColors[cfg.Preset]
+		}
+	}
+
+	if cfg.URLTemplate != "" {
+		if cfg.URL != "" {
+			return Config{}, fmt.Errorf("-url and -url-template are mutually exclusive")
+		}
+		if cfg.ParallelPeriods == "" {
+			resolved, err := resolveURLTemplate(cfg.URLTemplate, map[string]string{
+				"username": cfg.Username,
+				"period":   cfg.Period,
+				"rows":     strconv.Itoa(cfg.Rows),
+				"columns":  strconv.Itoa(cfg.Columns),
+			})
+			if err != nil {
+				return Config{}, err
+			}
+			cfg.URL = resolved
+		}
+	}
+
+	if cfg.ParallelPeriods != "" {
+		if cfg.URLTemplate == "" {
+			return Config{}, fmt.Errorf("-parallel-periods requires -url-template")
+		}
+		if cfg.Stack != "" || cfg.SourcesConfig != "" || cfg.GridConfig != "" {
+			return Config{}, fmt.Errorf("-parallel-periods is mutually exclusive with -stack, -sources-config and -grid-config")
+		}
+		for _, period := range strings.Split(cfg.ParallelPeriods, ",") {
+			if strings.TrimSpace(period) == "" {
+				return Config{}, fmt.Errorf("-parallel-periods contains an empty period, got %q", cfg.ParallelPeriods)
+			}
+		}
+	}
+
+	if cfg.URL == "" && cfg.SourcesConfig == "" && cfg.GridConfig == "" && cfg.FromCache == "" && cfg.Serve == "" && cfg.ImageBase64 == "" && cfg.ImageBase64Env == "" && cfg.ParallelPeriods == "" {
+		return Config{}, fmt.Errorf("-url is required unless -sources-config, -grid-config, -parallel-periods, -from-cache, -image-base64, -image-base64-env or -serve is given")
+	}
+
+	if cfg.ImageBase64 != "" && cfg.ImageBase64Env != "" {
+		return Config{}, fmt.Errorf("-image-base64 and -image-base64-env are mutually exclusive")
+	}
+
+	if cfg.Stack != "" {
+		if cfg.URL == "" {
+			return Config{}, fmt.Errorf("-url is required when -stack is set")
+		}
+		if cfg.SourcesConfig != "" || cfg.GridConfig != "" {
+			return Config{}, fmt.Errorf("-stack is mutually exclusive with -sources-config and -grid-config")
+		}
+		for _, method := range strings.Split(cfg.Stack, ",") {
+			if strings.TrimSpace(method) == "" {
+				return Config{}, fmt.Errorf("-stack contains an empty method, got %q", cfg.Stack)
+			}
+		}
+	} else if cfg.StackComposite {
+		return Config{}, fmt.Errorf("-stack-composite requires -stack")
+	} else if cfg.Incremental {
+		return Config{}, fmt.Errorf("-incremental requires -stack")
+	}
+	if cfg.Incremental && cfg.StackComposite {
+		return Config{}, fmt.Errorf("-incremental is not supported with -stack-composite, which needs every image's raw bytes on every run to build the sprite")
+	}
+
+	if cfg.ColorProfile != "preserve" && cfg.ColorProfile != "strip" {
+		return Config{}, fmt.Errorf("-color-profile must be \"preserve\" or \"strip\", got %q", cfg.ColorProfile)
+	}
+
+	if cfg.ExternalOut != "" && cfg.ImageOut == "" {
+		return Config{}, fmt.Errorf("-external-out requires -image-out")
+	}
+
+	if err := validateCompositeDirection(cfg.StackDirection); err != nil {
+		return Config{}, err
+	}
+	if cfg.StackSpacing < 0 {
+		return Config{}, fmt.Errorf("-stack-spacing must not be negative, got %d", cfg.StackSpacing)
+	}
+
+	if _, err := parseTimeoutBudget(cfg.TimeoutBudget); err != nil {
+		return Config{}, err
+	}
+
+	if _, err := parseFormatFallback(cfg.FormatFallback); err != nil {
+		return Config{}, err
+	}
+	if _, err := parseSinks(cfg.Sinks); err != nil {
+		return Config{}, err
+	}
+	if cfg.Grace < 0 {
+		return Config{}, fmt.Errorf("-grace must not be negative, got %s", cfg.Grace)
+	}
+	if cfg.Watermark != "" {
+		if cfg.WatermarkOpacity < 0 || cfg.WatermarkOpacity > 1 {
+			return Config{}, fmt.Errorf("-watermark-opacity must be between 0 and 1, got %v", cfg.WatermarkOpacity)
+		}
+		if !watermarkPositions[cfg.WatermarkPosition] {
+			return Config{}, fmt.Errorf("-watermark-position must be one of top-left, top-right, bottom-left, bottom-right or center, got %q", cfg.WatermarkPosition)
+		}
+		if cfg.WatermarkFontSize <= 0 {
+			return Config{}, fmt.Errorf("-watermark-font-size must be positive, got %d", cfg.WatermarkFontSize)
+		}
+	}
+	if cfg.MinPlaycount < 0 {
+		return Config{}, fmt.Errorf("-min-playcount must not be negative, got %d", cfg.MinPlaycount)
+	}
+
+	if cfg.TargetFile != "" && cfg.ReplaceToken == "" {
+		return Config{}, fmt.Errorf("-replace-token is required when -target-file is set")
+	}
+
+	if err := validateLineEnding(cfg.LineEnding); err != nil {
+		return Config{}, err
+	}
+
+	if err := validateJitterStrategy(cfg.Jitter); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.DownloadOnly != "" && cfg.URL == "" {
+		return Config{}, fmt.Errorf("-url is required when -download-only is set")
+	}
+
+	if cfg.GistID != "" && cfg.GistToken == "" {
+		return Config{}, fmt.Errorf("-gist-token is required when -gist-id is set")
+	}
+
+	if cfg.WatchJitter < 0 || cfg.WatchJitter > 100 {
+		return Config{}, fmt.Errorf("-watch-jitter must be between 0 and 100, got %v", cfg.WatchJitter)
+	}
+
+	if cfg.Cron != "" {
+		if !cfg.Watch {
+			return Config{}, fmt.Errorf("-cron requires -watch")
+		}
+		if _, err := parseCronSchedule(cfg.Cron); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if cfg.TemplateDir != "" {
+		if cfg.TemplateName == "" {
+			return Config{}, fmt.Errorf("-template-name is required when -template-dir is set")
+		}
+		if cfg.Template != "" {
+			return Config{}, fmt.Errorf("-template-dir and -template are mutually exclusive")
+		}
+	} else if cfg.TemplateName != "" {
+		return Config{}, fmt.Errorf("-template-name requires -template-dir")
+	}
+
+	if cfg.CompareIgnoreRegex != "" {
+		if cfg.CompareWithRemote == "" {
+			return Config{}, fmt.Errorf("-compare-ignore-regex requires -compare-with-remote")
+		}
+		if _, err := regexp.Compile(cfg.CompareIgnoreRegex); err != nil {
+			return Config{}, fmt.Errorf("-compare-ignore-regex: %w", err)
+		}
+	}
+
+	if cfg.ServeCacheTTL < 0 {
+		return Config{}, fmt.Errorf("-serve-cache-ttl must not be negative, got %v", cfg.ServeCacheTTL)
+	}
+
+	if cfg.ServeCacheSize < 0 {
+		return Config{}, fmt.Errorf("-serve-cache-size must not be negative, got %d", cfg.ServeCacheSize)
+	}
+
+	if cfg.LockTimeout < 0 {
+		return Config{}, fmt.Errorf("-lock-timeout must not be negative, got %v", cfg.LockTimeout)
+	}
+
+	if cfg.PruneKeep < 0 {
+		return Config{}, fmt.Errorf("-prune-keep must not be negative, got %d", cfg.PruneKeep)
+	}
+
+	if cfg.PruneOlderThan < 0 {
+		return Config{}, fmt.Errorf("-prune-older-than must not be negative, got %v", cfg.PruneOlderThan)
+	}
+
+	if cfg.MaxTotalBytes < 0 {
+		return Config{}, fmt.Errorf("-max-total-bytes must not be negative, got %d", cfg.MaxTotalBytes)
+	}
+
+	if cfg.TextLocation != "" && cfg.NoText {
+		return Config{}, fmt.Errorf("-text-location and -no-text are mutually exclusive")
+	}
+
+	if err := validateTextLocation(cfg.TextLocation); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.TimeTemplateConfig != "" && cfg.TemplateDir != "" {
+		return Config{}, fmt.Errorf("-time-template-config and -template-dir are mutually exclusive")
+	}
+
+	if cfg.MaxBytes < 0 {
+		return Config{}, fmt.Errorf("-max-bytes must not be negative, got %d", cfg.MaxBytes)
+	}
+
+	if cfg.DiffContext < 0 {
+		return Config{}, fmt.Errorf("-diff-context must not be negative, got %d", cfg.DiffContext)
+	}
+
+	if cfg.OGJPEGQuality < 0 || cfg.OGJPEGQuality > 100 {
+		return Config{}, fmt.Errorf("-og-jpeg-quality must be between 0 and 100, got %d", cfg.OGJPEGQuality)
+	}
+
+	if cfg.OGJPEGChromaSubsampling != "" && cfg.OGJPEGChromaSubsampling != "4:2:0" && cfg.OGJPEGChromaSubsampling != "4:4:4" {
+		return Config{}, fmt.Errorf("-og-jpeg-chroma-subsampling must be \"4:2:0\" or \"4:4:4\", got %q", cfg.OGJPEGChromaSubsampling)
+	}
+
+	if cfg.LogFormat != "json" && cfg.LogFormat != "text" {
+		return Config{}, fmt.Errorf("-log-format must be \"json\" or \"text\", got %q", cfg.LogFormat)
+	}
+
+	if cfg.LogFileMaxBytes < 0 {
+		return Config{}, fmt.Errorf("-log-file-max-bytes must not be negative, got %d", cfg.LogFileMaxBytes)
+	}
+
+	if cfg.AuthRefreshCommand != "" && cfg.AuthTokenFile == "" {
+		return Config{}, fmt.Errorf("-auth-token-file is required when -auth-refresh-command is set")
+	}
+
+	if cfg.TargetBytes < 0 {
+		return Config{}, fmt.Errorf("-target-bytes must not be negative, got %d", cfg.TargetBytes)
+	}
+
+	return cfg, nil
+}
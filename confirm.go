@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// confirmOverwrite prompts before clobbering an existing output file when
+// -interactive is set. It's a no-op when -out is "-", the file doesn't
+// exist yet, or CI is set (so pipelines never block on a prompt). Outside
+// CI, a non-interactive stdin (no TTY) defaults to aborting rather than
+// silently overwriting.
+func confirmOverwrite(cfg Config) error {
+	if !cfg.Interactive || cfg.Out == stdioPlaceholder {
+		return nil
+	}
+	if _, err := os.Stat(cfg.Out); os.IsNotExist(err) {
+		return nil
+	}
+	if os.Getenv("CI") != "" {
+		return nil
+	}
+	if !isTerminal(os.Stdin) {
+		return fmt.Errorf("%s already exists and stdin isn't a terminal; rerun interactively to confirm, or drop -interactive to overwrite unconditionally", cfg.Out)
+	}
+
+	fmt.Fprintf(os.Stderr, "eagleusb: overwrite %s? [y/N] ", cfg.Out)
+	var resp string
+	fmt.Fscanln(os.Stdin, &resp)
+	if resp = strings.ToLower(strings.TrimSpace(resp)); resp != "y" && resp != "yes" {
+		return fmt.Errorf("aborted: declined to overwrite %s", cfg.Out)
+	}
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfirmOverwrite_NonTTYAborts(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "README.md")
+	if err := os.WriteFile(out, []byte("existing"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CI", "")
+
+	err := confirmOverwrite(Config{Interactive: true, Out: out})
+	if err == nil {
+		t.Fatal("expected confirmOverwrite to abort without a TTY")
+	}
+}
+
+func TestConfirmOverwrite_SkippedInCI(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "README.md")
+	if err := os.WriteFile(out, []byte("existing"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CI", "true")
+
+	if err := confirmOverwrite(Config{Interactive: true, Out: out}); err != nil {
+		t.Fatalf("confirmOverwrite() error = %v, want nil in CI", err)
+	}
+}
+
+func TestConfirmOverwrite_NoOpWhenFileMissing(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "README.md")
+	t.Setenv("CI", "")
+
+	if err := confirmOverwrite(Config{Interactive: true, Out: out}); err != nil {
+		t.Fatalf("confirmOverwrite() error = %v, want nil when file doesn't exist", err)
+	}
+}
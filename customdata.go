@@ -0,0 +1,26 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// loadCustomData reads the JSON object at path for -data-file, e.g.:
+//
+//	{"tagline": "Currently listening to too much jazz", "links": ["a", "b"]}
+//
+// Despite the flag's generic name, only JSON is supported: the standard
+// library has no YAML decoder, and this package takes no third-party
+// dependencies to add one.
+func loadCustomData(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var custom map[string]any
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, err
+	}
+	return custom, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadCustomData_ValidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte(`{"tagline": "hello", "count": 3}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	custom, err := loadCustomData(path)
+	if err != nil {
+		t.Fatalf("loadCustomData() error = %v", err)
+	}
+	if custom["tagline"] != "hello" {
+		t.Errorf("custom[tagline] = %v, want %q", custom["tagline"], "hello")
+	}
+	if custom["count"] != float64(3) {
+		t.Errorf("custom[count] = %v, want 3", custom["count"])
+	}
+}
+
+func TestLoadCustomData_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte(`{not json`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadCustomData(path); err == nil {
+		t.Fatal("loadCustomData() error = nil, want an error for malformed JSON")
+	}
+}
+
+func TestLoadCustomData_MissingFile(t *testing.T) {
+	if _, err := loadCustomData(filepath.Join(t.TempDir(), "nope.json")); err == nil {
+		t.Fatal("loadCustomData() error = nil, want an error for a missing file")
+	}
+}
+
+func TestPipelineRun_DataFileRendersInTemplate(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	dataPath := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(dataPath, []byte(`{"tagline": "too much jazz"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmplPath := filepath.Join(t.TempDir(), "tmpl.md")
+	if err := os.WriteFile(tmplPath, []byte("{{.Custom.tagline}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Template:  tmplPath,
+		DataFile:  dataPath,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "too much jazz") {
+		t.Errorf("README = %q, want it to contain the -data-file tagline", data)
+	}
+}
+
+func TestPipelineRun_NoDataFileLeavesCustomNil(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	tmplPath := filepath.Join(t.TempDir(), "tmpl.md")
+	if err := os.WriteFile(tmplPath, []byte("{{if .Custom}}has custom{{else}}no custom{{end}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Template:  tmplPath,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "no custom" {
+		t.Errorf("README = %q, want %q", data, "no custom")
+	}
+}
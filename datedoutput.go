@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// datedOutputToken is the placeholder -out may contain to have a distinctly
+// named file written each run (e.g. "archive/README-{date}.md"), formatted
+// with -date-layout (a Go reference-time layout, default "2006-01-02").
+// -prune-keep/-prune-older-than then bound how many of these accumulate.
+const datedOutputToken = "{date}"
+
+// isDatedOutputPattern reports whether out uses the "{date}" placeholder.
+func isDatedOutputPattern(out string) bool {
+	return strings.Contains(out, datedOutputToken)
+}
+
+// resolveDatedOutputPath substitutes "{date}" in out with t formatted per
+// dateLayout. A pattern without "{date}" is returned unchanged.
+func resolveDatedOutputPath(out, dateLayout string, t time.Time) string {
+	if !isDatedOutputPattern(out) {
+		return out
+	}
+	return strings.ReplaceAll(out, datedOutputToken, t.Format(dateLayout))
+}
+
+// datedOutputGlob turns a "{date}" pattern into a filepath.Glob pattern
+// matching every file that pattern could have produced, by replacing
+// "{date}" with "*". Only ever called on patterns isDatedOutputPattern
+// already confirmed contain the placeholder, so it can't accidentally match
+// unrelated files that merely share the pattern's static parts.
+func datedOutputGlob(out string) string {
+	return strings.ReplaceAll(out, datedOutputToken, "*")
+}
+
+// pruneDatedOutputs implements -prune-keep/-prune-older-than: it finds
+// every file matching out's "{date}" pattern and deletes the ones beyond
+// the newest keep (by modification time), or older than olderThan,
+// whichever threshold is set (a file is deleted if either one says so). A
+// no-op when out has no "{date}" placeholder, or neither threshold is set.
+func pruneDatedOutputs(out string, keep int, olderThan time.Duration, now time.Time) error {
+	if !isDatedOutputPattern(out) || (keep <= 0 && olderThan <= 0) {
+		return nil
+	}
+
+	matches, err := filepath.Glob(datedOutputGlob(out))
+	if err != nil {
+		return fmt.Errorf("-prune-keep/-prune-older-than: %w", err)
+	}
+
+	type datedFile struct {
+		path    string
+		modTime time.Time
+	}
+	files := make([]datedFile, 0, len(matches))
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		files = append(files, datedFile{path: match, modTime: info.ModTime()})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	for i, f := range files {
+		prune := keep > 0 && i >= keep
+		if olderThan > 0 && now.Sub(f.modTime) > olderThan {
+			prune = true
+		}
+		if !prune {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil {
+			return fmt.Errorf("-prune-keep/-prune-older-than: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveDatedOutputPath(t *testing.T) {
+	day := time.Date(2026, 8, 9, 0, 0, 0, 0, time.UTC)
+
+	got := resolveDatedOutputPath("archive/README-{date}.md", "2006-01-02", day)
+	want := "archive/README-2026-08-09.md"
+	if got != want {
+		t.Errorf("resolveDatedOutputPath() = %q, want %q", got, want)
+	}
+
+	if got := resolveDatedOutputPath("README.md", "2006-01-02", day); got != "README.md" {
+		t.Errorf("resolveDatedOutputPath() without a placeholder = %q, want unchanged", got)
+	}
+}
+
+func TestPruneDatedOutputs_KeepsNewestN(t *testing.T) {
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "README-{date}.md")
+	now := time.Now()
+
+	var paths []string
+	for i, days := range []int{0, 1, 2, 3} {
+		path := filepath.Join(dir, fmt.Sprintf("README-day%d.md", i))
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(-time.Duration(days) * 24 * time.Hour)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	if err := pruneDatedOutputs(pattern, 2, 0, now); err != nil {
+		t.Fatalf("pruneDatedOutputs() error = %v", err)
+	}
+
+	assertExists(t, paths[0])
+	assertExists(t, paths[1])
+	assertGone(t, paths[2])
+	assertGone(t, paths[3])
+}
+
+func TestPruneDatedOutputs_DeletesOlderThan(t *testing.T) {
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "README-{date}.md")
+	now := time.Now()
+
+	fresh := filepath.Join(dir, "README-fresh.md")
+	stale := filepath.Join(dir, "README-stale.md")
+	for _, path := range []string{fresh, stale} {
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(stale, now.Add(-48*time.Hour), now.Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pruneDatedOutputs(pattern, 0, 24*time.Hour, now); err != nil {
+		t.Fatalf("pruneDatedOutputs() error = %v", err)
+	}
+
+	assertExists(t, fresh)
+	assertGone(t, stale)
+}
+
+func TestPruneDatedOutputs_NoopWithoutDatedPattern(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pruneDatedOutputs(path, 0, 0, time.Now()); err != nil {
+		t.Fatalf("pruneDatedOutputs() error = %v", err)
+	}
+	assertExists(t, path)
+}
+
+func TestPipelineRun_DatedOutputWritesAndPrunes(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	older := filepath.Join(dir, "README-2020-01-01.md")
+	if err := os.WriteFile(older, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	oldTime := time.Now().Add(-72 * time.Hour)
+	if err := os.Chtimes(older, oldTime, oldTime); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPipeline(Config{
+		URL:        srv.URL,
+		Out:        filepath.Join(dir, "README-{date}.md"),
+		Timeout:    5 * time.Second,
+		UserAgent:  defaultUserAgent,
+		DateLayout: "2006-01-02",
+		PruneKeep:  1,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	assertGone(t, older)
+
+	matches, err := filepath.Glob(filepath.Join(dir, "README-*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("matches = %v, want exactly 1 dated output after pruning", matches)
+	}
+}
+
+func assertExists(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected %s to still exist: %v", path, err)
+	}
+}
+
+func assertGone(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be pruned, stat err = %v", path, err)
+	}
+}
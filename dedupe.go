@@ -0,0 +1,23 @@
+package main
+
+// dedupeStackImages implements -dedupe: it marks each StackImage whose
+// ImageURL is byte-identical to an earlier one's with DuplicateOf set to
+// that earlier image's Method, so the default template can note the
+// duplication instead of re-embedding the same data URI. Comparing the
+// full data URI string (rather than hashing it) is enough here - two
+// images only produce identical strings when their encoded bytes and MIME
+// prefix already match exactly, and collage images are small enough that
+// hashing wouldn't save anything worth the extra step.
+func dedupeStackImages(images []StackImage) []StackImage {
+	deduped := make([]StackImage, len(images))
+	firstMethod := make(map[string]string, len(images))
+	for i, img := range images {
+		deduped[i] = img
+		if first, ok := firstMethod[img.ImageURL]; ok {
+			deduped[i].DuplicateOf = first
+			continue
+		}
+		firstMethod[img.ImageURL] = img.Method
+	}
+	return deduped
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupeStackImages_MarksLaterDuplicates(t *testing.T) {
+	images := []StackImage{
+		{Method: "albums", ImageURL: "data:image/png;base64,AAA"},
+		{Method: "artists", ImageURL: "data:image/png;base64,BBB"},
+		{Method: "tracks", ImageURL: "data:image/png;base64,AAA"},
+	}
+
+	got := dedupeStackImages(images)
+
+	if got[0].DuplicateOf != "" {
+		t.Errorf("albums.DuplicateOf = %q, want empty (it's the first)", got[0].DuplicateOf)
+	}
+	if got[1].DuplicateOf != "" {
+		t.Errorf("artists.DuplicateOf = %q, want empty (unique content)", got[1].DuplicateOf)
+	}
+	if got[2].DuplicateOf != "albums" {
+		t.Errorf("tracks.DuplicateOf = %q, want \"albums\"", got[2].DuplicateOf)
+	}
+}
+
+func TestPipelineRun_DedupeCollapsesIdenticalStackImages(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Stack:     "albums,artists",
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Dedupe:    true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v", err)
+	}
+
+	if data.StackImages[0].DuplicateOf != "" {
+		t.Errorf("albums.DuplicateOf = %q, want empty", data.StackImages[0].DuplicateOf)
+	}
+	if data.StackImages[1].DuplicateOf != "albums" {
+		t.Errorf("artists.DuplicateOf = %q, want \"albums\" (identical server response)", data.StackImages[1].DuplicateOf)
+	}
+}
+
+func TestPipelineRun_DedupeOffLeavesDuplicatesEmbedded(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Stack:     "albums,artists",
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v", err)
+	}
+
+	for _, img := range data.StackImages {
+		if img.DuplicateOf != "" {
+			t.Errorf("%s.DuplicateOf = %q, want empty when -dedupe isn't set", img.Method, img.DuplicateOf)
+		}
+	}
+}
+
+func TestDefaultTemplate_RendersDuplicateNoteInsteadOfImage(t *testing.T) {
+	tmpl, _, err := loadTemplateForConfig(Config{})
+	if err != nil {
+		t.Fatalf("loadTemplateForConfig() error = %v", err)
+	}
+
+	var buf strings.Builder
+	data := TemplateData{StackImages: []StackImage{
+		{Method: "albums", ImageURL: "data:image/png;base64,AAA"},
+		{Method: "artists", ImageURL: "data:image/png;base64,AAA", DuplicateOf: "albums"},
+	}}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	rendered := buf.String()
+	if strings.Count(rendered, "data:image/png;base64,AAA") != 1 {
+		t.Errorf("rendered = %q, want the data URI embedded only once", rendered)
+	}
+	if !strings.Contains(rendered, "artists is identical to albums") {
+		t.Errorf("rendered = %q, want a note about the duplicate", rendered)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+// isDeepColorPNG reports whether data is a PNG with more than 8 bits per
+// channel, without fully decoding it. png.DecodeConfig doesn't expose bit
+// depth directly, but the image/png decoder only ever reports one of these
+// three color models for a 16-bit source, so checking the model is
+// equivalent and much cheaper than decoding the whole image first.
+func isDeepColorPNG(data []byte) (bool, error) {
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	switch cfg.ColorModel {
+	case color.RGBA64Model, color.NRGBA64Model, color.Gray16Model:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// downconvertPNGTo8Bit decodes a 16-bit-per-channel PNG and re-encodes it
+// at 8 bits per channel, implementing -optimize's deep-color handling: the
+// extra precision is rarely visible once embedded as a small profile-README
+// image, but it roughly doubles pixel data size for nothing.
+func downconvertPNGTo8Bit(data []byte) ([]byte, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding deep-color PNG: %w", err)
+	}
+
+	bounds := img.Bounds()
+	var dst draw.Image
+	if img.ColorModel() == color.Gray16Model {
+		dst = image.NewGray(bounds)
+	} else {
+		dst = image.NewNRGBA(bounds)
+	}
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, fmt.Errorf("encoding 8-bit PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func make16BitPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewNRGBA64(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			img.Set(x, y, color.NRGBA64{R: uint16(x * 2000), G: uint16(y * 2000), B: 0xffff, A: 0xffff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encoding 16-bit PNG fixture: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestIsDeepColorPNG(t *testing.T) {
+	deep := make16BitPNG(t)
+	if ok, err := isDeepColorPNG(deep); err != nil || !ok {
+		t.Errorf("isDeepColorPNG(16-bit) = %v, %v, want true, nil", ok, err)
+	}
+
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("fetching fixture: %v", err)
+	}
+	defer resp.Body.Close()
+	shallow, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	if ok, err := isDeepColorPNG(shallow); err != nil || ok {
+		t.Errorf("isDeepColorPNG(8-bit) = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestDownconvertPNGTo8Bit_ShrinksSize(t *testing.T) {
+	deep := make16BitPNG(t)
+	converted, err := downconvertPNGTo8Bit(deep)
+	if err != nil {
+		t.Fatalf("downconvertPNGTo8Bit() error = %v", err)
+	}
+	if len(converted) >= len(deep) {
+		t.Errorf("converted size %d, want smaller than source size %d", len(converted), len(deep))
+	}
+	if ok, err := isDeepColorPNG(converted); err != nil || ok {
+		t.Errorf("isDeepColorPNG(converted) = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestPipelineRun_OptimizeDownconvertsDeepColorPNG(t *testing.T) {
+	deep := make16BitPNG(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(deep)
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Optimize:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestPipelineRun_OptimizeIsNoOpWithoutDeepColor(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Optimize:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dataURIPattern matches an embedded "data:<mime>;base64,<payload>" image, the
+// one line in a rendered README that can run to hundreds of thousands of
+// characters - exactly what renderDiff collapses before diffing, so a
+// one-pixel change in the source image doesn't dump the whole payload twice.
+var dataURIPattern = regexp.MustCompile(`data:[a-zA-Z0-9.+-]+/[a-zA-Z0-9.+-]+;base64,[A-Za-z0-9+/=]+`)
+
+// diffLine is one line of a unified diff: ' ' for context, '-'/'+' for a
+// removal/addition, kept as this before rendering so renderDiff can collapse
+// a changed image line pair before context-trimming.
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// renderDiff returns a unified-style textual diff from oldText to newText
+// (e.g. the published and freshly regenerated READMEs -compare-with-remote
+// compares), keeping context lines of unchanged context around each change.
+// Embedded data URIs are collapsed to a short size summary first, since
+// diffing the raw base64 either reports no readable change (same length,
+// different bytes) or dumps two giant lines - neither is useful dry-run
+// output.
+func renderDiff(oldText, newText []byte, context int) string {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+
+	ops := diffLines(oldLines, newLines)
+	ops = collapseDataURIChanges(ops)
+	return formatUnifiedDiff(ops, context)
+}
+
+func splitLines(text []byte) []string {
+	collapsed := dataURIPattern.ReplaceAllStringFunc(string(text), func(match string) string {
+		return fmt.Sprintf("<data URI: %d bytes>", len(match))
+	})
+	return strings.Split(collapsed, "\n")
+}
+
+// diffLines runs a classic LCS-based line diff between a and b.
+func diffLines(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	dp := make([][]int, n+1)
+	for i := range dp {
+		dp[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else if dp[i+1][j] >= dp[i][j+1] {
+				dp[i][j] = dp[i+1][j]
+			} else {
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffLine
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffLine{' ', a[i]})
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			ops = append(ops, diffLine{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffLine{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffLine{'+', b[j]})
+	}
+	return ops
+}
+
+// dataURIPlaceholderPattern finds the "<data URI: N bytes>" placeholder
+// splitLines leaves behind, wherever it falls in a line - e.g. the README's
+// own "![collage](<data URI: N bytes>)" markdown image syntax, not just a
+// line that is nothing but the placeholder.
+var dataURIPlaceholderPattern = regexp.MustCompile(`<data URI: (\d+) bytes>`)
+
+// mergeDataURILineChange reports whether oldLine and newLine differ only in
+// their embedded data URI placeholder (identical markdown/text around it),
+// returning a human-readable "image data changed (823KB -> 640KB)" summary
+// of the two placeholder sizes if so.
+func mergeDataURILineChange(oldLine, newLine string) (string, bool) {
+	oldMatch := dataURIPlaceholderPattern.FindStringSubmatchIndex(oldLine)
+	newMatch := dataURIPlaceholderPattern.FindStringSubmatchIndex(newLine)
+	if oldMatch == nil || newMatch == nil {
+		return "", false
+	}
+	if oldLine[:oldMatch[0]]+oldLine[oldMatch[1]:] != newLine[:newMatch[0]]+newLine[newMatch[1]:] {
+		return "", false
+	}
+	oldSize, err := strconv.Atoi(oldLine[oldMatch[2]:oldMatch[3]])
+	if err != nil {
+		return "", false
+	}
+	newSize, err := strconv.Atoi(newLine[newMatch[2]:newMatch[3]])
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("image data changed (%s -> %s)", formatKB(oldSize), formatKB(newSize)), true
+}
+
+// collapseDataURIChanges rewrites a removed-then-added pair of lines that
+// differ only in their embedded data URI placeholder into a single
+// human-readable summary line, e.g. "image data changed (823KB -> 640KB)",
+// instead of showing them as a generic one-line-removed/one-line-added diff
+// hunk.
+func collapseDataURIChanges(ops []diffLine) []diffLine {
+	var out []diffLine
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == '-' && i+1 < len(ops) && ops[i+1].kind == '+' {
+			if summary, ok := mergeDataURILineChange(ops[i].text, ops[i+1].text); ok {
+				out = append(out, diffLine{'!', summary})
+				i++
+				continue
+			}
+		}
+		out = append(out, ops[i])
+	}
+	return out
+}
+
+// formatKB renders n bytes rounded to the nearest kilobyte, e.g. "823KB".
+func formatKB(n int) string {
+	return fmt.Sprintf("%dKB", (n+512)/1024)
+}
+
+// formatUnifiedDiff renders ops as unified-diff-style text, keeping up to
+// context lines of unchanged context on either side of each run of changes
+// and collapsing longer unchanged stretches to a "..." separator between
+// hunks, the same way `diff -U` elides them. context <= 0 still shows every
+// changed line, just with no surrounding context.
+func formatUnifiedDiff(ops []diffLine, context int) string {
+	if context < 0 {
+		context = 0
+	}
+
+	changed := make([]bool, len(ops))
+	for i, op := range ops {
+		changed[i] = op.kind != ' '
+	}
+
+	show := make([]bool, len(ops))
+	for i, isChanged := range changed {
+		if !isChanged {
+			continue
+		}
+		for d := -context; d <= context; d++ {
+			j := i + d
+			if j >= 0 && j < len(ops) {
+				show[j] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	prevShown := false
+	for i, op := range ops {
+		if !show[i] {
+			if prevShown {
+				b.WriteString("...\n")
+			}
+			prevShown = false
+			continue
+		}
+		switch op.kind {
+		case '-':
+			fmt.Fprintf(&b, "-%s\n", op.text)
+		case '+':
+			fmt.Fprintf(&b, "+%s\n", op.text)
+		case '!':
+			fmt.Fprintf(&b, "%s\n", op.text)
+		default:
+			fmt.Fprintf(&b, " %s\n", op.text)
+		}
+		prevShown = true
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
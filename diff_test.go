@@ -0,0 +1,172 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLines_InsertDeleteUnchanged(t *testing.T) {
+	a := []string{"one", "two", "three"}
+	b := []string{"one", "two and a half", "three", "four"}
+
+	ops := diffLines(a, b)
+
+	var got []diffLine
+	got = append(got, ops...)
+	if len(got) == 0 {
+		t.Fatal("diffLines() returned no ops")
+	}
+
+	var kinds []byte
+	for _, op := range got {
+		kinds = append(kinds, op.kind)
+	}
+	if kinds[0] != ' ' {
+		t.Errorf("first op kind = %q, want ' ' for the unchanged \"one\" line", kinds[0])
+	}
+
+	var removed, added bool
+	for _, op := range ops {
+		if op.kind == '-' && op.text == "two" {
+			removed = true
+		}
+		if op.kind == '+' && op.text == "two and a half" {
+			added = true
+		}
+	}
+	if !removed || !added {
+		t.Errorf("ops = %+v, want a removed \"two\" and an added \"two and a half\"", ops)
+	}
+}
+
+func TestCollapseDataURIChanges_MergesRemovedAndAddedPlaceholder(t *testing.T) {
+	ops := []diffLine{
+		{' ', "before"},
+		{'-', "![collage](<data URI: 842752 bytes>)"},
+		{'+', "![collage](<data URI: 655360 bytes>)"},
+		{' ', "after"},
+	}
+
+	collapsed := collapseDataURIChanges(ops)
+
+	if len(collapsed) != 3 {
+		t.Fatalf("len(collapsed) = %d, want 3", len(collapsed))
+	}
+	if collapsed[1].kind != '!' {
+		t.Fatalf("collapsed[1].kind = %q, want '!'", collapsed[1].kind)
+	}
+	want := "image data changed (823KB -> 640KB)"
+	if collapsed[1].text != want {
+		t.Errorf("collapsed[1].text = %q, want %q", collapsed[1].text, want)
+	}
+}
+
+func TestCollapseDataURIChanges_LeavesUnrelatedChangesAlone(t *testing.T) {
+	ops := []diffLine{
+		{'-', "old text"},
+		{'+', "new text"},
+	}
+
+	collapsed := collapseDataURIChanges(ops)
+
+	if len(collapsed) != 2 || collapsed[0] != ops[0] || collapsed[1] != ops[1] {
+		t.Errorf("collapseDataURIChanges() = %+v, want unchanged ops", collapsed)
+	}
+}
+
+func TestFormatKB_RoundsToNearestKilobyte(t *testing.T) {
+	tests := []struct {
+		bytes int
+		want  string
+	}{
+		{0, "0KB"},
+		{1024, "1KB"},
+		{842752, "823KB"},
+		{655360, "640KB"},
+		{511, "0KB"},
+		{513, "1KB"},
+	}
+	for _, tt := range tests {
+		if got := formatKB(tt.bytes); got != tt.want {
+			t.Errorf("formatKB(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestFormatUnifiedDiff_TrimsContextAroundChange(t *testing.T) {
+	ops := []diffLine{
+		{' ', "line1"},
+		{' ', "line2"},
+		{' ', "line3"},
+		{' ', "line4"},
+		{' ', "line5"},
+		{'-', "old"},
+		{'+', "new"},
+		{' ', "line6"},
+		{' ', "line7"},
+		{' ', "line8"},
+		{' ', "line9"},
+		{' ', "line10"},
+	}
+
+	out := formatUnifiedDiff(ops, 1)
+
+	if strings.Contains(out, "line1\n") || strings.Contains(out, "line10") {
+		t.Errorf("formatUnifiedDiff() = %q, want distant context lines trimmed", out)
+	}
+	if !strings.Contains(out, "line5") || !strings.Contains(out, "line6") {
+		t.Errorf("formatUnifiedDiff() = %q, want the one line of context on either side of the change", out)
+	}
+	if !strings.Contains(out, "...") {
+		t.Errorf("formatUnifiedDiff() = %q, want a \"...\" separator for the elided stretch", out)
+	}
+	if !strings.Contains(out, "-old") || !strings.Contains(out, "+new") {
+		t.Errorf("formatUnifiedDiff() = %q, want the changed lines present", out)
+	}
+}
+
+func TestFormatUnifiedDiff_ZeroContextShowsOnlyChangedLines(t *testing.T) {
+	ops := []diffLine{
+		{' ', "before"},
+		{'-', "old"},
+		{'+', "new"},
+		{' ', "after"},
+	}
+
+	out := formatUnifiedDiff(ops, 0)
+
+	if strings.Contains(out, "before") || strings.Contains(out, "after") {
+		t.Errorf("formatUnifiedDiff() with context 0 = %q, want no unchanged lines", out)
+	}
+	if !strings.Contains(out, "-old") || !strings.Contains(out, "+new") {
+		t.Errorf("formatUnifiedDiff() = %q, want the changed lines present", out)
+	}
+}
+
+func TestRenderDiff_CollapsesEmbeddedImageChange(t *testing.T) {
+	old := []byte("# README\n\n![collage](data:image/png;base64," + strings.Repeat("A", 842752) + ")\n")
+	new := []byte("# README\n\n![collage](data:image/png;base64," + strings.Repeat("B", 655360) + ")\n")
+
+	out := renderDiff(old, new, 3)
+
+	if !strings.Contains(out, "image data changed (823KB -> 640KB)") {
+		t.Errorf("renderDiff() = %q, want the collapsed image-data summary line", out)
+	}
+	if strings.Contains(out, strings.Repeat("A", 100)) || strings.Contains(out, strings.Repeat("B", 100)) {
+		t.Error("renderDiff() leaked raw base64 payload into the diff output")
+	}
+}
+
+func TestRenderDiff_ShowsUnrelatedTextChangesPlainly(t *testing.T) {
+	old := []byte("line one\nline two\nline three\n")
+	new := []byte("line one\nline TWO\nline three\n")
+
+	out := renderDiff(old, new, 3)
+
+	if !strings.Contains(out, "-line two") || !strings.Contains(out, "+line TWO") {
+		t.Errorf("renderDiff() = %q, want plain -/+ lines for a non-image change", out)
+	}
+	if !strings.Contains(out, " line one") || !strings.Contains(out, " line three") {
+		t.Errorf("renderDiff() = %q, want unchanged lines kept as context", out)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// downloadCacheManifest is the sidecar metadata written next to a
+// -download-only cache file: everything -from-cache needs to resume the
+// pipeline after the raw image bytes, without ever touching the network.
+type downloadCacheManifest struct {
+	ContentType string `json:"contentType"`
+	AlbumCount  int    `json:"albumCount"`
+}
+
+// downloadCacheManifestPath returns the sidecar manifest path for a given
+// -download-only/-from-cache path, following the same "path + suffix"
+// convention as manifestPath.
+func downloadCacheManifestPath(path string) string {
+	return path + ".eagleusb-cache-manifest.json"
+}
+
+// saveDownloadCache writes body to path and manifest to its sidecar, for
+// -download-only.
+func saveDownloadCache(path string, body []byte, manifest downloadCacheManifest) error {
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	if err := os.WriteFile(downloadCacheManifestPath(path), data, 0o644); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	return nil
+}
+
+// loadDownloadCache reads back what saveDownloadCache wrote, for -from-cache.
+func loadDownloadCache(path string) ([]byte, downloadCacheManifest, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, downloadCacheManifest{}, fmt.Errorf("%w: %w", ErrFetch, err)
+	}
+
+	data, err := os.ReadFile(downloadCacheManifestPath(path))
+	if err != nil {
+		return nil, downloadCacheManifest{}, fmt.Errorf("%w: cache manifest: %w", ErrFetch, err)
+	}
+
+	var manifest downloadCacheManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, downloadCacheManifest{}, fmt.Errorf("%w: cache manifest: %w", ErrFetch, err)
+	}
+	return body, manifest, nil
+}
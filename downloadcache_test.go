@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun_DownloadOnlyThenFromCache(t *testing.T) {
+	var fetches int
+	png := makePNG(2, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "collage.cache")
+	downloadCfg := Config{
+		URL:          srv.URL,
+		DownloadOnly: cachePath,
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+	}
+	dp, err := NewPipeline(downloadCfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := dp.Run(); err != nil {
+		t.Fatalf("download-only Run() error = %v", err)
+	}
+	if fetches != 1 {
+		t.Fatalf("fetches = %d, want 1", fetches)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	fromCacheCfg := Config{
+		FromCache: cachePath,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	}
+	fp, err := NewPipeline(fromCacheCfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := fp.Run(); err != nil {
+		t.Fatalf("from-cache Run() error = %v", err)
+	}
+	if fetches != 1 {
+		t.Fatalf("fetches after from-cache run = %d, want still 1 (no network access)", fetches)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) == 0 {
+		t.Fatal("expected a non-empty rendered README")
+	}
+}
+
+func TestRunDownloadOnly_RejectsUndecodableImage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("not a png"))
+	}))
+	defer srv.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "collage.cache")
+	p, err := NewPipeline(Config{
+		URL:          srv.URL,
+		DownloadOnly: cachePath,
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to reject an undecodable image")
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"strings"
+)
+
+// supportedMimes lists the MIME types encodeImageToBase64 knows how to
+// validate, and thus the only values -mime may force.
+var supportedMimes = map[string]bool{
+	"image/png":     true,
+	"image/jpeg":    true,
+	"image/gif":     true,
+	"image/svg+xml": true,
+	"image/webp":    true,
+}
+
+// ImageInfo describes an image buffer validated by ValidateImage: its
+// detected MIME type, and its pixel dimensions for formats canDecodeToPixels
+// reports true for. Width and Height are zero for WebP and SVG, which this
+// tree only validates at the container level (see webp.go and validateSVG)
+// rather than fully decoding to pixels. JPEGFallback is true when a JPEG
+// only validated via decodeJPEGConfigOnly's header-only fallback, meaning
+// Width/Height came from its headers rather than a full pixel decode (see
+// jpegfallback.go).
+type ImageInfo struct {
+	Mime         string
+	Width        int
+	Height       int
+	JPEGFallback bool
+}
+
+// ValidateImage confirms data is a supported, intact image - the same
+// sniff/decode logic encodeImageToBase64 uses internally - and reports its
+// detected format and dimensions, for callers that just want the validation
+// step without the rest of the fetch/encode pipeline. mimeHint, when
+// non-empty, is trusted outright instead of sniffed from data, the same
+// escape hatch encodeImageToBase64's forcedMime gives a caller that already
+// knows the format a server's Content-Type got wrong.
+func ValidateImage(data []byte, mimeHint string) (ImageInfo, error) {
+	mime := resolveMime(data, "", mimeHint)
+	if mimeHint != "" && !supportedMimes[mime] {
+		return ImageInfo{}, fmt.Errorf("encoding image: unsupported forced mime %q", mime)
+	}
+
+	width, height, jpegFallback, err := sniffAndValidate(data, mime, false)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+
+	return ImageInfo{Mime: mime, Width: width, Height: height, JPEGFallback: jpegFallback}, nil
+}
+
+// encodeImageToBase64 validates the given bytes as a supported image format
+// and returns a "data:<mime>;base64,..." URI ready to embed in HTML or
+// Markdown. forcedMime, when non-empty, overrides both the header and the
+// sniffer - an escape hatch for servers that report a generic
+// application/octet-stream for a valid image.
+//
+// Raster formats (PNG/JPEG/GIF and anything else the standard image package
+// understands) are validated by decoding them, unless fastValidate is set
+// (see -fast-validate), in which case only the format's magic-byte
+// signature is checked, skipping the full decode. SVG is XML, not a raster
+// format, so it can't go through image.Decode - it is validated by parsing
+// it as XML and checking for an <svg> root element instead, which is
+// already cheap enough that -fast-validate makes no difference to it. The
+// standard library also has no WebP decoder, so WebP is always validated at
+// the RIFF container level instead of by decoding pixels.
+//
+// jpegFallback is true when a JPEG only validated via decodeJPEGConfigOnly's
+// header-only fallback rather than a full decode (see jpegfallback.go);
+// callers fold it into their own warned/degraded-run bookkeeping the same
+// way they already do for animated.
+func encodeImageToBase64(data []byte, contentType, forcedMime string, fastValidate bool) (dataURI string, animated bool, jpegFallback bool, err error) {
+	mime := resolveMime(data, contentType, forcedMime)
+	if forcedMime != "" && !supportedMimes[mime] {
+		return "", false, false, fmt.Errorf("encoding image: unsupported forced mime %q", mime)
+	}
+
+	_, _, jpegFallback, err = sniffAndValidate(data, mime, fastValidate)
+	if err != nil {
+		return "", false, false, err
+	}
+	if mime == "image/webp" {
+		animated = isAnimatedWebP(data)
+		warnIfAnimated(animated)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	return fmt.Sprintf("data:%s;base64,%s", mime, encoded), animated, jpegFallback, nil
+}
+
+// sniffAndValidate is the shared core of encodeImageToBase64 and
+// ValidateImage: it validates data against an already-resolved mime and, for
+// the raster formats canDecodeToPixels reports true for, returns its pixel
+// dimensions (zero for WebP/SVG, which are only validated at the container
+// level in this tree - see the package comment on webp.go and validateSVG).
+// fastValidate skips the full decode in favor of a magic-byte check, same as
+// -fast-validate, at the cost of not having dimensions to return.
+//
+// jpegFallback is true when mime is image/jpeg, a full image.Decode failed,
+// and decodeJPEGConfigOnly's header-only fallback recovered dimensions
+// anyway (see jpegfallback.go) - it's always false for every other format
+// and for the fastValidate path, which never attempts a full decode to fail
+// in the first place.
+func sniffAndValidate(data []byte, mime string, fastValidate bool) (width, height int, jpegFallback bool, err error) {
+	switch mime {
+	case "image/svg+xml":
+		if err := validateSVG(data); err != nil {
+			return 0, 0, false, fmt.Errorf("decoding image: %w", err)
+		}
+		return 0, 0, false, nil
+	case "image/webp":
+		if err := validateWebP(data); err != nil {
+			return 0, 0, false, fmt.Errorf("decoding image: %w", err)
+		}
+		return 0, 0, false, nil
+	default:
+		if looksLikeAntiBotChallengeBody(data) {
+			return 0, 0, false, fmt.Errorf("%w: try setting a browser-like -user-agent or a -cookie", ErrAntiBot)
+		}
+		if fastValidate {
+			if err := validateSignature(data, mime); err != nil {
+				return 0, 0, false, fmt.Errorf("decoding image: %w", err)
+			}
+			return 0, 0, false, nil
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			if mime == "image/jpeg" {
+				if w, h, ok := decodeJPEGConfigOnly(bytes.NewReader(data)); ok {
+					logJPEGLenientDecode(true)
+					return w, h, true, nil
+				}
+			}
+			return 0, 0, false, fmt.Errorf("decoding image: %w", err)
+		}
+		bounds := img.Bounds()
+		return bounds.Dx(), bounds.Dy(), false, nil
+	}
+}
+
+// validateSignature checks data's leading magic bytes against mime, without
+// decoding the rest of the image. It's the cheap half of what image.Decode
+// does, for -fast-validate to fail fast on an obviously corrupt header
+// before spending time on a full decode.
+func validateSignature(data []byte, mime string) error {
+	switch mime {
+	case "image/png":
+		sig := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+		if len(data) < len(sig) || !bytes.Equal(data[:len(sig)], sig) {
+			return fmt.Errorf("invalid PNG: missing signature")
+		}
+	case "image/jpeg":
+		if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+			return fmt.Errorf("invalid JPEG: missing SOI marker")
+		}
+	case "image/gif":
+		if len(data) < 6 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+			return fmt.Errorf("invalid GIF: missing signature")
+		}
+	default:
+		return fmt.Errorf("fast-validate: unsupported mime %q for header-only validation", mime)
+	}
+	return nil
+}
+
+// resolveMime returns forcedMime if set, otherwise prefers a Content-Type
+// reported by the server, falling back to sniffing the bytes when the
+// header is missing or too generic to trust.
+func resolveMime(data []byte, contentType, forcedMime string) string {
+	if forcedMime != "" {
+		return forcedMime
+	}
+
+	mime := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	if mime != "" && mime != "application/octet-stream" {
+		return mime
+	}
+
+	if looksLikeSVG(data) {
+		return "image/svg+xml"
+	}
+
+	return http.DetectContentType(data)
+}
+
+// looksLikeSVG reports whether data appears to be an SVG document, since
+// http.DetectContentType does not recognize SVG.
+func looksLikeSVG(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<svg"))
+}
+
+// validateSVG confirms data is well-formed XML with an <svg> root element.
+func validateSVG(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("invalid SVG: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local != "svg" {
+				return fmt.Errorf("invalid SVG: root element is <%s>, not <svg>", start.Name.Local)
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestEncodeImageToBase64_SVG(t *testing.T) {
+	svg := []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"><rect/></svg>`)
+
+	uri, _, _, err := encodeImageToBase64(svg, "", "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	const wantPrefix = "data:image/svg+xml;base64,"
+	if len(uri) <= len(wantPrefix) || uri[:len(wantPrefix)] != wantPrefix {
+		t.Fatalf("data URI = %q, want prefix %q", uri, wantPrefix)
+	}
+}
+
+func TestEncodeImageToBase64_ForcedMimeRejectsUnsupported(t *testing.T) {
+	if _, _, _, err := encodeImageToBase64([]byte("irrelevant"), "application/octet-stream", "image/avif", false); err == nil {
+		t.Fatal("expected error forcing an unsupported mime")
+	}
+}
+
+func TestEncodeImageToBase64_InvalidSVG(t *testing.T) {
+	notSVG := []byte(`<svgg><rect/>`)
+
+	if _, _, _, err := encodeImageToBase64(notSVG, "image/svg+xml", "", false); err == nil {
+		t.Fatal("expected error for malformed SVG, got nil")
+	}
+}
+
+func TestValidateImage_ReportsMimeAndDimensions(t *testing.T) {
+	info, err := ValidateImage(makePNG(4, 3), "")
+	if err != nil {
+		t.Fatalf("ValidateImage() error = %v", err)
+	}
+	if info.Mime != "image/png" {
+		t.Errorf("Mime = %q, want %q", info.Mime, "image/png")
+	}
+	if info.Width != 4 || info.Height != 3 {
+		t.Errorf("dimensions = %dx%d, want 4x3", info.Width, info.Height)
+	}
+}
+
+func TestValidateImage_ZeroDimensionsForContainerOnlyFormats(t *testing.T) {
+	svg := []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"><rect/></svg>`)
+	info, err := ValidateImage(svg, "")
+	if err != nil {
+		t.Fatalf("ValidateImage() error = %v", err)
+	}
+	if info.Width != 0 || info.Height != 0 {
+		t.Errorf("dimensions = %dx%d, want 0x0 for SVG", info.Width, info.Height)
+	}
+}
+
+func TestValidateImage_RejectsCorruptData(t *testing.T) {
+	if _, err := ValidateImage([]byte("not an image"), "image/png"); err == nil {
+		t.Fatal("expected an error for corrupt PNG data")
+	}
+}
+
+func TestValidateImage_RejectsUnsupportedMimeHint(t *testing.T) {
+	if _, err := ValidateImage([]byte("irrelevant"), "image/avif"); err == nil {
+		t.Fatal("expected error forcing an unsupported mime")
+	}
+}
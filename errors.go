@@ -0,0 +1,58 @@
+package main
+
+import "errors"
+
+// Sentinel errors for programmatic consumers. Wrap a caused error with
+// fmt.Errorf("%w: %w", ErrX, err) so callers can branch with errors.Is
+// while the human-readable chain is preserved for errors.Unwrap/Error().
+var (
+	// ErrFetch indicates the collage image could not be downloaded.
+	ErrFetch = errors.New("eagleusb: fetch failed")
+	// ErrDecode indicates the downloaded bytes could not be decoded or
+	// validated as a supported image format.
+	ErrDecode = errors.New("eagleusb: decode failed")
+	// ErrTemplate indicates the README template could not be parsed or
+	// executed.
+	ErrTemplate = errors.New("eagleusb: template failed")
+	// ErrWrite indicates rendered output could not be written to its
+	// destination.
+	ErrWrite = errors.New("eagleusb: write failed")
+	// ErrGitCommit indicates -git-commit could not stage or commit -out.
+	ErrGitCommit = errors.New("eagleusb: git commit failed")
+	// ErrWarning indicates -fail-on-warning is set and a non-fatal warning
+	// (e.g. an animated WebP) was emitted during the run.
+	ErrWarning = errors.New("eagleusb: warning emitted")
+	// ErrGist indicates -gist-token was set but the rendered output could
+	// not be created or updated as a GitHub Gist.
+	ErrGist = errors.New("eagleusb: gist failed")
+	// ErrPostCommand indicates -post-command exited non-zero or could not
+	// be started.
+	ErrPostCommand = errors.New("eagleusb: post-command failed")
+	// ErrValidateMarkdown indicates -validate-markdown found the rendered
+	// output is not well-formed Markdown.
+	ErrValidateMarkdown = errors.New("eagleusb: markdown validation failed")
+	// ErrMaxTotalBytes indicates -max-total-bytes was set and the combined
+	// size of every embedded image's base64 payload still exceeds it, even
+	// after -optimize's automatic re-quantizing (if enabled).
+	ErrMaxTotalBytes = errors.New("eagleusb: max-total-bytes exceeded")
+	// ErrMaxBytes indicates -max-bytes was set and a single fetched
+	// response exceeded it, measured off the actual bytes read rather than
+	// a declared Content-Length (which a chunked response won't even send).
+	ErrMaxBytes = errors.New("eagleusb: response exceeds -max-bytes limit")
+	// ErrAuthRefresh indicates -auth-token-file held a missing or expired
+	// token and either -auth-refresh-command wasn't set or it failed;
+	// re-running -auth-refresh-command (or re-authenticating and
+	// overwriting -auth-token-file by hand) and retrying is the fix.
+	ErrAuthRefresh = errors.New("eagleusb: access token refresh failed")
+	// ErrAntiBot indicates the response looks like a Cloudflare (or
+	// similar) anti-bot interstitial rather than the expected image or API
+	// response; see antibot.go. Setting a browser-like -user-agent or a
+	// -cookie captured from a browser that already solved the challenge
+	// are the usual fixes.
+	ErrAntiBot = errors.New("eagleusb: blocked by anti-bot protection")
+	// ErrTargetBytes indicates -target-bytes was set but no JPEG quality
+	// from 1 to 100 re-encodes the image small enough to fit, within the
+	// bounded number of attempts encodeToTargetBytes makes; see
+	// targetsize.go.
+	ErrTargetBytes = errors.New("eagleusb: target-bytes unreachable")
+)
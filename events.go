@@ -0,0 +1,34 @@
+package main
+
+import "time"
+
+// EventType identifies a stage in a Pipeline run, for consumers that want to
+// drive a progress bar or richer UI instead of parsing logs.
+type EventType string
+
+const (
+	EventFetchStarted EventType = "fetch_started"
+	EventFetchDone    EventType = "fetch_done"
+	EventDecodeDone   EventType = "decode_done"
+	EventWritten      EventType = "written"
+)
+
+// Event is one progress notification emitted through Pipeline.OnEvent.
+type Event struct {
+	Type      EventType
+	URL       string
+	Size      int
+	Timestamp time.Time
+	Err       error
+}
+
+// emit calls p.OnEvent with e stamped with the current time, if a callback
+// is set. It's a no-op otherwise, so the CLI (which doesn't set one) pays
+// nothing for this.
+func (p *Pipeline) emit(e Event) {
+	if p.OnEvent == nil {
+		return
+	}
+	e.Timestamp = time.Now()
+	p.OnEvent(e)
+}
@@ -0,0 +1,49 @@
+package main
+
+import "fmt"
+
+// stripJPEGEXIF returns a copy of a JPEG body with its APP1 segments (the
+// marker EXIF metadata, and occasionally XMP, are packed into) removed.
+// Unlike decoding and re-encoding, this only drops segments and copies
+// every other byte verbatim, so it doesn't recompress the image.
+func stripJPEGEXIF(body []byte) ([]byte, error) {
+	if len(body) < 4 || body[0] != 0xff || body[1] != 0xd8 {
+		return nil, fmt.Errorf("not a JPEG: missing SOI marker")
+	}
+
+	out := make([]byte, 0, len(body))
+	out = append(out, body[0], body[1])
+
+	for i := 2; i < len(body); {
+		if body[i] != 0xff {
+			return nil, fmt.Errorf("malformed JPEG: expected marker at offset %d", i)
+		}
+		marker := body[i+1]
+
+		switch {
+		case marker == 0xd9: // EOI
+			out = append(out, body[i], body[i+1])
+			return out, nil
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7): // markers with no payload
+			out = append(out, body[i], body[i+1])
+			i += 2
+		case marker == 0xda: // start of scan: the rest is entropy-coded data, copy verbatim
+			out = append(out, body[i:]...)
+			return out, nil
+		default:
+			if i+4 > len(body) {
+				return nil, fmt.Errorf("malformed JPEG: truncated segment header at offset %d", i)
+			}
+			segLen := int(body[i+2])<<8 | int(body[i+3])
+			if i+2+segLen > len(body) {
+				return nil, fmt.Errorf("malformed JPEG: truncated segment at offset %d", i)
+			}
+			if marker != 0xe1 { // APP1: drop it, everything else is copied as-is
+				out = append(out, body[i:i+2+segLen]...)
+			}
+			i += 2 + segLen
+		}
+	}
+
+	return nil, fmt.Errorf("malformed JPEG: missing EOI marker")
+}
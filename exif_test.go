@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+// minimalJPEG builds a tiny but structurally valid JPEG byte stream: SOI,
+// an optional APP1 (EXIF) segment, an APP0 (JFIF) segment, a fake scan, EOI.
+func minimalJPEG(withEXIF bool) []byte {
+	var b []byte
+	b = append(b, 0xff, 0xd8) // SOI
+
+	if withEXIF {
+		exifPayload := append([]byte("Exif\x00\x00"), []byte("fake-gps-and-camera-data")...)
+		segLen := len(exifPayload) + 2
+		b = append(b, 0xff, 0xe1, byte(segLen>>8), byte(segLen))
+		b = append(b, exifPayload...)
+	}
+
+	jfifPayload := []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+	segLen := len(jfifPayload) + 2
+	b = append(b, 0xff, 0xe0, byte(segLen>>8), byte(segLen))
+	b = append(b, jfifPayload...)
+
+	b = append(b, 0xff, 0xda) // SOS marker (header contents don't matter for this test)
+	b = append(b, 0x00, 0x0c, 0x03, 0x01, 0x00, 0x02, 0x11, 0x00, 0x03, 0x11, 0x00, 0x00, 0x3f, 0x00)
+	b = append(b, 0xab, 0xcd, 0xef) // fake entropy-coded data
+	b = append(b, 0xff, 0xd9)       // EOI
+	return b
+}
+
+func TestStripJPEGEXIF_RemovesAPP1(t *testing.T) {
+	in := minimalJPEG(true)
+	out, err := stripJPEGEXIF(in)
+	if err != nil {
+		t.Fatalf("stripJPEGEXIF() error = %v", err)
+	}
+	if len(out) >= len(in) {
+		t.Fatalf("expected output shorter than input after stripping EXIF, got %d >= %d", len(out), len(in))
+	}
+	if string(out[0:2]) != "\xff\xd8" || string(out[len(out)-2:]) != "\xff\xd9" {
+		t.Fatal("expected SOI/EOI markers to be preserved")
+	}
+	for i := 0; i < len(out)-1; i++ {
+		if out[i] == 0xff && out[i+1] == 0xe1 {
+			t.Fatal("expected no APP1 marker to remain")
+		}
+	}
+}
+
+func TestStripJPEGEXIF_NoEXIFIsUnchangedAsideFromCopy(t *testing.T) {
+	in := minimalJPEG(false)
+	out, err := stripJPEGEXIF(in)
+	if err != nil {
+		t.Fatalf("stripJPEGEXIF() error = %v", err)
+	}
+	if string(out) != string(in) {
+		t.Fatal("expected output identical to input when there's no APP1 segment")
+	}
+}
+
+func TestStripJPEGEXIF_RejectsNonJPEG(t *testing.T) {
+	if _, err := stripJPEGEXIF([]byte("not a jpeg")); err == nil {
+		t.Fatal("expected an error for non-JPEG input")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// envEscapePlaceholder stands in for an escaped "$$" while expandEnvValue
+// runs os.Expand, so a literal dollar sign survives expansion untouched.
+// It's a control character that can't occur in a flag value from a shell.
+const envEscapePlaceholder = "\x00"
+
+// expandEnvValue expands $VAR and ${VAR} references in s against the
+// process environment, the same syntax os.ExpandEnv uses, but additionally
+// honors "$$" as an escaped literal dollar sign (os.ExpandEnv has no
+// escape of its own). Fields expanded this way are listed next to their
+// flag definitions below: -out, -image-out, -external-out, -template,
+// -template-dir, -target-file, -client-cert, -client-key, -output-dir,
+// -og-out, -download-only, -from-cache, -sources-config, -grid-config and
+// -user-agent-file, so a config can reference e.g. ${HOME}/profile/README.md
+// and stay portable across machines.
+func expandEnvValue(s string) string {
+	escaped := strings.ReplaceAll(s, "$$", envEscapePlaceholder)
+	expanded := os.Expand(escaped, os.Getenv)
+	return strings.ReplaceAll(expanded, envEscapePlaceholder, "$")
+}
+
+// expandEnvConfigPaths runs expandEnvValue over every Config field that
+// names a path, in place.
+func expandEnvConfigPaths(cfg *Config) {
+	for _, field := range []*string{
+		&cfg.Out,
+		&cfg.ImageOut,
+		&cfg.ExternalOut,
+		&cfg.Template,
+		&cfg.TemplateDir,
+		&cfg.TargetFile,
+		&cfg.ClientCert,
+		&cfg.ClientKey,
+		&cfg.OutputDir,
+		&cfg.OGOut,
+		&cfg.DownloadOnly,
+		&cfg.FromCache,
+		&cfg.SourcesConfig,
+		&cfg.GridConfig,
+		&cfg.UserAgentFile,
+	} {
+		*field = expandEnvValue(*field)
+	}
+
+	for i := range cfg.Templates {
+		cfg.Templates[i] = expandEnvValue(cfg.Templates[i])
+	}
+}
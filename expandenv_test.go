@@ -0,0 +1,93 @@
+package main
+
+import "testing"
+
+func TestExpandEnvValue(t *testing.T) {
+	t.Setenv("EAGLEUSB_TEST_DIR", "/home/tester")
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"${EAGLEUSB_TEST_DIR}/profile/README.md", "/home/tester/profile/README.md"},
+		{"$EAGLEUSB_TEST_DIR/README.md", "/home/tester/README.md"},
+		{"literal $$HOME unexpanded", "literal $HOME unexpanded"},
+		{"no vars here", "no vars here"},
+	}
+	for _, tt := range tests {
+		if got := expandEnvValue(tt.in); got != tt.want {
+			t.Errorf("expandEnvValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpandEnvConfigPaths_ExpandsListedFields(t *testing.T) {
+	t.Setenv("EAGLEUSB_TEST_DIR", "/srv/eagleusb")
+
+	cfg := Config{
+		Out:           "${EAGLEUSB_TEST_DIR}/README.md",
+		ImageOut:      "${EAGLEUSB_TEST_DIR}/image.png",
+		ExternalOut:   "${EAGLEUSB_TEST_DIR}/external.md",
+		Template:      "${EAGLEUSB_TEST_DIR}/tmpl.md",
+		Templates:     []string{"${EAGLEUSB_TEST_DIR}/a.md", "${EAGLEUSB_TEST_DIR}/b.md"},
+		TemplateDir:   "${EAGLEUSB_TEST_DIR}/templates",
+		TargetFile:    "${EAGLEUSB_TEST_DIR}/target.md",
+		ClientCert:    "${EAGLEUSB_TEST_DIR}/cert.pem",
+		ClientKey:     "${EAGLEUSB_TEST_DIR}/key.pem",
+		OutputDir:     "${EAGLEUSB_TEST_DIR}/out",
+		OGOut:         "${EAGLEUSB_TEST_DIR}/og.png",
+		DownloadOnly:  "${EAGLEUSB_TEST_DIR}/cache.bin",
+		FromCache:     "${EAGLEUSB_TEST_DIR}/cache.bin",
+		SourcesConfig: "${EAGLEUSB_TEST_DIR}/sources.json",
+		GridConfig:    "${EAGLEUSB_TEST_DIR}/grid.json",
+		UserAgentFile: "${EAGLEUSB_TEST_DIR}/agents.txt",
+		URL:           "https://example.com/?user=${EAGLEUSB_TEST_DIR}",
+	}
+
+	expandEnvConfigPaths(&cfg)
+
+	want := "/srv/eagleusb"
+	for name, got := range map[string]string{
+		"Out":           cfg.Out,
+		"ImageOut":      cfg.ImageOut,
+		"ExternalOut":   cfg.ExternalOut,
+		"Template":      cfg.Template,
+		"TemplateDir":   cfg.TemplateDir,
+		"TargetFile":    cfg.TargetFile,
+		"ClientCert":    cfg.ClientCert,
+		"ClientKey":     cfg.ClientKey,
+		"OutputDir":     cfg.OutputDir,
+		"OGOut":         cfg.OGOut,
+		"DownloadOnly":  cfg.DownloadOnly,
+		"FromCache":     cfg.FromCache,
+		"SourcesConfig": cfg.SourcesConfig,
+		"GridConfig":    cfg.GridConfig,
+		"UserAgentFile": cfg.UserAgentFile,
+	} {
+		if got[:len(want)] != want {
+			t.Errorf("%s = %q, want it to start with the expanded %q", name, got, want)
+		}
+	}
+
+	for i, tmpl := range cfg.Templates {
+		if tmpl[:len(want)] != want {
+			t.Errorf("Templates[%d] = %q, want it to start with the expanded %q", i, tmpl, want)
+		}
+	}
+
+	if cfg.URL != "https://example.com/?user=${EAGLEUSB_TEST_DIR}" {
+		t.Errorf("URL = %q, want -url left unexpanded (it's not a path field)", cfg.URL)
+	}
+}
+
+func TestParseFlags_ExpandsEnvInOut(t *testing.T) {
+	t.Setenv("EAGLEUSB_TEST_DIR", "/srv/eagleusb")
+
+	cfg, err := parseFlags([]string{"-url", "https://example.com", "-out", "${EAGLEUSB_TEST_DIR}/README.md"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if want := "/srv/eagleusb/README.md"; cfg.Out != want {
+		t.Errorf("Out = %q, want %q", cfg.Out, want)
+	}
+}
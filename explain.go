@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// runExplain prints a dry preview of the resolved configuration: the URL
+// that would be fetched, the timeouts in effect, the template that would
+// render the output, the output target, and the format conversions that
+// would be applied. It never makes a network request or writes anything,
+// making it a cheaper, broader-scoped companion to -self-test (which does
+// probe the network) for understanding flag precedence while onboarding.
+func runExplain(cfg Config) error {
+	fmt.Println("url:", explainURL(cfg))
+	fmt.Println("timeout:", cfg.Timeout)
+	if cfg.TimeoutPerImage > 0 {
+		fmt.Println("timeout-per-image:", cfg.TimeoutPerImage)
+	}
+	if cfg.MaxBytes > 0 {
+		fmt.Println("max-bytes:", cfg.MaxBytes)
+	}
+	fmt.Println("connect-retries:", cfg.ConnectRetries)
+	fmt.Println("status-retries:", cfg.StatusRetries)
+	fmt.Println("jitter:", cfg.Jitter)
+
+	templateName, err := explainTemplate(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Println("template:", templateName)
+
+	fmt.Println("output:", explainOutput(cfg))
+	if isDatedOutputPattern(cfg.Out) && (cfg.PruneKeep > 0 || cfg.PruneOlderThan > 0) {
+		fmt.Println("prune:", explainPrune(cfg))
+	}
+	if cfg.ExternalOut != "" {
+		fmt.Printf("external-output: %s (same template, image linked to -image-out %q instead of a data URI)\n", cfg.ExternalOut, cfg.ImageOut)
+	}
+
+	for _, line := range explainConversions(cfg) {
+		fmt.Println("conversion:", line)
+	}
+
+	return nil
+}
+
+func explainURL(cfg Config) string {
+	switch {
+	case cfg.ImageBase64 != "":
+		return "(none, decoded from -image-base64)"
+	case cfg.ImageBase64Env != "":
+		return fmt.Sprintf("(none, decoded from env var %s via -image-base64-env)", cfg.ImageBase64Env)
+	case cfg.FromCache != "":
+		return fmt.Sprintf("(none, reading cached image and manifest from -from-cache %q)", cfg.FromCache)
+	case cfg.GridConfig != "":
+		return fmt.Sprintf("(none, resolved per-cell from -grid-config %q)", cfg.GridConfig)
+	case cfg.SourcesConfig != "":
+		return fmt.Sprintf("(none, resolved per-source from -sources-config %q)", cfg.SourcesConfig)
+	case cfg.Stack != "":
+		return fmt.Sprintf("(none, one per -stack method built from -url %q)", redactURL(cfg.URL, parseRedactNames(cfg.Redact)))
+	case cfg.URL == "":
+		return "(none set)"
+	default:
+		return redactURL(cfg.URL, parseRedactNames(cfg.Redact))
+	}
+}
+
+func explainTemplate(cfg Config) (string, error) {
+	if cfg.TemplateDir != "" {
+		if _, _, err := loadTemplateForConfig(cfg); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s (entry %q in -template-dir %q)", cfg.TemplateName, cfg.TemplateName, cfg.TemplateDir), nil
+	}
+
+	if cfg.TimeTemplateConfig != "" {
+		windows, err := loadTimeTemplateConfig(cfg.TimeTemplateConfig)
+		if err != nil {
+			return "", fmt.Errorf("-time-template-config: %w", err)
+		}
+		selected, err := selectTimeTemplate(windows, time.Now())
+		if err != nil {
+			return "", fmt.Errorf("-time-template-config: %w", err)
+		}
+		if selected != "" {
+			cfg.Template = selected
+		}
+		if _, _, err := loadTemplateForConfig(cfg); err != nil {
+			return "", err
+		}
+		label := cfg.Template
+		if label == "" {
+			label = "(built-in default)"
+		}
+		return fmt.Sprintf("%s (selected by -time-template-config %q for the current hour)", label, cfg.TimeTemplateConfig), nil
+	}
+
+	if _, _, err := loadTemplateForConfig(cfg); err != nil {
+		return "", err
+	}
+	switch cfg.Template {
+	case "":
+		return "(built-in default)", nil
+	case stdioPlaceholder:
+		return "(stdin)", nil
+	default:
+		return cfg.Template, nil
+	}
+}
+
+func explainOutput(cfg Config) string {
+	switch {
+	case cfg.TargetFile != "":
+		return fmt.Sprintf("%s (token %q replaced in place)", cfg.TargetFile, cfg.ReplaceToken)
+	case cfg.GistToken != "":
+		if cfg.GistID != "" {
+			return fmt.Sprintf("gist %s (file %q)", cfg.GistID, cfg.GistFilename)
+		}
+		return fmt.Sprintf("new gist (file %q)", cfg.GistFilename)
+	case cfg.OutputDir != "":
+		return fmt.Sprintf("%s (one file per -template)", cfg.OutputDir)
+	case cfg.Out == stdioPlaceholder:
+		return "(stdout)"
+	case isDatedOutputPattern(cfg.Out):
+		return fmt.Sprintf("%s (resolves to %s each run)", cfg.Out, resolveDatedOutputPath(cfg.Out, cfg.DateLayout, time.Now()))
+	default:
+		return cfg.Out
+	}
+}
+
+// explainPrune describes the -prune-keep/-prune-older-than thresholds that
+// would be applied to out's sibling dated files after a successful write.
+func explainPrune(cfg Config) string {
+	switch {
+	case cfg.PruneKeep > 0 && cfg.PruneOlderThan > 0:
+		return fmt.Sprintf("keep the newest %d dated outputs and delete any older than %v", cfg.PruneKeep, cfg.PruneOlderThan)
+	case cfg.PruneKeep > 0:
+		return fmt.Sprintf("keep the newest %d dated outputs", cfg.PruneKeep)
+	default:
+		return fmt.Sprintf("delete dated outputs older than %v", cfg.PruneOlderThan)
+	}
+}
+
+// explainConversions lists the format conversions -explain would apply, in
+// the order the pipeline considers them, skipping any that are disabled.
+func explainConversions(cfg Config) []string {
+	var lines []string
+
+	if cfg.ForceMime != "" {
+		lines = append(lines, fmt.Sprintf("force MIME type to %q", cfg.ForceMime))
+	}
+	if cfg.StripEXIF {
+		lines = append(lines, "strip EXIF/XMP metadata from JPEG images")
+	}
+	if cfg.ColorProfile == "strip" {
+		lines = append(lines, "strip an embedded ICC color profile from JPEG/PNG images, if present")
+	}
+	if cfg.PNGColors > 0 {
+		lines = append(lines, fmt.Sprintf("quantize to a %d-color paletted PNG", cfg.PNGColors))
+	}
+	if cfg.Optimize {
+		lines = append(lines, "down-convert 16-bit-per-channel PNGs to 8-bit")
+	}
+	if cfg.ThumbnailWidth > 0 {
+		lines = append(lines, fmt.Sprintf("embed a %dpx-wide thumbnail, linking to the full image", cfg.ThumbnailWidth))
+	}
+	if cfg.Picture {
+		lines = append(lines, "embed as a <picture> element with a WebP source and PNG fallback")
+	}
+	if cfg.Responsive {
+		lines = append(lines, "render the embedded image with a responsive style and intrinsic dimensions")
+	}
+	if cfg.Srcset {
+		lines = append(lines, "embed a 1x/2x srcset pair for Retina displays")
+	}
+	if cfg.CacheBust {
+		lines = append(lines, "embed a content-hash comment next to the image")
+	}
+	if cfg.PostCommand != "" {
+		lines = append(lines, fmt.Sprintf("pipe the rendered output through %q", cfg.PostCommand))
+	}
+	if cfg.ValidateMarkdown {
+		lines = append(lines, "validate that the result is well-formed Markdown before writing")
+	}
+	if cfg.Stack != "" || cfg.GridConfig != "" {
+		if cfg.NoText {
+			lines = append(lines, "omit the collage service's \"textlocation\" parameter (-no-text)")
+		} else if cfg.TextLocation != "" {
+			lines = append(lines, fmt.Sprintf("request collage text labels at %q", cfg.TextLocation))
+		}
+	}
+	if cfg.MaxTotalBytes > 0 {
+		suffix := ""
+		if cfg.Optimize {
+			suffix = ", re-quantizing the largest images first if needed"
+		}
+		lines = append(lines, fmt.Sprintf("fail if the combined embedded image size exceeds %d bytes%s", cfg.MaxTotalBytes, suffix))
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "(none, embedded as fetched)")
+	}
+
+	return lines
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func captureExplainOutput(t *testing.T, cfg Config) (string, error) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	runErr := runExplain(cfg)
+	w.Close()
+	os.Stdout = oldStdout
+
+	var captured bytes.Buffer
+	if _, err := captured.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+	return captured.String(), runErr
+}
+
+func TestRunExplain_DescribesDefaults(t *testing.T) {
+	cfg := Config{
+		URL:       "https://example.com/collage.png",
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		UserAgent: defaultUserAgent,
+	}
+
+	out, err := captureExplainOutput(t, cfg)
+	if err != nil {
+		t.Fatalf("runExplain() error = %v", err)
+	}
+	if !strings.Contains(out, "url: https://example.com/collage.png") {
+		t.Errorf("expected the resolved URL to be printed, got %q", out)
+	}
+	if !strings.Contains(out, "template: (built-in default)") {
+		t.Errorf("expected the default template to be described, got %q", out)
+	}
+	if !strings.Contains(out, "output: "+cfg.Out) {
+		t.Errorf("expected the output path to be printed, got %q", out)
+	}
+	if !strings.Contains(out, "conversion: (none, embedded as fetched)") {
+		t.Errorf("expected no conversions to be listed by default, got %q", out)
+	}
+}
+
+func TestRunExplain_DescribesConfiguredConversions(t *testing.T) {
+	cfg := Config{
+		URL:        "https://example.com/collage.png",
+		Out:        stdioPlaceholder,
+		UserAgent:  defaultUserAgent,
+		PNGColors:  32,
+		Picture:    true,
+		Responsive: true,
+	}
+
+	out, err := captureExplainOutput(t, cfg)
+	if err != nil {
+		t.Fatalf("runExplain() error = %v", err)
+	}
+	if !strings.Contains(out, "output: (stdout)") {
+		t.Errorf("expected -out \"-\" to be described as stdout, got %q", out)
+	}
+	for _, want := range []string{
+		"conversion: quantize to a 32-color paletted PNG",
+		"conversion: embed as a <picture> element",
+		"conversion: render the embedded image with a responsive style",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got %q", want, out)
+		}
+	}
+}
+
+func TestRunExplain_DescribesSourcesConfigAndFromCache(t *testing.T) {
+	cfg := Config{
+		FromCache: filepath.Join(t.TempDir(), "cache.json"),
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		UserAgent: defaultUserAgent,
+	}
+
+	out, err := captureExplainOutput(t, cfg)
+	if err != nil {
+		t.Fatalf("runExplain() error = %v", err)
+	}
+	if !strings.Contains(out, "-from-cache") {
+		t.Errorf("expected the -from-cache path to be mentioned, got %q", out)
+	}
+}
+
+func TestRunExplain_FailsOnUnparsableTemplate(t *testing.T) {
+	cfg := Config{
+		URL:       "https://example.com/collage.png",
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		UserAgent: defaultUserAgent,
+		Template:  "{{ .Unclosed",
+	}
+
+	if _, err := captureExplainOutput(t, cfg); err == nil {
+		t.Fatal("expected runExplain to report a template parse error")
+	}
+}
+
+func TestParseFlags_ExplainDoesNotRequireNetwork(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com", "-explain"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if !cfg.Explain {
+		t.Fatal("expected Explain to be true")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExternalTemplateData_ReplacesEmbeddedFieldsWithImageOut(t *testing.T) {
+	data := TemplateData{
+		ImageURL:     "data:image/png;base64,AAAA",
+		ThumbnailURL: "data:image/png;base64,thumb",
+		WebPImageURL: "data:image/webp;base64,BBBB",
+	}
+
+	got := externalTemplateData(data, "image.png")
+
+	if got.ImageURL != "image.png" {
+		t.Errorf("ImageURL = %q, want %q", got.ImageURL, "image.png")
+	}
+	if got.ThumbnailURL != "image.png" {
+		t.Errorf("ThumbnailURL = %q, want %q", got.ThumbnailURL, "image.png")
+	}
+	if got.WebPImageURL != "image.png" {
+		t.Errorf("WebPImageURL = %q, want %q", got.WebPImageURL, "image.png")
+	}
+	if got.PNGImageURL != "" {
+		t.Errorf("PNGImageURL = %q, want unchanged empty", got.PNGImageURL)
+	}
+}
+
+func TestParseFlags_ExternalOutRequiresImageOut(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "https://example.com", "-external-out", "external.md"}); err == nil {
+		t.Fatal("expected -external-out without -image-out to fail validation")
+	}
+}
+
+func TestPipelineRun_ExternalOutLinksInsteadOfEmbedding(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "README.md")
+	externalOut := filepath.Join(dir, "external.md")
+	imageOut := filepath.Join(dir, "collage.png")
+
+	p, err := NewPipeline(Config{
+		URL:         srv.URL,
+		Out:         out,
+		ImageOut:    imageOut,
+		ExternalOut: externalOut,
+		Timeout:     5 * time.Second,
+		UserAgent:   defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	primary, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("reading -out: %v", err)
+	}
+	if !strings.Contains(string(primary), "data:image/png;base64,") {
+		t.Error("expected -out to embed a data URI")
+	}
+
+	external, err := os.ReadFile(externalOut)
+	if err != nil {
+		t.Fatalf("reading -external-out: %v", err)
+	}
+	if strings.Contains(string(external), "data:image/png;base64,") {
+		t.Error("expected -external-out not to embed a data URI")
+	}
+	if !strings.Contains(string(external), imageOut) {
+		t.Errorf("expected -external-out to reference %q", imageOut)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func animatedWebPServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	body := makeAnimatedWebP()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/webp")
+		w.Write(body)
+	}))
+}
+
+func TestPipelineRun_FailOnWarningFailsOnAnimatedWebP(t *testing.T) {
+	srv := animatedWebPServer(t)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:           srv.URL,
+		Out:           filepath.Join(t.TempDir(), "README.md"),
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+		FailOnWarning: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail when -fail-on-warning is set and the collage is an animated WebP")
+	}
+}
+
+func TestPipelineRun_WarningIsNonFatalByDefault(t *testing.T) {
+	srv := animatedWebPServer(t)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want nil since -fail-on-warning wasn't set", err)
+	}
+}
+
+func TestPipelineRun_FailOnWarningPassesWithoutWarning(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:           srv.URL,
+		Out:           filepath.Join(t.TempDir(), "README.md"),
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+		FailOnWarning: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want nil since no warning occurred", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun_FallbackLinkAddsRedactedSourceURL(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	srcURL := srv.URL + "?api_key=supersecret"
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:          srcURL,
+		Out:          out,
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+		FallbackLink: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, _, err := p.renderOutput()
+	if err != nil {
+		t.Fatalf("renderOutput() error = %v", err)
+	}
+	if data.SourceURL == "" {
+		t.Fatal("expected SourceURL to be set")
+	}
+	if strings.Contains(data.SourceURL, "supersecret") {
+		t.Errorf("SourceURL = %q, want the api_key redacted", data.SourceURL)
+	}
+
+	u, err := url.Parse(data.SourceURL)
+	if err != nil {
+		t.Fatalf("parsing SourceURL: %v", err)
+	}
+	if u.Query().Get("api_key") != "REDACTED" {
+		t.Errorf("api_key = %q, want REDACTED", u.Query().Get("api_key"))
+	}
+}
+
+func TestPipelineRun_NoFallbackLinkLeavesSourceURLEmpty(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, _, err := p.renderOutput()
+	if err != nil {
+		t.Fatalf("renderOutput() error = %v", err)
+	}
+	if data.SourceURL != "" {
+		t.Errorf("SourceURL = %q, want empty without -fallback-link", data.SourceURL)
+	}
+}
+
+func TestDefaultTemplate_RendersFallbackLinkWhenSourceURLSet(t *testing.T) {
+	tmpl, _, err := loadTemplateForConfig(Config{})
+	if err != nil {
+		t.Fatalf("loadTemplateForConfig() error = %v", err)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, TemplateData{ImageURL: "data:image/png;base64,AA==", SourceURL: "https://example.com/collage.png"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "https://example.com/collage.png") {
+		t.Errorf("rendered = %q, want it to contain the fallback link", buf.String())
+	}
+}
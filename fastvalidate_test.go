@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestValidateSignature_AcceptsRealHeaders(t *testing.T) {
+	cases := []struct {
+		mime string
+		data []byte
+	}{
+		{"image/png", makePNG(1, 1)},
+		{"image/jpeg", makeJPEG(1, 1)},
+		{"image/gif", makeGIF(1, 1)},
+	}
+	for _, c := range cases {
+		if err := validateSignature(c.data, c.mime); err != nil {
+			t.Errorf("validateSignature(%s) error = %v", c.mime, err)
+		}
+	}
+}
+
+func TestValidateSignature_RejectsCorruptHeaders(t *testing.T) {
+	cases := []struct {
+		mime string
+		data []byte
+	}{
+		{"image/png", []byte("not a png")},
+		{"image/jpeg", []byte("not a jpeg")},
+		{"image/gif", []byte("not a gif")},
+	}
+	for _, c := range cases {
+		if err := validateSignature(c.data, c.mime); err == nil {
+			t.Errorf("validateSignature(%s) expected an error, got nil", c.mime)
+		}
+	}
+}
+
+func TestEncodeImageToBase64_FastValidateSkipsFullDecode(t *testing.T) {
+	// A PNG with a real signature but garbage chunk data after it: a full
+	// decode would reject it, but -fast-validate only checks the signature.
+	data := corruptPNG()
+
+	if _, _, _, err := encodeImageToBase64(data, "image/png", "", false); err == nil {
+		t.Fatal("expected a full decode to reject corrupt chunk data")
+	}
+	if _, _, _, err := encodeImageToBase64(data, "image/png", "", true); err != nil {
+		t.Fatalf("encodeImageToBase64(fastValidate=true) error = %v, want the signature-only check to pass", err)
+	}
+}
+
+func TestEncodeImageToBase64_FastValidateStillRejectsBadSignature(t *testing.T) {
+	if _, _, _, err := encodeImageToBase64([]byte("definitely not an image"), "image/png", "", true); err == nil {
+		t.Fatal("expected an error for a missing PNG signature even with -fast-validate")
+	}
+}
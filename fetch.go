@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// retryBudget tracks independent retry allowances for connection-level
+// failures (DNS/dial/TLS) versus HTTP status failures (5xx/429). The two are
+// kept separate because the right backoff and tolerance differ: a flaky
+// resolver calls for more patience than a server that is actively erroring.
+type retryBudget struct {
+	ConnectRetries int
+	StatusRetries  int
+	JitterStrategy string
+}
+
+// statusError represents a non-2xx HTTP response, distinguishing it from a
+// connection-establishment failure for retry classification.
+type statusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %s", e.Status)
+}
+
+// truncatedReadError means the response body stopped short of its declared
+// Content-Length, e.g. because the context deadline fired mid-download. It
+// wraps the underlying read error so errors.Is(err, context.DeadlineExceeded)
+// still works, while giving a clearer message than the bare read error: this
+// is network flakiness, not a corrupt-but-complete download (which instead
+// surfaces as a decode error, see ErrDecode).
+type truncatedReadError struct {
+	BytesRead     int64
+	ContentLength int64
+	Err           error
+}
+
+func (e *truncatedReadError) Error() string {
+	return fmt.Sprintf("download truncated (read %d of %d bytes): %v", e.BytesRead, e.ContentLength, e.Err)
+}
+
+func (e *truncatedReadError) Unwrap() error {
+	return e.Err
+}
+
+// fetchImage downloads the image at url, retrying according to budget:
+// dial/connection errors consult ConnectRetries, HTTP status errors consult
+// StatusRetries. It returns the raw body bytes and the response headers.
+func fetchImage(ctx context.Context, client *http.Client, url, userAgent, accept string, budget retryBudget, redactNames []string, logger *slog.Logger, rng *seededRand) ([]byte, http.Header, error) {
+	body, header, _, _, _, err := fetchImageConditional(ctx, client, url, userAgent, accept, "", budget, "", false, 0, "", nil, "", redactNames, logger, rng)
+	return body, header, err
+}
+
+// fetchImageConditional is like fetchImage but sends an If-None-Match
+// request header when ifNoneMatch is non-empty, letting a server reply 304
+// Not Modified. notModified is true when that happens, in which case body
+// is nil and the caller should reuse its previous copy. The response
+// headers are returned either way, so callers can read the ETag for the
+// next cycle (e.g. watch mode's in-memory cache) or other metadata.
+// acceptLanguage, when non-empty, is forwarded as the Accept-Language
+// header, in case the source localizes its response (see -lang). When
+// trace is true (-trace), timing breaks down DNS/connect/TLS/TTFB for the
+// final attempt; it's the zero value otherwise. maxBytes, when positive
+// (-max-bytes), caps how much of the response body is read, returning
+// ErrMaxBytes if it's exceeded - enforced against the actual bytes read via
+// io.LimitReader, not a declared Content-Length, so it still catches an
+// oversized chunked response that never sends that header. authToken, when
+// non-empty (-auth-token-file, see tokenstore.go), is sent as an
+// `Authorization: Bearer` header for sources that require it. spoolFile,
+// when non-nil (-spool, see spool.go), receives the response body instead
+// of it being buffered into the returned body slice, which is nil in that
+// case; spoolFile is truncated before each attempt, so a retried fetch
+// doesn't append to a partial write left by the one before it. cookie, when
+// non-empty (-cookie), is sent as a literal Cookie header - an escape hatch
+// for a source behind anti-bot protection (see antibot.go) where the user
+// has already solved the challenge in a browser and copied its session
+// cookie out. If ctx carries a run ID (see withRunID/-run-id), every log
+// line this call emits through logger is tagged with it for correlation.
+// finalURL is the URL the response actually came from, after following any
+// redirects - equal to url when none occurred (see
+// -warn-on-redirect-to-different-host). rng backs retry backoff's jitter
+// (see applyJitter), seeded from -seed.
+func fetchImageConditional(ctx context.Context, client *http.Client, url, userAgent, accept, acceptLanguage string, budget retryBudget, ifNoneMatch string, trace bool, maxBytes int64, authToken string, spoolFile *os.File, cookie string, redactNames []string, logger *slog.Logger, rng *seededRand) (body []byte, header http.Header, notModified bool, timing fetchTiming, finalURL string, err error) {
+	if runID := runIDFromContext(ctx); runID != "" && logger != nil {
+		logger = logger.With(slog.String("run_id", runID))
+	}
+
+	connectAttempts, statusAttempts := 0, 0
+	var lastErr error
+	start := time.Now()
+
+	for {
+		body, header, notModified, timing, finalURL, err := doFetch(ctx, client, url, userAgent, accept, acceptLanguage, ifNoneMatch, trace, maxBytes, authToken, spoolFile, cookie)
+		if err == nil {
+			if trace {
+				logTiming(logger, url, redactNames, timing)
+			}
+			return body, header, notModified, timing, finalURL, nil
+		}
+		lastErr = err
+
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && isRetryableStatus(statusErr.StatusCode) {
+			if statusAttempts >= budget.StatusRetries {
+				break
+			}
+			statusAttempts++
+			delay := applyJitter(rng, backoff(statusAttempts), budget.JitterStrategy)
+			logRetry(logger, url, redactNames, statusAttempts, delay, statusErr.StatusCode, nil, time.Since(start))
+			if waitErr := sleepOrDone(ctx, delay); waitErr != nil {
+				return nil, nil, false, fetchTiming{}, "", waitErr
+			}
+			continue
+		}
+
+		// Dial/DNS/TLS failures and transport-level resets (GOAWAY,
+		// connection reset, unexpected EOF) both land here and share
+		// ConnectRetries: isRetryableTransportError doesn't gate the
+		// retry, it only sharpens the log line below, since an
+		// unrecognized transport error is just as likely to be a
+		// transient network blip worth retrying.
+		if connectAttempts >= budget.ConnectRetries {
+			break
+		}
+		connectAttempts++
+		delay := applyJitter(rng, backoff(connectAttempts), budget.JitterStrategy)
+		logRetry(logger, url, redactNames, connectAttempts, delay, 0, err, time.Since(start))
+		if waitErr := sleepOrDone(ctx, delay); waitErr != nil {
+			return nil, nil, false, fetchTiming{}, "", waitErr
+		}
+	}
+
+	return nil, nil, false, fetchTiming{}, "", fmt.Errorf("fetching %s: %w", url, lastErr)
+}
+
+// logTiming emits one debug-level structured event with a fetch's
+// DNS/connect/TLS-handshake/TTFB breakdown, for -trace.
+func logTiming(logger *slog.Logger, url string, redactNames []string, timing fetchTiming) {
+	if logger == nil {
+		return
+	}
+	logger.Debug("fetch timing",
+		slog.String("url", redactURL(url, redactNames)),
+		slog.Duration("dns", timing.DNS),
+		slog.Duration("connect", timing.Connect),
+		slog.Duration("tls_handshake", timing.TLSHandshake),
+		slog.Duration("ttfb", timing.TTFB),
+	)
+}
+
+// logRetry emits one debug-level structured event per retry decision, with
+// the URL's credentials and sensitive query parameters redacted.
+func logRetry(logger *slog.Logger, url string, redactNames []string, attempt int, delay time.Duration, statusCode int, connectErr error, elapsed time.Duration) {
+	if logger == nil {
+		return
+	}
+	attrs := []any{
+		slog.String("url", redactURL(url, redactNames)),
+		slog.Int("attempt", attempt),
+		slog.Duration("delay", delay),
+		slog.Duration("elapsed", elapsed),
+	}
+	if statusCode != 0 {
+		attrs = append(attrs, slog.Int("status_code", statusCode))
+	}
+	if connectErr != nil {
+		attrs = append(attrs, slog.String("error", connectErr.Error()))
+		if isRetryableTransportError(connectErr) {
+			attrs = append(attrs, slog.String("reason", "transport_reset"))
+		}
+	}
+	logger.Debug("retrying fetch", attrs...)
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// isRetryableTransportError reports whether err looks like a mid-transfer
+// transport failure worth retrying: the server sending an HTTP/2 GOAWAY,
+// resetting the connection, or closing it unexpectedly. Neither net/http
+// nor its internal HTTP/2 transport exports a sentinel or typed error for
+// these - they surface as an opaque *url.Error wrapping one of a handful of
+// well-known strings - so matching the message is the only option. They're
+// folded into the same connect-level retry budget as dial/DNS/TLS failures
+// (see retryBudget.ConnectRetries) rather than tracked separately, since
+// doFetch always issues a brand new *http.Request on retry and
+// http.Transport never hands back a connection that just errored, so the
+// retry is inherently against a fresh connection already.
+func isRetryableTransportError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, needle := range []string{
+		"connection reset by peer",
+		"broken pipe",
+		"use of closed network connection",
+		"http2: server sent GOAWAY",
+		"http2: client conn not usable",
+		"unexpected EOF",
+	} {
+		if strings.Contains(msg, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func sleepOrDone(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func doFetch(ctx context.Context, client *http.Client, url, userAgent, accept, acceptLanguage, ifNoneMatch string, trace bool, maxBytes int64, authToken string, spoolFile *os.File, cookie string) (body []byte, header http.Header, notModified bool, timing fetchTiming, finalURL string, err error) {
+	if trace {
+		ctx = withClientTrace(ctx, &timing)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, false, fetchTiming{}, "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	if authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+authToken)
+	}
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, false, timing, "", err
+	}
+	defer resp.Body.Close()
+
+	finalURL = resp.Request.URL.String()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, resp.Header, true, timing, finalURL, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := &statusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		if looksLikeAntiBotChallengeStatus(resp.StatusCode, resp.Header) {
+			return nil, nil, false, timing, "", fmt.Errorf("%w: try setting a browser-like -user-agent or a -cookie: %w", ErrAntiBot, statusErr)
+		}
+		return nil, nil, false, timing, "", statusErr
+	}
+
+	reader := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		reader = io.LimitReader(resp.Body, maxBytes+1)
+	}
+
+	if spoolFile != nil {
+		n, err := spoolResponseBody(spoolFile, reader)
+		if err != nil {
+			if resp.ContentLength > 0 && n < resp.ContentLength {
+				return nil, nil, false, timing, "", &truncatedReadError{BytesRead: n, ContentLength: resp.ContentLength, Err: err}
+			}
+			return nil, nil, false, timing, "", err
+		}
+		if maxBytes > 0 && n > maxBytes {
+			return nil, nil, false, timing, "", fmt.Errorf("%w: got more than %d bytes", ErrMaxBytes, maxBytes)
+		}
+		return nil, resp.Header, false, timing, finalURL, nil
+	}
+
+	body, err = io.ReadAll(reader)
+	if err != nil {
+		if resp.ContentLength > 0 && int64(len(body)) < resp.ContentLength {
+			return nil, nil, false, timing, "", &truncatedReadError{BytesRead: int64(len(body)), ContentLength: resp.ContentLength, Err: err}
+		}
+		return nil, nil, false, timing, "", err
+	}
+
+	if maxBytes > 0 && int64(len(body)) > maxBytes {
+		return nil, nil, false, timing, "", fmt.Errorf("%w: got more than %d bytes", ErrMaxBytes, maxBytes)
+	}
+
+	return body, resp.Header, false, timing, finalURL, nil
+}
+
+// backoff returns a simple linear backoff delay for the given attempt.
+func backoff(attempt int) time.Duration {
+	return time.Duration(attempt) * 500 * time.Millisecond
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchImage_RetriesStatusWithinBudget(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	body, _, err := fetchImage(context.Background(), srv.Client(), srv.URL, defaultUserAgent, "", retryBudget{StatusRetries: 3}, nil, nil, newSeededRand(1))
+	if err != nil {
+		t.Fatalf("fetchImage() error = %v", err)
+	}
+	if string(body) != "ok" {
+		t.Fatalf("body = %q, want %q", body, "ok")
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDoFetch_TruncatedBodyReportsBytesReadOfContentLength(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("short"))
+	}))
+	defer srv.Close()
+
+	_, _, _, _, _, err := doFetch(context.Background(), srv.Client(), srv.URL, defaultUserAgent, "", "", "", false, 0, "", nil, "")
+	if err == nil {
+		t.Fatal("expected an error for a body shorter than its Content-Length")
+	}
+
+	var truncErr *truncatedReadError
+	if !errors.As(err, &truncErr) {
+		t.Fatalf("error = %v, want a *truncatedReadError", err)
+	}
+	if truncErr.BytesRead != 5 || truncErr.ContentLength != 100 {
+		t.Fatalf("truncatedReadError = %+v, want BytesRead=5 ContentLength=100", truncErr)
+	}
+}
+
+func TestDoFetch_MaxBytesRejectsChunkedResponseWithoutContentLength(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Writing through a Flusher without ever setting Content-Length
+		// forces Go's http server to respond chunked, the case -max-bytes
+		// must catch without a declared length to check against.
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("http.ResponseWriter does not support flushing")
+		}
+		w.Header().Set("Content-Type", "image/png")
+		for i := 0; i < 5; i++ {
+			w.Write(bytes.Repeat([]byte("x"), 100))
+			flusher.Flush()
+		}
+	}))
+	defer srv.Close()
+
+	_, _, _, _, _, err := doFetch(context.Background(), srv.Client(), srv.URL, defaultUserAgent, "", "", "", false, 200, "", nil, "")
+	if !errors.Is(err, ErrMaxBytes) {
+		t.Fatalf("doFetch() error = %v, want ErrMaxBytes", err)
+	}
+}
+
+func TestDoFetch_MaxBytesAllowsResponseUnderLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	body, _, _, _, _, err := doFetch(context.Background(), srv.Client(), srv.URL, defaultUserAgent, "", "", "", false, 200, "", nil, "")
+	if err != nil {
+		t.Fatalf("doFetch() error = %v", err)
+	}
+	if string(body) != "ok" {
+		t.Fatalf("body = %q, want %q", body, "ok")
+	}
+}
+
+func TestFetchImage_GivesUpAfterStatusBudget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	_, _, err := fetchImage(context.Background(), srv.Client(), srv.URL, defaultUserAgent, "", retryBudget{StatusRetries: 1}, nil, nil, newSeededRand(1))
+	if err == nil {
+		t.Fatal("expected an error once the status retry budget is exhausted")
+	}
+}
+
+// resettingListener accepts TCP connections and, for the first n of them,
+// forces a hard RST (via SO_LINGER 0) instead of a clean close, simulating
+// the opaque "connection reset by peer" a flaky HTTP/2 upstream produces on
+// GOAWAY or a mid-transfer reset. Connections past n get a real, valid
+// response written by serve.
+type resettingListener struct {
+	net.Listener
+	resets int32
+}
+
+func (l *resettingListener) accept(serve func(net.Conn)) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return
+		}
+		if l.resets > 0 {
+			l.resets--
+			if tcp, ok := conn.(*net.TCPConn); ok {
+				tcp.SetLinger(0)
+			}
+			conn.Close()
+			continue
+		}
+		go serve(conn)
+	}
+}
+
+func TestFetchImage_RetriesConnectionResetWithFreshConnection(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer ln.Close()
+
+	rl := &resettingListener{Listener: ln, resets: 1}
+	go rl.accept(func(conn net.Conn) {
+		defer conn.Close()
+		buf := make([]byte, 4096)
+		conn.Read(buf)
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Type: image/png\r\nContent-Length: 2\r\n\r\nok"))
+	})
+
+	url := "http://" + ln.Addr().String() + "/"
+	body, _, err := fetchImage(context.Background(), http.DefaultClient, url, defaultUserAgent, "", retryBudget{ConnectRetries: 2}, nil, nil, newSeededRand(1))
+	if err != nil {
+		t.Fatalf("fetchImage() error = %v, want the reset attempt to be retried", err)
+	}
+	if string(body) != "ok" {
+		t.Fatalf("body = %q, want %q", body, "ok")
+	}
+}
+
+func TestIsRetryableTransportError(t *testing.T) {
+	if isRetryableTransportError(nil) {
+		t.Error("isRetryableTransportError(nil) = true, want false")
+	}
+	if !isRetryableTransportError(errors.New("read tcp 127.0.0.1:1234->127.0.0.1:80: connection reset by peer")) {
+		t.Error("expected a connection reset to be classified as retryable")
+	}
+	if !isRetryableTransportError(errors.New("http2: server sent GOAWAY")) {
+		t.Error("expected a GOAWAY to be classified as retryable")
+	}
+	if isRetryableTransportError(errors.New("unsupported protocol scheme")) {
+		t.Error("expected an unrelated error not to be classified as a transport reset")
+	}
+}
+
+// TestFetchImage_HonorsContextDeadlineUnderRepeatedFailures guards against a
+// latent timing bug: every backoff sleep must be done via a select on
+// ctx.Done(), not a bare time.Sleep, or a sleep scheduled just before the
+// deadline can overrun it.
+func TestFetchImage_HonorsContextDeadlineUnderRepeatedFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	const timeout = 200 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := fetchImage(ctx, srv.Client(), srv.URL, defaultUserAgent, "", retryBudget{StatusRetries: 1000}, nil, nil, newSeededRand(1))
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected an error once the context deadline is exceeded")
+	}
+	if margin := 150 * time.Millisecond; elapsed > timeout+margin {
+		t.Fatalf("fetchImage() took %v, want close to the %v deadline (margin %v)", elapsed, timeout, margin)
+	}
+}
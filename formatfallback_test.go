@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseFormatFallback_ValidList(t *testing.T) {
+	formats, err := parseFormatFallback("webp, png")
+	if err != nil {
+		t.Fatalf("parseFormatFallback() error = %v", err)
+	}
+	if len(formats) != 2 || formats[0] != "webp" || formats[1] != "png" {
+		t.Errorf("formats = %v, want [webp png]", formats)
+	}
+}
+
+func TestParseFormatFallback_Empty(t *testing.T) {
+	formats, err := parseFormatFallback("")
+	if err != nil || formats != nil {
+		t.Errorf("parseFormatFallback(\"\") = %v, %v, want nil, nil", formats, err)
+	}
+}
+
+func TestParseFormatFallback_RejectsUnknownFormat(t *testing.T) {
+	if _, err := parseFormatFallback("webp,avif"); err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+}
+
+func TestFormatFallbackURL_SetsFormatParam(t *testing.T) {
+	got, err := formatFallbackURL("https://example.com/collage?method=albums", "png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://example.com/collage?format=png&method=albums"
+	if got != want {
+		t.Errorf("formatFallbackURL() = %q, want %q", got, want)
+	}
+}
+
+func TestFetchWithFormatFallback_SkipsInvalidFirstFormat(t *testing.T) {
+	png := makePNG(4, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "webp" {
+			w.Header().Set("Content-Type", "image/webp")
+			w.Write([]byte("not actually webp"))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	body, header, _, _, err := fetchWithFormatFallback(context.Background(), srv.Client(), srv.URL, defaultUserAgent, "image/webp,image/png", "", []string{"webp", "png"}, retryBudget{}, 0, "", "", false, nil, nil, newSeededRand(1))
+	if err != nil {
+		t.Fatalf("fetchWithFormatFallback() error = %v", err)
+	}
+	if header.Get("Content-Type") != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", header.Get("Content-Type"))
+	}
+	if len(body) != len(png) {
+		t.Errorf("body length = %d, want %d (the PNG, not the bogus WebP)", len(body), len(png))
+	}
+}
+
+func TestFetchWithFormatFallback_AllFormatsInvalid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("garbage"))
+	}))
+	defer srv.Close()
+
+	if _, _, _, _, err := fetchWithFormatFallback(context.Background(), srv.Client(), srv.URL, defaultUserAgent, "image/png", "", []string{"webp", "png"}, retryBudget{}, 0, "", "", false, nil, nil, newSeededRand(1)); err == nil {
+		t.Fatal("expected an error when every format fails validation")
+	}
+}
+
+func TestParseFlags_FormatFallbackRejectsUnknownFormat(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "https://example.com", "-format-fallback", "avif"}); err == nil {
+		t.Fatal("expected -format-fallback validation to fail at config-parse time")
+	}
+}
+
+func TestPipelineRun_FormatFallbackFallsBackToSecondFormat(t *testing.T) {
+	png := makePNG(4, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") == "webp" {
+			w.Header().Set("Content-Type", "image/webp")
+			w.Write([]byte("not webp"))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:            srv.URL,
+		Out:            filepath.Join(t.TempDir(), "README.md"),
+		Timeout:        5 * time.Second,
+		UserAgent:      defaultUserAgent,
+		FormatFallback: "webp,png",
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
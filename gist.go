@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// gistAPIBase is the GitHub REST API host, overridable in tests so they can
+// point it at an httptest server instead of the real api.github.com.
+var gistAPIBase = "https://api.github.com"
+
+// gistFile is one entry of a gist's "files" map, per the GitHub Gists API.
+type gistFile struct {
+	Content string `json:"content"`
+}
+
+// gistRequest is the body of both the create (POST /gists) and update
+// (PATCH /gists/{id}) requests; GitHub accepts the same shape for either.
+type gistRequest struct {
+	Description string              `json:"description,omitempty"`
+	Public      bool                `json:"public"`
+	Files       map[string]gistFile `json:"files"`
+}
+
+// gistResponse is the subset of GitHub's gist JSON this package needs.
+type gistResponse struct {
+	ID      string `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+// uploadGist creates a new gist, or updates gistID when non-empty, with a
+// single file named filename containing content. It implements -gist-token:
+// an alternative to writing -out to the local filesystem, for profiles that
+// embed their README in a gist instead of a repository. It returns the
+// gist's HTML URL, suitable for runResult.OutputPath.
+func uploadGist(ctx context.Context, client *http.Client, token, gistID, filename string, content []byte) (string, error) {
+	payload := gistRequest{
+		Description: "generated by eagleusb",
+		Public:      true,
+		Files:       map[string]gistFile{filename: {Content: string(content)}},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	url := gistAPIBase + "/gists"
+	method := http.MethodPost
+	if gistID != "" {
+		url = gistAPIBase + "/gists/" + gistID
+		method = http.MethodPatch
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "token "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("github api returned %s: %s", resp.Status, respBody)
+	}
+
+	var result gistResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", err
+	}
+	return result.HTMLURL, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeGistServer records every request it receives and returns a fixed
+// gist ID/URL, mimicking just enough of the GitHub Gists API for uploadGist.
+func fakeGistServer(t *testing.T, requests *[]*http.Request) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		*requests = append(*requests, r)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		json.NewEncoder(w).Encode(gistResponse{ID: "abc123", HTMLURL: "https://gist.github.com/user/abc123"})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUploadGist_CreatesWhenGistIDEmpty(t *testing.T) {
+	var requests []*http.Request
+	gistSrv := fakeGistServer(t, &requests)
+	orig := gistAPIBase
+	gistAPIBase = gistSrv.URL
+	defer func() { gistAPIBase = orig }()
+
+	url, err := uploadGist(context.Background(), http.DefaultClient, "token123", "", "README.md", []byte("hello"))
+	if err != nil {
+		t.Fatalf("uploadGist() error = %v", err)
+	}
+	if url != "https://gist.github.com/user/abc123" {
+		t.Errorf("url = %q, want the gist's html_url", url)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	if requests[0].Method != http.MethodPost {
+		t.Errorf("method = %q, want POST for an empty -gist-id", requests[0].Method)
+	}
+	if requests[0].URL.Path != "/gists" {
+		t.Errorf("path = %q, want /gists", requests[0].URL.Path)
+	}
+	if got := requests[0].Header.Get("Authorization"); got != "token token123" {
+		t.Errorf("Authorization = %q, want %q", got, "token token123")
+	}
+}
+
+func TestUploadGist_UpdatesWhenGistIDSet(t *testing.T) {
+	var requests []*http.Request
+	gistSrv := fakeGistServer(t, &requests)
+	orig := gistAPIBase
+	gistAPIBase = gistSrv.URL
+	defer func() { gistAPIBase = orig }()
+
+	if _, err := uploadGist(context.Background(), http.DefaultClient, "token123", "abc123", "README.md", []byte("hello")); err != nil {
+		t.Fatalf("uploadGist() error = %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	if requests[0].Method != http.MethodPatch {
+		t.Errorf("method = %q, want PATCH for a non-empty -gist-id", requests[0].Method)
+	}
+	if !strings.HasSuffix(requests[0].URL.Path, "/gists/abc123") {
+		t.Errorf("path = %q, want suffix /gists/abc123", requests[0].URL.Path)
+	}
+}
+
+func TestUploadGist_NonOKStatusReturnsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Bad credentials"}`))
+	}))
+	defer srv.Close()
+	orig := gistAPIBase
+	gistAPIBase = srv.URL
+	defer func() { gistAPIBase = orig }()
+
+	_, err := uploadGist(context.Background(), http.DefaultClient, "bad-token", "", "README.md", []byte("hello"))
+	if err == nil {
+		t.Fatal("expected an error for a 401 response")
+	}
+}
+
+func TestPipelineRun_GistTokenUploadsInsteadOfWritingOut(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	var requests []*http.Request
+	gistSrv := fakeGistServer(t, &requests)
+	orig := gistAPIBase
+	gistAPIBase = gistSrv.URL
+	defer func() { gistAPIBase = orig }()
+
+	out := t.TempDir() + "/README.md"
+	p, err := NewPipeline(Config{
+		URL:          imgSrv.URL,
+		Out:          out,
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+		GistToken:    "token123",
+		GistFilename: "README.md",
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	result, err := p.runOnce()
+	if err != nil {
+		t.Fatalf("runOnce() error = %v", err)
+	}
+	if result.OutputPath != "https://gist.github.com/user/abc123" {
+		t.Errorf("OutputPath = %q, want the gist URL", result.OutputPath)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("got %d gist requests, want 1", len(requests))
+	}
+
+	var body gistRequest
+	if err := json.NewDecoder(requests[0].Body).Decode(&body); err != nil {
+		t.Fatalf("decoding gist request body: %v", err)
+	}
+	if _, ok := body.Files["README.md"]; !ok {
+		t.Errorf("gist files = %v, want a README.md entry", body.Files)
+	}
+}
+
+func TestParseFlags_GistIDWithoutTokenIsRejected(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-gist-id", "abc123"})
+	if err == nil {
+		t.Fatal("expected an error when -gist-id is set without -gist-token")
+	}
+}
+
+func TestParseFlags_GistTokenAloneIsAccepted(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com", "-gist-token", "token123"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v, want nil", err)
+	}
+	if cfg.GistToken != "token123" {
+		t.Errorf("GistToken = %q, want %q", cfg.GistToken, "token123")
+	}
+}
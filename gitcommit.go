@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// CommitData is made available to -commit-message-template, so the commit
+// history left by -git-commit stays informative (e.g. "update collage:
+// 823KB -> 640KB, webp") instead of a static one-liner.
+type CommitData struct {
+	OldSize   int64
+	NewSize   int64
+	Format    string
+	Timestamp time.Time
+}
+
+const defaultCommitMessage = `update collage ({{.Format}}, {{.OldSize}}B -> {{.NewSize}}B)`
+
+// renderCommitMessage executes tmplSrc (or the built-in default when empty)
+// against data, the same way renderTemplate does for README templates, just
+// to a string instead of a file.
+func renderCommitMessage(tmplSrc string, data CommitData) (string, error) {
+	if tmplSrc == "" {
+		tmplSrc = defaultCommitMessage
+	}
+
+	tmpl, err := template.New("commit-message").Parse(tmplSrc)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// mimeFromDataURI extracts the short format name (e.g. "png") from a
+// "data:image/png;base64,..." URI, for use in commit messages. It returns
+// "unknown" for anything else, including plain markdown image URLs.
+func mimeFromDataURI(dataURI string) string {
+	const prefix = "data:image/"
+	if !strings.HasPrefix(dataURI, prefix) {
+		return "unknown"
+	}
+	rest := dataURI[len(prefix):]
+	format, _, _ := strings.Cut(rest, ";")
+	if format == "" {
+		return "unknown"
+	}
+	return format
+}
+
+// commitOutput stages and commits out in the git repository containing it,
+// using message. It's the automation behind -git-commit: regenerating a
+// profile README and committing the result in one run. If staging out
+// leaves nothing different from HEAD - the fetched collage round-tripped to
+// the same bytes as last time - it reports that and returns without
+// committing, rather than adding an empty commit to the history; this
+// matters most for scheduled/-watch runs, which would otherwise produce a
+// stream of no-op commits every cycle.
+func commitOutput(out, message string) error {
+	dir := filepath.Dir(out)
+
+	addCmd := exec.Command("git", "-C", dir, "add", filepath.Base(out))
+	if output, err := addCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git add: %w: %s", err, output)
+	}
+
+	// --cached compares the now-staged file against HEAD, rather than
+	// plain "git diff", which silently ignores paths that aren't tracked
+	// yet (e.g. out's very first commit) and would wrongly report "no
+	// changes" for it.
+	diffCmd := exec.Command("git", "-C", dir, "diff", "--cached", "--quiet", "--", filepath.Base(out))
+	if err := diffCmd.Run(); err == nil {
+		fmt.Fprintln(os.Stderr, "eagleusb: no changes to commit")
+		return nil
+	} else if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		return fmt.Errorf("git diff --cached: %w", err)
+	}
+
+	commitCmd := exec.Command("git", "-C", dir, "commit", "-m", message, "--", filepath.Base(out))
+	if output, err := commitCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git commit: %w: %s", err, output)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initGitRepoWithFile creates a git repository in a temp dir, writes name
+// with contents, and commits it, so tests can exercise commitOutput against
+// a file that already has committed history behind it.
+func initGitRepoWithFile(t *testing.T, name, contents string) (dir, path string) {
+	t.Helper()
+	dir = t.TempDir()
+
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "test")
+
+	path = filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+
+	return dir, path
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, output)
+	}
+}
+
+func lastCommitSubjects(t *testing.T, dir string, n int) []string {
+	t.Helper()
+	cmd := exec.Command("git", "-C", dir, "log", "--format=%s", "-n", fmt.Sprint(n))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git log: %v: %s", err, output)
+	}
+	return strings.Split(strings.TrimSpace(string(output)), "\n")
+}
+
+func TestRenderCommitMessage_Default(t *testing.T) {
+	msg, err := renderCommitMessage("", CommitData{OldSize: 823000, NewSize: 640000, Format: "webp"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"823000", "640000", "webp"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("message = %q, want it to contain %q", msg, want)
+		}
+	}
+}
+
+func TestRenderCommitMessage_CustomTemplate(t *testing.T) {
+	msg, err := renderCommitMessage("collage refreshed: {{.Format}}", CommitData{Format: "png"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg != "collage refreshed: png" {
+		t.Fatalf("message = %q, want %q", msg, "collage refreshed: png")
+	}
+}
+
+func TestMimeFromDataURI(t *testing.T) {
+	cases := map[string]string{
+		"data:image/png;base64,abcd":  "png",
+		"data:image/webp;base64,abcd": "webp",
+		"not-a-data-uri":              "unknown",
+	}
+	for input, want := range cases {
+		if got := mimeFromDataURI(input); got != want {
+			t.Errorf("mimeFromDataURI(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCommitOutput_SkipsWhenUnchanged(t *testing.T) {
+	dir, path := initGitRepoWithFile(t, "README.md", "same contents\n")
+
+	if err := commitOutput(path, "update collage"); err != nil {
+		t.Fatalf("commitOutput() error = %v", err)
+	}
+
+	subjects := lastCommitSubjects(t, dir, 2)
+	if len(subjects) != 1 || subjects[0] != "initial" {
+		t.Fatalf("commits = %v, want only the initial commit (no-op skipped)", subjects)
+	}
+}
+
+func TestCommitOutput_CommitsWhenChanged(t *testing.T) {
+	dir, path := initGitRepoWithFile(t, "README.md", "old contents\n")
+
+	if err := os.WriteFile(path, []byte("new contents\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := commitOutput(path, "update collage"); err != nil {
+		t.Fatalf("commitOutput() error = %v", err)
+	}
+
+	subjects := lastCommitSubjects(t, dir, 1)
+	if subjects[0] != "update collage" {
+		t.Fatalf("last commit subject = %q, want %q", subjects[0], "update collage")
+	}
+}
+
+func TestCommitOutput_CommitsBrandNewUntrackedFile(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "test")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "initial")
+
+	path := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(path, []byte("brand new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := commitOutput(path, "update collage"); err != nil {
+		t.Fatalf("commitOutput() error = %v", err)
+	}
+
+	subjects := lastCommitSubjects(t, dir, 1)
+	if subjects[0] != "update collage" {
+		t.Fatalf("last commit subject = %q, want %q (a brand new untracked file must still be committed)", subjects[0], "update collage")
+	}
+}
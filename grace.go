@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// runOnceWithGrace implements -grace: on a failed runOnce, it keeps
+// retrying the whole fetch/decode/render pipeline, backing off between
+// attempts the same way fetchImageConditional backs off individual HTTP
+// retries (see backoff/applyJitter in fetch.go), until either an attempt
+// succeeds or -grace's duration elapses since the first failure - whichever
+// comes first. Only then does it return the last attempt's error.
+//
+// This is a coarser retry than -connect-retries/-status-retries: those
+// cover individual HTTP requests inside one fetch, while -grace covers the
+// entire run, including decode and render failures those per-request
+// retries can't see. It's meant for scheduled monitoring, where a single
+// failed run during a brief upstream blip shouldn't immediately read as
+// downtime.
+//
+// ctx is the caller's signal-aware context (see Run): it's only consulted
+// between attempts, to let a Ctrl-C/SIGTERM interrupt a backoff sleep
+// immediately rather than waiting it out, since -grace itself - not ctx -
+// is what bounds how long retrying continues.
+func (p *Pipeline) runOnceWithGrace(ctx context.Context) (runResult, error) {
+	if p.Config.Grace <= 0 {
+		return p.runOnce()
+	}
+
+	deadline := time.Now().Add(p.Config.Grace)
+	for attempt := 0; ; attempt++ {
+		result, err := p.runOnce()
+		if err == nil {
+			return result, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return result, fmt.Errorf("%w: still failing after -grace %s: %w", ErrFetch, p.Config.Grace, err)
+		}
+
+		delay := applyJitter(p.rng, backoff(attempt+1), p.Config.Jitter)
+		if delay > remaining {
+			delay = remaining
+		}
+		fmt.Fprintf(os.Stderr, "eagleusb: run failed (%v), retrying within -grace (%s remaining)\n", err, remaining.Round(time.Second))
+		if waitErr := sleepOrDone(ctx, delay); waitErr != nil {
+			return result, err
+		}
+	}
+}
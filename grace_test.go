@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyPNGServer fails every request with failCount remaining failures
+// before serving a valid PNG, for exercising -grace's retry loop.
+func flakyPNGServer(t *testing.T, failCount int) *httptest.Server {
+	t.Helper()
+	var calls int32
+	png := []byte{
+		0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+		0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+		0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4, 0x89, 0x00, 0x00, 0x00,
+		0x0a, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0x00, 0x01, 0x00, 0x00,
+		0x05, 0x00, 0x01, 0x0d, 0x0a, 0x2d, 0xb4, 0x00, 0x00, 0x00, 0x00, 0x49,
+		0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(atomic.AddInt32(&calls, 1)) <= failCount {
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("not actually a jpeg"))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+}
+
+func TestPipelineRun_GraceRetriesUntilSuccess(t *testing.T) {
+	srv := flakyPNGServer(t, 2)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Grace:     5 * time.Second,
+		Seed:      1,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want -grace to retry past the first two failures", err)
+	}
+}
+
+func TestPipelineRun_GraceGivesUpAfterDeadlineElapses(t *testing.T) {
+	srv := flakyPNGServer(t, 1000)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Grace:     600 * time.Millisecond,
+		Seed:      1,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail once -grace's deadline elapses")
+	}
+}
+
+func TestPipelineRun_NoGraceFailsOnFirstError(t *testing.T) {
+	srv := flakyPNGServer(t, 1000)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail immediately with -grace unset")
+	}
+}
+
+func TestParseFlags_GraceRejectsNegative(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "https://example.com", "-grace", "-1s"}); err == nil {
+		t.Fatal("expected an error for a negative -grace")
+	}
+}
+
+func TestParseFlags_GraceDefaultsToZero(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if cfg.Grace != 0 {
+		t.Errorf("Grace = %v, want 0", cfg.Grace)
+	}
+}
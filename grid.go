@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// GridConfig declares a -grid-config matrix of collages to fetch: every
+// combination of Methods x Periods is built from BaseURL via
+// buildCollageURL and fetched concurrently, e.g. methods
+// ["albums", "artists"] and periods ["7day", "overall"] for a 2x2 grid.
+//
+// There's no Last.fm/songstitch-style URL-building API in this tree (see
+// sources.go and since.go for the same constraint) — buildCollageURL is a
+// generic query-parameter templating helper, not a real collage-service
+// client. A real deployment would point BaseURL at a service that honors
+// "method" and "period" query parameters the way Last.fm collage
+// generators conventionally do.
+type GridConfig struct {
+	BaseURL string   `json:"baseURL"`
+	Methods []string `json:"methods"`
+	Periods []string `json:"periods"`
+}
+
+// conventionalPeriods and conventionalMethods are the period/method values
+// Last.fm-style collage generators conventionally accept. buildCollageURL
+// doesn't validate against them - it's a generic query-parameter
+// templater, not a real Last.fm client, so an unrecognized value is simply
+// forwarded to BaseURL for it to reject - but -list-periods/-list-methods
+// print them as a discoverability aid for -grid-config authors, and this
+// is the one place both would draw from if validation is ever added.
+var conventionalPeriods = []string{"7day", "1month", "3month", "6month", "12month", "overall"}
+
+var conventionalMethods = []string{"albums", "artists", "tracks"}
+
+// conventionalTextLocations are the label-placement values songstitch-style
+// collage generators conventionally accept for a "textlocation" query
+// parameter, validated by validateTextLocation for -text-location.
+var conventionalTextLocations = []string{"top", "topleft", "topcentre", "topright", "bottom", "bottomleft", "bottomcentre", "bottomright"}
+
+// resolvedTextLocation returns the textLocation buildCollageURL should
+// receive for cfg: always empty (parameter omitted) when -no-text is set,
+// otherwise cfg.TextLocation (itself empty when -text-location wasn't
+// given).
+func resolvedTextLocation(cfg Config) string {
+	if cfg.NoText {
+		return ""
+	}
+	return cfg.TextLocation
+}
+
+// validateTextLocation checks loc against conventionalTextLocations. An
+// empty loc (no override) is always valid.
+func validateTextLocation(loc string) error {
+	if loc == "" {
+		return nil
+	}
+	for _, v := range conventionalTextLocations {
+		if loc == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("-text-location must be one of %q, got %q", conventionalTextLocations, loc)
+}
+
+// loadGridConfig reads a JSON GridConfig from path, e.g.:
+//
+//	{"baseURL": "https://example.com/collage",
+//	 "methods": ["albums", "artists"],
+//	 "periods": ["7day", "overall"]}
+func loadGridConfig(path string) (GridConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return GridConfig{}, err
+	}
+
+	var cfg GridConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return GridConfig{}, err
+	}
+	return cfg, nil
+}
+
+// buildCollageURL builds the URL for one method+period combination of a
+// -grid-config matrix or -stack method, by setting "method" and "period"
+// query parameters on baseURL (preserving any it already has). textLocation
+// additionally sets a "textlocation" parameter (see -text-location) when
+// non-empty, letting callers control or omit the collage service's baked-in
+// text overlay; an empty textLocation leaves the parameter unset entirely,
+// which is also what -no-text resolves to.
+func buildCollageURL(baseURL, method, period, textLocation string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid baseURL %q: %w", baseURL, err)
+	}
+
+	q := u.Query()
+	q.Set("method", method)
+	q.Set("period", period)
+	if textLocation != "" {
+		q.Set("textlocation", textLocation)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
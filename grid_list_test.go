@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestParseFlags_ListPeriodsDoesNotRequireURL(t *testing.T) {
+	cfg, err := parseFlags([]string{"-list-periods"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v, want nil", err)
+	}
+	if !cfg.ListPeriods {
+		t.Fatal("expected ListPeriods to be true")
+	}
+}
+
+func TestParseFlags_ListMethodsDoesNotRequireURL(t *testing.T) {
+	cfg, err := parseFlags([]string{"-list-methods"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v, want nil", err)
+	}
+	if !cfg.ListMethods {
+		t.Fatal("expected ListMethods to be true")
+	}
+}
+
+func TestConventionalPeriodsAndMethods_AreNonEmpty(t *testing.T) {
+	if len(conventionalPeriods) == 0 {
+		t.Fatal("expected at least one conventional period")
+	}
+	if len(conventionalMethods) == 0 {
+		t.Fatal("expected at least one conventional method")
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuildCollageURL(t *testing.T) {
+	got, err := buildCollageURL("https://example.com/collage", "albums", "7day", "")
+	if err != nil {
+		t.Fatalf("buildCollageURL() error = %v", err)
+	}
+
+	want := "https://example.com/collage?method=albums&period=7day"
+	if got != want {
+		t.Fatalf("buildCollageURL() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildCollageURL_InvalidBaseURL(t *testing.T) {
+	if _, err := buildCollageURL(":\\bad", "albums", "7day", ""); err == nil {
+		t.Fatal("expected an error for an invalid baseURL")
+	}
+}
+
+func TestLoadGridConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grid.json")
+	body := `{"baseURL": "https://example.com/collage", "methods": ["albums", "artists"], "periods": ["7day", "overall"]}`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadGridConfig(path)
+	if err != nil {
+		t.Fatalf("loadGridConfig() error = %v", err)
+	}
+	if cfg.BaseURL != "https://example.com/collage" || len(cfg.Methods) != 2 || len(cfg.Periods) != 2 {
+		t.Fatalf("loadGridConfig() = %+v", cfg)
+	}
+}
+
+func TestPipelineRun_GridConfigBuildsMatrix(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	gridPath := filepath.Join(t.TempDir(), "grid.json")
+	cfgJSON, err := json.Marshal(GridConfig{
+		BaseURL: srv.URL,
+		Methods: []string{"albums", "artists"},
+		Periods: []string{"7day", "overall"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(gridPath, cfgJSON, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		GridConfig: gridPath,
+		Out:        out,
+		Timeout:    5 * time.Second,
+		UserAgent:  defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v", err)
+	}
+
+	if len(data.Grid) != 2 || len(data.Grid[0]) != 2 || len(data.Grid[1]) != 2 {
+		t.Fatalf("Grid shape = %v, want 2x2", data.Grid)
+	}
+	for r, row := range data.Grid {
+		for c, cellURL := range row {
+			if cellURL == "" {
+				t.Fatalf("Grid[%d][%d] is empty", r, c)
+			}
+		}
+	}
+}
+
+func TestPipelineRun_GridConfigPartialToleratesFailure(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("method") == "artists" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	gridPath := filepath.Join(t.TempDir(), "grid.json")
+	cfgJSON, err := json.Marshal(GridConfig{
+		BaseURL: srv.URL,
+		Methods: []string{"albums", "artists"},
+		Periods: []string{"7day"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(gridPath, cfgJSON, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPipeline(Config{
+		GridConfig:    gridPath,
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+		StatusRetries: 0,
+		Partial:       true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v, want nil with -partial", err)
+	}
+	if data.Grid[0][0] == "" {
+		t.Fatal("Grid[0][0] (albums) should have succeeded")
+	}
+	if data.Grid[0][1] != "" {
+		t.Fatal("Grid[0][1] (artists) should be empty after failing")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// healthCheckTimeout caps -healthcheck runs well below the default -timeout,
+// since uptime monitors need a fast, bounded probe rather than the full
+// retry budget of a real fetch.
+const healthCheckTimeout = 5 * time.Second
+
+// runHealthCheck issues a HEAD request against cfg.URL and reports whether
+// it responded 200 with an image content type. Unlike -self-test, it probes
+// only the remote endpoint: no template, output directory or decode checks.
+func runHealthCheck(cfg Config) bool {
+	client := &http.Client{Timeout: healthCheckTimeout}
+
+	req, err := http.NewRequest(http.MethodHead, cfg.URL, nil)
+	if err != nil {
+		fmt.Println("UNHEALTHY:", err)
+		return false
+	}
+	req.Header.Set("User-Agent", cfg.UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Println("UNHEALTHY:", err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("UNHEALTHY: status %s\n", resp.Status)
+		return false
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		fmt.Printf("UNHEALTHY: content type %q is not an image\n", contentType)
+		return false
+	}
+
+	fmt.Printf("HEALTHY: %s (%s)\n", resp.Status, contentType)
+	return true
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRunHealthCheck_HealthyImage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if !runHealthCheck(Config{URL: srv.URL, UserAgent: defaultUserAgent}) {
+		t.Fatal("expected healthy result")
+	}
+}
+
+func TestRunHealthCheck_NonImageContentType(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if runHealthCheck(Config{URL: srv.URL, UserAgent: defaultUserAgent}) {
+		t.Fatal("expected unhealthy result for non-image content type")
+	}
+}
+
+func TestRunHealthCheck_NonOKStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if runHealthCheck(Config{URL: srv.URL, UserAgent: defaultUserAgent}) {
+		t.Fatal("expected unhealthy result for 503 status")
+	}
+}
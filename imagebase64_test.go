@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun_ImageBase64RendersWithoutNetwork(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(makePNG(2, 2))
+	out := filepath.Join(t.TempDir(), "README.md")
+
+	p, err := NewPipeline(Config{
+		ImageBase64: encoded,
+		Out:         out,
+		Timeout:     5 * time.Second,
+		UserAgent:   defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	rendered, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(rendered), "data:image/png;base64,") {
+		t.Fatalf("rendered output missing embedded PNG data URI: %q", rendered)
+	}
+}
+
+func TestPipelineRun_ImageBase64EnvRendersFromEnvironment(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(makePNG(2, 2))
+	t.Setenv("EAGLEUSB_TEST_IMAGE_BASE64", encoded)
+	out := filepath.Join(t.TempDir(), "README.md")
+
+	p, err := NewPipeline(Config{
+		ImageBase64Env: "EAGLEUSB_TEST_IMAGE_BASE64",
+		Out:            out,
+		Timeout:        5 * time.Second,
+		UserAgent:      defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestPipelineRun_ImageBase64RejectsInvalidEncoding(t *testing.T) {
+	p, err := NewPipeline(Config{
+		ImageBase64: "not-valid-base64!!!",
+		Out:         filepath.Join(t.TempDir(), "README.md"),
+		Timeout:     5 * time.Second,
+		UserAgent:   defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail on invalid base64")
+	}
+}
+
+func TestParseFlags_ImageBase64SatisfiesURLRequirement(t *testing.T) {
+	if _, err := parseFlags([]string{"-image-base64", "aGVsbG8="}); err != nil {
+		t.Fatalf("parseFlags() error = %v, want -image-base64 to satisfy the -url requirement", err)
+	}
+}
+
+func TestParseFlags_ImageBase64AndImageBase64EnvAreMutuallyExclusive(t *testing.T) {
+	_, err := parseFlags([]string{"-image-base64", "aGVsbG8=", "-image-base64-env", "SOME_VAR"})
+	if err == nil {
+		t.Fatal("expected an error when both -image-base64 and -image-base64-env are set")
+	}
+}
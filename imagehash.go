@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/bits"
+)
+
+// computeImageHash decodes body and returns its average hash (see
+// averageHash), along with ok=true if mime is a format image.Decode
+// understands. WebP and SVG aren't stdlib-decodable (see webp.go, svg.go),
+// so ok is false for them rather than erroring - -image-diff-threshold
+// simply has nothing to compare for those sources.
+func computeImageHash(body []byte, mime string) (hash uint64, ok bool, err error) {
+	if !canDecodeToPixels(mime) {
+		return 0, false, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	return averageHash(img), true, nil
+}
+
+// averageHash computes an 8x8 average hash (aHash): img is shrunk to 8x8
+// grayscale, and each of the 64 bits is set if that cell's luminance is at
+// or above the mean of all 64 cells. Similar images produce hashes a small
+// Hamming distance apart, even after lossy re-encoding, unlike a
+// cryptographic hash of the raw bytes.
+func averageHash(img image.Image) uint64 {
+	const size = 8
+	small := resizeNearestExact(img, size, size)
+
+	var gray [size * size]uint8
+	var sum int
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			g := color.GrayModel.Convert(small.At(x, y)).(color.Gray).Y
+			gray[y*size+x] = g
+			sum += int(g)
+		}
+	}
+	mean := sum / (size * size)
+
+	var hash uint64
+	for i, g := range gray {
+		if int(g) >= mean {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// hammingDistance returns the number of differing bits between two hashes.
+func hammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
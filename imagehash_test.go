@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// makeSplitPNG returns a w x h PNG, solid black on the left half and solid
+// white on the right, for a fixture whose average hash differs sharply
+// from a solid-color image of the same size: large contiguous regions
+// survive nearest-neighbor downsampling, unlike a fine checkerboard, which
+// aliases back to a solid color at 8x8.
+func makeSplitPNG(w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, color.Black)
+			} else {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func TestHammingDistance(t *testing.T) {
+	if d := hammingDistance(0, 0); d != 0 {
+		t.Fatalf("hammingDistance(0, 0) = %d, want 0", d)
+	}
+	if d := hammingDistance(0, 0xff); d != 8 {
+		t.Fatalf("hammingDistance(0, 0xff) = %d, want 8", d)
+	}
+}
+
+func TestAverageHash_IdenticalImagesMatch(t *testing.T) {
+	img, _, err := image.Decode(bytes.NewReader(makePNG(32, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hammingDistance(averageHash(img), averageHash(img)) != 0 {
+		t.Fatal("expected an image's average hash to match itself exactly")
+	}
+}
+
+func TestAverageHash_DifferentImagesDiffer(t *testing.T) {
+	solid, _, err := image.Decode(bytes.NewReader(makePNG(32, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	split, _, err := image.Decode(bytes.NewReader(makeSplitPNG(32, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := hammingDistance(averageHash(solid), averageHash(split)); d == 0 {
+		t.Fatal("expected a solid-color image and a split black/white image to hash differently")
+	}
+}
+
+func TestComputeImageHash_SkipsUndecodableFormats(t *testing.T) {
+	for _, mime := range []string{"image/webp", "image/svg+xml"} {
+		_, ok, err := computeImageHash([]byte("whatever"), mime)
+		if err != nil {
+			t.Fatalf("computeImageHash(%q) error = %v, want nil", mime, err)
+		}
+		if ok {
+			t.Fatalf("computeImageHash(%q) ok = true, want false", mime)
+		}
+	}
+}
+
+func TestPipelineRun_ImageDiffThresholdWarnsOnDrasticChange(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:                srv.URL,
+		Out:                out,
+		Timeout:            5 * time.Second,
+		UserAgent:          defaultUserAgent,
+		ImageDiffThreshold: 2,
+	}
+
+	body = makePNG(32, 32)
+	p1, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p1.Run(); err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+
+	body = makeSplitPNG(32, 32)
+	p2, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p2.Run(); err != nil {
+		t.Fatalf("second Run() error = %v, want nil since -fail-on-warning wasn't set", err)
+	}
+
+	cfg.FailOnWarning = true
+	body = makePNG(32, 32)
+	p3, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p3.Run(); err == nil {
+		t.Fatal("expected third Run() to fail: -fail-on-warning set and image changed drastically back from the split image")
+	}
+}
+
+func TestPipelineRun_ImageDiffThresholdPassesOnSameImage(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:                srv.URL,
+		Out:                out,
+		Timeout:            5 * time.Second,
+		UserAgent:          defaultUserAgent,
+		ImageDiffThreshold: 2,
+		FailOnWarning:      true,
+	}
+
+	for i := 0; i < 2; i++ {
+		p, err := NewPipeline(cfg)
+		if err != nil {
+			t.Fatalf("NewPipeline() error = %v", err)
+		}
+		if err := p.Run(); err != nil {
+			t.Fatalf("Run() #%d error = %v, want nil since the image never changed", i, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+)
+
+// minWorthwhileReduction is the smallest size reduction gzip must achieve
+// before we bother keeping the compressed form over the raw bytes.
+const minWorthwhileReduction = 0.10
+
+// alreadyCompressedMimes lists image formats whose own compression makes a
+// second gzip pass pointless.
+var alreadyCompressedMimes = map[string]bool{
+	"image/jpeg": true,
+	"image/webp": true,
+	"image/gif":  true,
+}
+
+// writeImageOut writes data to path, a ".gz" suffix is appended and the
+// data gzip-compressed first, unless mime is already a compressed format.
+func writeImageOut(path string, data []byte, mime string) error {
+	if alreadyCompressedMimes[mime] {
+		fmt.Fprintf(os.Stderr, "eagleusb: %s is already compressed, writing %s uncompressed\n", mime, path)
+		return os.WriteFile(path, data, 0o644)
+	}
+
+	compressed, err := gzipBytes(data)
+	if err != nil {
+		return fmt.Errorf("gzip image: %w", err)
+	}
+
+	reduction := 1 - float64(len(compressed))/float64(len(data))
+	if reduction < minWorthwhileReduction {
+		fmt.Fprintf(os.Stderr, "eagleusb: gzip only reduced %s by %.0f%%, consider a pre-compressed output format\n", path, reduction*100)
+	}
+
+	return os.WriteFile(path+".gz", compressed, 0o644)
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
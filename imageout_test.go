@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteImageOut_CompressesRaw(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 1024)
+	out := filepath.Join(t.TempDir(), "collage.png")
+
+	if err := writeImageOut(out, data, "image/png"); err != nil {
+		t.Fatalf("writeImageOut() error = %v", err)
+	}
+
+	gzData, err := os.ReadFile(out + ".gz")
+	if err != nil {
+		t.Fatalf("reading gzipped output: %v", err)
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(gzData))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	decompressed, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("decompressing: %v", err)
+	}
+	if !bytes.Equal(decompressed, data) {
+		t.Fatal("decompressed data does not match original")
+	}
+}
+
+func TestWriteImageOut_SkipsAlreadyCompressed(t *testing.T) {
+	data := []byte("not really a jpeg but that's fine")
+	out := filepath.Join(t.TempDir(), "collage.jpg")
+
+	if err := writeImageOut(out, data, "image/jpeg"); err != nil {
+		t.Fatalf("writeImageOut() error = %v", err)
+	}
+
+	if _, err := os.Stat(out + ".gz"); !os.IsNotExist(err) {
+		t.Fatal("expected no .gz file for an already-compressed format")
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("output does not match original data")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+// cachedImage is a -incremental manifest entry: one -stack method's ETag
+// and already-encoded fetchResult fields from a previous process
+// invocation, persisted to the manifest sidecar (see manifest.go) so the
+// next run can issue a conditional request and, on a 304, reuse the cached
+// data URI without re-fetching or re-encoding. It deliberately omits the
+// raw image body: nothing downstream of a cache hit needs it, and leaving
+// it out keeps the manifest small.
+type cachedImage struct {
+	ETag         string `json:"etag"`
+	Mime         string `json:"mime,omitempty"`
+	DataURI      string `json:"data_uri"`
+	ThumbnailURI string `json:"thumbnail_uri,omitempty"`
+	WebPURI      string `json:"webp_uri,omitempty"`
+	PNGFallback  string `json:"png_fallback,omitempty"`
+	Srcset       string `json:"srcset,omitempty"`
+	AlbumCount   int    `json:"album_count,omitempty"`
+	Animated     bool   `json:"animated,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+}
+
+// toResult rebuilds the fetchResult fields a cache hit needs from a
+// persisted cachedImage.
+func (c cachedImage) toResult() fetchResult {
+	return fetchResult{
+		mime:         c.Mime,
+		dataURI:      c.DataURI,
+		thumbnailURI: c.ThumbnailURI,
+		webpURI:      c.WebPURI,
+		pngFallback:  c.PNGFallback,
+		srcset:       c.Srcset,
+		albumCount:   c.AlbumCount,
+		animated:     c.Animated,
+		width:        c.Width,
+		height:       c.Height,
+	}
+}
+
+// cachedImageFrom captures the fields of a freshly fetched result worth
+// persisting for the next run's conditional request.
+func cachedImageFrom(etag string, r fetchResult) cachedImage {
+	return cachedImage{
+		ETag:         etag,
+		Mime:         r.mime,
+		DataURI:      r.dataURI,
+		ThumbnailURI: r.thumbnailURI,
+		WebPURI:      r.webpURI,
+		PNGFallback:  r.pngFallback,
+		Srcset:       r.srcset,
+		AlbumCount:   r.albumCount,
+		Animated:     r.animated,
+		Width:        r.width,
+		Height:       r.height,
+	}
+}
+
+// incrementalCaches seeds one *imageCache per method from prev's persisted
+// entries, for -incremental's per-method conditional fetches.
+func incrementalCaches(methods []string, prev manifest) []*imageCache {
+	caches := make([]*imageCache, len(methods))
+	for i, method := range methods {
+		caches[i] = &imageCache{}
+		if cached, ok := prev.Images[method]; ok {
+			caches[i].seed(cached.ETag, cached.toResult())
+		}
+	}
+	return caches
+}
+
+// recordIncrementalManifest persists each method's latest ETag/result (or,
+// for a method that errored this run, its previous entry, so one failed
+// fetch doesn't discard an otherwise-good cache) back to -out's manifest,
+// and reports whether any method actually changed (a cache miss, meaning
+// the conditional request didn't come back 304) - the signal dispatchRunOnce
+// uses to skip writing -out when every image is unchanged.
+func recordIncrementalManifest(out string, methods []string, results []fetchResult, caches []*imageCache, prev manifest) (changed bool, err error) {
+	updated := make(map[string]cachedImage, len(methods))
+	for i, method := range methods {
+		if results[i].err != nil {
+			if cached, ok := prev.Images[method]; ok {
+				updated[method] = cached
+			}
+			continue
+		}
+		if caches[i].Misses > 0 {
+			changed = true
+		}
+		if etag, result, ok := caches[i].get(); ok {
+			updated[method] = cachedImageFrom(etag, result)
+		}
+	}
+
+	m, loadErr := loadManifest(manifestPath(out))
+	if loadErr != nil {
+		return changed, loadErr
+	}
+	m.Images = updated
+	return changed, saveManifest(manifestPath(out), m)
+}
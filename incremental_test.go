@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// etagStackServer serves png under any "method" query param, returning
+// etag and honoring If-None-Match with a 304, while counting how many
+// times each method was actually fetched (not 304'd) in hits.
+func etagStackServer(t *testing.T, png []byte, etag string, misses map[string]int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.URL.Query().Get("method")
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		misses[method]++
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+}
+
+func TestPipelineRun_IncrementalSkipsWriteWhenNothingChanged(t *testing.T) {
+	png := makePNG(1, 1)
+	misses := make(map[string]int)
+	srv := etagStackServer(t, png, `"v1"`, misses)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	newPipeline := func() *Pipeline {
+		p, err := NewPipeline(Config{
+			URL:         srv.URL,
+			Stack:       "albums,artists",
+			Out:         out,
+			Timeout:     5 * time.Second,
+			UserAgent:   defaultUserAgent,
+			Incremental: true,
+		})
+		if err != nil {
+			t.Fatalf("NewPipeline() error = %v", err)
+		}
+		return p
+	}
+
+	if err := newPipeline().Run(); err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+	firstInfo, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := newPipeline().Run(); err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	secondInfo, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if misses["albums"] != 1 || misses["artists"] != 1 {
+		t.Errorf("misses = %v, want each method fetched once (the second run should 304)", misses)
+	}
+	if !secondInfo.ModTime().Equal(firstInfo.ModTime()) {
+		t.Errorf("README was rewritten on the unchanged second run")
+	}
+}
+
+func TestPipelineRun_IncrementalRewritesWhenAMethodChanges(t *testing.T) {
+	png := makePNG(1, 1)
+	var albumsETag string
+	var n int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.URL.Query().Get("method")
+		if method == "artists" {
+			w.Header().Set("ETag", `"artists-v1"`)
+			if r.Header.Get("If-None-Match") == `"artists-v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(png)
+			return
+		}
+		n++
+		albumsETag = "albums-v" + string(rune('0'+n))
+		w.Header().Set("ETag", albumsETag)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	newPipeline := func() *Pipeline {
+		p, err := NewPipeline(Config{
+			URL:         srv.URL,
+			Stack:       "albums,artists",
+			Out:         out,
+			Timeout:     5 * time.Second,
+			UserAgent:   defaultUserAgent,
+			Incremental: true,
+		})
+		if err != nil {
+			t.Fatalf("NewPipeline() error = %v", err)
+		}
+		return p
+	}
+
+	if err := newPipeline().Run(); err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+	firstInfo, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := newPipeline().Run(); err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	secondInfo, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !secondInfo.ModTime().After(firstInfo.ModTime()) {
+		t.Errorf("README wasn't rewritten even though albums' ETag changed")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	jitterFull  = "full"
+	jitterEqual = "equal"
+	jitterNone  = "none"
+)
+
+// validateJitterStrategy rejects anything other than "full", "equal" or
+// "none" for -jitter.
+func validateJitterStrategy(strategy string) error {
+	switch strategy {
+	case jitterFull, jitterEqual, jitterNone:
+		return nil
+	default:
+		return fmt.Errorf("-jitter must be %q, %q or %q, got %q", jitterFull, jitterEqual, jitterNone, strategy)
+	}
+}
+
+// applyJitter spreads a backoff delay using one of the AWS-style jitter
+// formulas, so that many instances retrying the same flaky source on the
+// same schedule don't all reconnect in lockstep:
+//
+//   - "full": uniformly random in [0, cap] (https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/)
+//   - "equal": half cap, plus uniformly random in [0, cap/2]
+//   - "none": cap unchanged, for deterministic tests and diffable logs
+//
+// An empty or unrecognized strategy is treated as "full", matching -jitter's
+// default. rng is the run's -seed-derived source (see newSeededRand), so
+// jittered delays are reproducible under a fixed seed.
+func applyJitter(rng *seededRand, delayCap time.Duration, strategy string) time.Duration {
+	switch strategy {
+	case jitterNone:
+		return delayCap
+	case jitterEqual:
+		half := delayCap / 2
+		return half + time.Duration(rng.Int63n(int64(half)+1))
+	default:
+		return time.Duration(rng.Int63n(int64(delayCap) + 1))
+	}
+}
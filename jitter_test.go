@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyJitter_NoneReturnsCapUnchanged(t *testing.T) {
+	cap := 2 * time.Second
+	if got := applyJitter(newSeededRand(1), cap, jitterNone); got != cap {
+		t.Errorf("applyJitter(cap, \"none\") = %v, want %v", got, cap)
+	}
+}
+
+func TestApplyJitter_FullStaysWithinZeroToCap(t *testing.T) {
+	cap := 2 * time.Second
+	rng := newSeededRand(1)
+	for i := 0; i < 200; i++ {
+		got := applyJitter(rng, cap, jitterFull)
+		if got < 0 || got > cap {
+			t.Fatalf("applyJitter(cap, \"full\") = %v, want within [0, %v]", got, cap)
+		}
+	}
+}
+
+func TestApplyJitter_EqualStaysWithinHalfCapToCap(t *testing.T) {
+	cap := 2 * time.Second
+	half := cap / 2
+	rng := newSeededRand(1)
+	for i := 0; i < 200; i++ {
+		got := applyJitter(rng, cap, jitterEqual)
+		if got < half || got > cap {
+			t.Fatalf("applyJitter(cap, \"equal\") = %v, want within [%v, %v]", got, half, cap)
+		}
+	}
+}
+
+func TestApplyJitter_UnrecognizedStrategyDefaultsToFull(t *testing.T) {
+	cap := 2 * time.Second
+	rng := newSeededRand(1)
+	for i := 0; i < 50; i++ {
+		got := applyJitter(rng, cap, "")
+		if got < 0 || got > cap {
+			t.Fatalf("applyJitter(cap, \"\") = %v, want within [0, %v]", got, cap)
+		}
+	}
+}
+
+func TestValidateJitterStrategy(t *testing.T) {
+	for _, strategy := range []string{jitterFull, jitterEqual, jitterNone} {
+		if err := validateJitterStrategy(strategy); err != nil {
+			t.Errorf("validateJitterStrategy(%q) error = %v, want nil", strategy, err)
+		}
+	}
+	if err := validateJitterStrategy("exponential"); err == nil {
+		t.Error("expected an error for an unrecognized -jitter strategy")
+	}
+}
+
+func TestParseFlags_JitterDefaultsToFull(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if cfg.Jitter != jitterFull {
+		t.Errorf("Jitter = %q, want %q", cfg.Jitter, jitterFull)
+	}
+}
+
+func TestParseFlags_JitterRejectsUnknownStrategy(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-jitter", "exponential"})
+	if err == nil {
+		t.Fatal("expected an error for an unrecognized -jitter strategy")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"image/jpeg"
+	"io"
+	"os"
+)
+
+// decodeJPEGConfigOnly is sniffAndValidate's fallback for a JPEG that fails a
+// full image.Decode. jpeg.DecodeConfig only parses headers through the Start
+// Of Frame marker to recover width/height, without ever touching the
+// entropy-coded scan data after Start Of Scan - and a malformed restart
+// marker or a progressive scan libjpeg's encoders produce but Go's stdlib
+// decoder trips on almost always lives in that scan data, not the headers.
+// So this succeeds on some JPEGs a full decode rejects, at the cost of
+// returning only dimensions, not pixels - the same "validate the container,
+// not the pixels" tradeoff this tree already makes for WebP and SVG (see
+// ImageInfo).
+//
+// r must still be positioned at the start of the JPEG; callers that already
+// consumed r for the failed image.Decode attempt need to seek it back first.
+func decodeJPEGConfigOnly(r io.Reader) (width, height int, ok bool) {
+	cfg, err := jpeg.DecodeConfig(r)
+	if err != nil {
+		return 0, 0, false
+	}
+	return cfg.Width, cfg.Height, true
+}
+
+// logJPEGLenientDecode reports, to stderr, that a JPEG only validated via
+// decodeJPEGConfigOnly's header-only fallback rather than a full pixel
+// decode - the same "print a non-fatal notice and hand the signal back to
+// the caller" shape as webp.go's warnIfAnimated, so a caller can fold it
+// into p.warned for -fail-on-warning to catch a run that's silently
+// degrading to header-only validation.
+//
+// This tree has no reliable way to ask a source to re-send a baseline
+// (non-progressive) encode of the same image instead - unlike
+// -format-fallback's "format" query parameter, there's no corresponding
+// parameter for baseline-vs-progressive, and Go's stdlib JPEG encoder can't
+// write progressive JPEGs either (see -og-jpeg-progressive in config.go), so
+// there's nothing on either end of that round trip to fall back to. This
+// lenient decode is the one fallback this tree can actually make good on.
+func logJPEGLenientDecode(lenient bool) bool {
+	if lenient {
+		fmt.Fprintln(os.Stderr, "eagleusb: JPEG failed a full decode (likely a progressive scan or restart marker the stdlib decoder can't fully handle); falling back to header-only dimensions")
+	}
+	return lenient
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// progressiveJPEGFixture builds a minimal progressive (SOF2) JPEG whose
+// frame header alone is enough for jpeg.DecodeConfig to recover width and
+// height, but whose scan is missing the Huffman tables (DHT) a real
+// decoder needs to reconstruct pixels from it, the same way restart-marker
+// or scan corruption trips up real-world progressive JPEGs from sources
+// that actually encode with libjpeg. Go's stdlib image/jpeg encoder can
+// only write baseline JPEGs (see -og-jpeg-progressive in config.go), so
+// this has to be hand-assembled marker by marker, the same way
+// colorprofile_test.go's minimalJPEGWithICC is.
+func progressiveJPEGFixture() []byte {
+	var b []byte
+	b = append(b, 0xff, 0xd8) // SOI
+
+	// SOF2 (progressive DCT), 1 grayscale component, 16x16.
+	b = append(b, 0xff, 0xc2)
+	b = append(b, 0x00, 0x0b) // Lf = 11
+	b = append(b, 0x08)       // precision
+	b = append(b, 0x00, 0x10) // height = 16
+	b = append(b, 0x00, 0x10) // width = 16
+	b = append(b, 0x01)       // Nf = 1 component
+	b = append(b, 0x01, 0x11, 0x00)
+
+	// SOS for a first (DC) progressive scan of that one component, with no
+	// DHT/DQT ever supplied - a real decode fails reconstructing this.
+	b = append(b, 0xff, 0xda)
+	b = append(b, 0x00, 0x08) // Ls = 8
+	b = append(b, 0x01)       // Ns = 1
+	b = append(b, 0x01, 0x00) // component 1, DC/AC table selector 0
+	b = append(b, 0x00, 0x03, 0x00)
+
+	b = append(b, 0xab, 0xcd, 0xef, 0x12, 0x34) // fake entropy-coded data
+	b = append(b, 0xff, 0xd9)                   // EOI
+	return b
+}
+
+func TestProgressiveJPEGFixture_FullDecodeFailsButConfigSucceeds(t *testing.T) {
+	fixture := progressiveJPEGFixture()
+
+	if _, err := jpeg.Decode(bytes.NewReader(fixture)); err == nil {
+		t.Fatal("expected a full jpeg.Decode of the fixture to fail (missing Huffman tables)")
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(fixture))
+	if err != nil {
+		t.Fatalf("jpeg.DecodeConfig() error = %v, want the header-only parse to succeed", err)
+	}
+	if cfg.Width != 16 || cfg.Height != 16 {
+		t.Errorf("DecodeConfig dimensions = %dx%d, want 16x16", cfg.Width, cfg.Height)
+	}
+}
+
+func TestDecodeJPEGConfigOnly_RecoversDimensionsFromProgressiveFixture(t *testing.T) {
+	width, height, ok := decodeJPEGConfigOnly(bytes.NewReader(progressiveJPEGFixture()))
+	if !ok {
+		t.Fatal("decodeJPEGConfigOnly() ok = false, want true")
+	}
+	if width != 16 || height != 16 {
+		t.Errorf("dimensions = %dx%d, want 16x16", width, height)
+	}
+}
+
+func TestDecodeJPEGConfigOnly_FailsOnGarbage(t *testing.T) {
+	if _, _, ok := decodeJPEGConfigOnly(bytes.NewReader([]byte("not a jpeg at all"))); ok {
+		t.Fatal("decodeJPEGConfigOnly() ok = true for non-JPEG data, want false")
+	}
+}
+
+func TestSniffAndValidate_FallsBackToLenientDecodeForProgressiveJPEG(t *testing.T) {
+	width, height, jpegFallback, err := sniffAndValidate(progressiveJPEGFixture(), "image/jpeg", false)
+	if err != nil {
+		t.Fatalf("sniffAndValidate() error = %v, want the lenient fallback to recover", err)
+	}
+	if !jpegFallback {
+		t.Error("jpegFallback = false, want true")
+	}
+	if width != 16 || height != 16 {
+		t.Errorf("dimensions = %dx%d, want 16x16", width, height)
+	}
+}
+
+func TestSniffAndValidate_FastValidateNeverAttemptsJPEGFallback(t *testing.T) {
+	_, _, jpegFallback, err := sniffAndValidate(progressiveJPEGFixture(), "image/jpeg", true)
+	if err != nil {
+		t.Fatalf("sniffAndValidate(fastValidate=true) error = %v, want the signature-only check to pass", err)
+	}
+	if jpegFallback {
+		t.Error("jpegFallback = true, want false: fastValidate never attempts a full decode to fall back from")
+	}
+}
+
+func TestEncodeImageToBase64_ReportsJPEGFallback(t *testing.T) {
+	dataURI, animated, jpegFallback, err := encodeImageToBase64(progressiveJPEGFixture(), "image/jpeg", "", false)
+	if err != nil {
+		t.Fatalf("encodeImageToBase64() error = %v", err)
+	}
+	if animated {
+		t.Error("animated = true, want false for a JPEG")
+	}
+	if !jpegFallback {
+		t.Error("jpegFallback = false, want true")
+	}
+	if dataURI == "" {
+		t.Error("dataURI is empty")
+	}
+}
+
+func TestValidateImage_ReportsJPEGFallback(t *testing.T) {
+	info, err := ValidateImage(progressiveJPEGFixture(), "")
+	if err != nil {
+		t.Fatalf("ValidateImage() error = %v", err)
+	}
+	if !info.JPEGFallback {
+		t.Error("JPEGFallback = false, want true")
+	}
+	if info.Width != 16 || info.Height != 16 {
+		t.Errorf("dimensions = %dx%d, want 16x16", info.Width, info.Height)
+	}
+}
+
+func TestSniffAndValidate_CorruptJPEGWithNoRecoverableHeaderStillFails(t *testing.T) {
+	if _, _, _, err := sniffAndValidate([]byte{0xff, 0xd8, 0xff, 0xd9}, "image/jpeg", false); err == nil {
+		t.Fatal("expected an error for a JPEG with no frame header at all")
+	}
+}
+
+func progressiveJPEGServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	body := progressiveJPEGFixture()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(body)
+	}))
+}
+
+func TestPipelineRun_SucceedsOnProgressiveJPEGViaLenientFallback(t *testing.T) {
+	srv := progressiveJPEGServer(t)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want the lenient JPEG fallback to recover", err)
+	}
+}
+
+func TestPipelineRun_FailOnWarningFailsOnLenientJPEGFallback(t *testing.T) {
+	srv := progressiveJPEGServer(t)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:           srv.URL,
+		Out:           filepath.Join(t.TempDir(), "README.md"),
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+		FailOnWarning: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail when -fail-on-warning is set and the JPEG only validated via the lenient fallback")
+	}
+}
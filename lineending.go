@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	lineEndingLF   = "lf"
+	lineEndingCRLF = "crlf"
+)
+
+// applyLineEnding rewrites data's line endings to lineEnding ("lf" or
+// "crlf"), for -line-ending. Existing CRLFs are normalized to LF first, so
+// the result is consistent regardless of what the template or source
+// produced. Safe to apply to the whole rendered output, including the data
+// URI: a base64 payload never contains \r or \n, so there's nothing there
+// for this to rewrite.
+func applyLineEnding(data []byte, lineEnding string) []byte {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if lineEnding == lineEndingCRLF {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	return []byte(text)
+}
+
+// validateLineEnding rejects anything other than "lf" or "crlf" for
+// -line-ending.
+func validateLineEnding(lineEnding string) error {
+	switch lineEnding {
+	case lineEndingLF, lineEndingCRLF:
+		return nil
+	default:
+		return fmt.Errorf("-line-ending must be %q or %q, got %q", lineEndingLF, lineEndingCRLF, lineEnding)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestApplyLineEnding(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         string
+		lineEnding string
+		want       string
+	}{
+		{"lf leaves LF alone", "a\nb\n", lineEndingLF, "a\nb\n"},
+		{"lf normalizes existing CRLF", "a\r\nb\r\n", lineEndingLF, "a\nb\n"},
+		{"crlf converts LF", "a\nb\n", lineEndingCRLF, "a\r\nb\r\n"},
+		{"crlf is idempotent on existing CRLF", "a\r\nb\r\n", lineEndingCRLF, "a\r\nb\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(applyLineEnding([]byte(tt.in), tt.lineEnding)); got != tt.want {
+				t.Fatalf("applyLineEnding(%q, %q) = %q, want %q", tt.in, tt.lineEnding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyLineEnding_DoesNotTouchDataURIPayload(t *testing.T) {
+	in := "![collage](data:image/png;base64,iVBORw0KGgoAAAA==)\n"
+	got := string(applyLineEnding([]byte(in), lineEndingCRLF))
+	want := "![collage](data:image/png;base64,iVBORw0KGgoAAAA==)\r\n"
+	if got != want {
+		t.Fatalf("applyLineEnding() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateLineEnding(t *testing.T) {
+	if err := validateLineEnding(lineEndingLF); err != nil {
+		t.Fatalf("validateLineEnding(lf) error = %v", err)
+	}
+	if err := validateLineEnding(lineEndingCRLF); err != nil {
+		t.Fatalf("validateLineEnding(crlf) error = %v", err)
+	}
+	if err := validateLineEnding("bogus"); err == nil {
+		t.Fatal("expected an error for an invalid -line-ending value")
+	}
+}
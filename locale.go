@@ -0,0 +1,35 @@
+package main
+
+// captionCatalog holds localized caption strings keyed by BCP-47-ish
+// language code, one map per locale. Only a handful of locales are seeded
+// since this ships with no translation pipeline; a -lang without an entry
+// falls back to English.
+var captionCatalog = map[string]map[string]string{
+	"en": {"alt": "collage"},
+	"es": {"alt": "mosaico"},
+	"fr": {"alt": "mosaïque"},
+	"de": {"alt": "collage"},
+	"ja": {"alt": "コラージュ"},
+}
+
+const defaultLang = "en"
+
+// caption returns the localized string for key in lang, falling back to
+// English if lang is unknown or doesn't have that key.
+func caption(lang, key string) string {
+	if msgs, ok := captionCatalog[lang]; ok {
+		if msg, ok := msgs[key]; ok {
+			return msg
+		}
+	}
+	return captionCatalog[defaultLang][key]
+}
+
+// acceptLanguageFor returns the Accept-Language header value for lang, or
+// "" (meaning don't send the header) for the default.
+func acceptLanguageFor(lang string) string {
+	if lang == "" || lang == defaultLang {
+		return ""
+	}
+	return lang
+}
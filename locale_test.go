@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaption_FallsBackToEnglishForUnknownLang(t *testing.T) {
+	if got := caption("xx", "alt"); got != caption("en", "alt") {
+		t.Fatalf("caption(xx) = %q, want the English fallback %q", got, caption("en", "alt"))
+	}
+}
+
+func TestAcceptLanguageFor_EmptyForDefault(t *testing.T) {
+	if got := acceptLanguageFor(""); got != "" {
+		t.Fatalf("acceptLanguageFor(%q) = %q, want empty", "", got)
+	}
+	if got := acceptLanguageFor(defaultLang); got != "" {
+		t.Fatalf("acceptLanguageFor(%q) = %q, want empty", defaultLang, got)
+	}
+	if got := acceptLanguageFor("fr"); got != "fr" {
+		t.Fatalf("acceptLanguageFor(fr) = %q, want fr", got)
+	}
+}
+
+func TestPipelineRun_LangSetsAltTextAndForwardsHeader(t *testing.T) {
+	var gotAcceptLanguage string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAcceptLanguage = r.Header.Get("Accept-Language")
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(makePNG(1, 1))
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Lang:      "fr",
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if gotAcceptLanguage != "fr" {
+		t.Fatalf("Accept-Language = %q, want fr", gotAcceptLanguage)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), caption("fr", "alt")) {
+		t.Fatalf("output = %q, want the French alt text", contents)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// lockPollInterval is how often acquireRunLock retries a held lock while
+// waiting out -lock-timeout.
+const lockPollInterval = 200 * time.Millisecond
+
+// acquireRunLock takes an exclusive, non-blocking flock on a lockfile next
+// to outputPath (outputPath + ".lock"), retrying until timeout elapses, so
+// two overlapping runs - e.g. a -cron invocation that overran into the
+// next one - can't write the same output concurrently and corrupt it. The
+// returned release func must be called to drop the lock. Acquiring against
+// an empty or "-" outputPath is a no-op, since there's no shared file to
+// guard.
+func acquireRunLock(outputPath string, timeout time.Duration) (release func(), err error) {
+	if outputPath == "" || outputPath == stdioPlaceholder {
+		return func() {}, nil
+	}
+
+	lockPath := outputPath + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file %s: %w", lockPath, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err == nil {
+			return func() {
+				syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("another run is in progress (lock held on %s)", lockPath)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
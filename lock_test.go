@@ -0,0 +1,131 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireRunLock_BlocksConcurrentHolder(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "README.md")
+
+	release, err := acquireRunLock(out, 0)
+	if err != nil {
+		t.Fatalf("acquireRunLock() error = %v", err)
+	}
+	defer release()
+
+	if _, err := acquireRunLock(out, 0); err == nil {
+		t.Fatal("expected acquireRunLock to fail while the lock is already held")
+	}
+}
+
+func TestAcquireRunLock_ReleaseAllowsReacquire(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "README.md")
+
+	release, err := acquireRunLock(out, 0)
+	if err != nil {
+		t.Fatalf("acquireRunLock() error = %v", err)
+	}
+	release()
+
+	release2, err := acquireRunLock(out, 0)
+	if err != nil {
+		t.Fatalf("acquireRunLock() error after release = %v", err)
+	}
+	release2()
+}
+
+func TestAcquireRunLock_WaitsUpToTimeout(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "README.md")
+
+	release, err := acquireRunLock(out, 0)
+	if err != nil {
+		t.Fatalf("acquireRunLock() error = %v", err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		release()
+	}()
+
+	start := time.Now()
+	release2, err := acquireRunLock(out, time.Second)
+	if err != nil {
+		t.Fatalf("acquireRunLock() error = %v, want it to succeed once the first holder releases", err)
+	}
+	defer release2()
+	if time.Since(start) < 100*time.Millisecond {
+		t.Error("expected acquireRunLock to wait for the first holder to release")
+	}
+}
+
+func TestAcquireRunLock_NoopForEmptyOrStdout(t *testing.T) {
+	for _, out := range []string{"", stdioPlaceholder} {
+		release, err := acquireRunLock(out, 0)
+		if err != nil {
+			t.Fatalf("acquireRunLock(%q) error = %v", out, err)
+		}
+		release()
+	}
+}
+
+func TestPipelineRun_NoLockSkipsLocking(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+
+	// Hold the lock externally to prove -no-lock bypasses it entirely.
+	release, err := acquireRunLock(out, 0)
+	if err != nil {
+		t.Fatalf("acquireRunLock() error = %v", err)
+	}
+	defer release()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		NoLock:    true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want -no-lock to bypass the held lock", err)
+	}
+}
+
+func TestPipelineRun_FailsWhenLockAlreadyHeld(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+
+	release, err := acquireRunLock(out, 0)
+	if err != nil {
+		t.Fatalf("acquireRunLock() error = %v", err)
+	}
+	defer release()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail while another run holds the lock")
+	}
+}
+
+func TestParseFlags_LockTimeoutRejectsNegative(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-lock-timeout", "-1s"})
+	if err == nil {
+		t.Fatal("expected an error for a negative -lock-timeout")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// newLogger builds the structured logger used for retry diagnostics. Debug
+// events (one per retry decision) are only emitted when -debug is set;
+// everything else stays quiet so normal runs aren't noisy. When cfg.LogFile
+// is set, logs are also appended there (rotated past cfg.LogFileMaxBytes),
+// in whichever of cfg.LogFormat's "json" or "text" styles; a file that can't
+// be opened is reported to stderr and skipped rather than aborting the run,
+// matching how an unusable -user-agent-file is handled.
+func newLogger(cfg Config) *slog.Logger {
+	level := slog.LevelInfo
+	if cfg.Debug {
+		level = slog.LevelDebug
+	}
+
+	var w io.Writer = os.Stderr
+	if cfg.LogFile != "" {
+		fw, err := newRotatingFileWriter(cfg.LogFile, cfg.LogFileMaxBytes)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "eagleusb:", err, "- -log-file disabled for this run")
+		} else {
+			w = io.MultiWriter(os.Stderr, fw)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if cfg.LogFormat == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(handler)
+}
+
+// defaultRedact is -redact's default: query parameter name substrings
+// redacted even if the user never sets -redact, preserving this package's
+// long-standing default secret hygiene.
+const defaultRedact = "key,token,secret,password,auth"
+
+// parseRedactNames splits -redact's comma-separated list into the
+// lowercased substrings redactURL matches query parameter names against,
+// falling back to defaultRedact's list when s is empty - whether because
+// -redact was never set (the flag's own default already gives
+// defaultRedact) or because a Config was built directly by a test or
+// library caller, bypassing parseFlags (see budget's identical fallback for
+// -timeout-budget).
+func parseRedactNames(s string) []string {
+	if s == "" {
+		s = defaultRedact
+	}
+	names := strings.Split(s, ",")
+	for i, n := range names {
+		names[i] = strings.ToLower(strings.TrimSpace(n))
+	}
+	return names
+}
+
+// redactURL returns url with any userinfo and query parameters whose name
+// contains one of sensitive's substrings (case-insensitively) replaced with
+// "REDACTED", so fetch URLs can be logged, -explain'd or embedded in
+// -embed-buildinfo's provenance comment without leaking credentials. See
+// -redact and parseRedactNames.
+func redactURL(rawURL string, sensitive []string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "REDACTED"
+	}
+
+	if u.User != nil {
+		u.User = url.UserPassword("REDACTED", "REDACTED")
+	}
+
+	q := u.Query()
+	for key := range q {
+		lower := strings.ToLower(key)
+		for _, s := range sensitive {
+			if s != "" && strings.Contains(lower, s) {
+				q.Set(key, "REDACTED")
+				break
+			}
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// defaultLogFileMaxBytes is the rotation threshold used when -log-file is
+// set without a custom -log-file-max-bytes, bounding an unattended cron
+// run's disk use without requiring the operator to configure anything.
+const defaultLogFileMaxBytes = 10 * 1024 * 1024
+
+// rotatingFileWriter is an io.Writer that appends to a log file, renaming it
+// to a single ".1" backup and starting fresh once a write would grow it past
+// maxBytes. This is deliberately simple - one backup, size-triggered -
+// rather than a full logrotate-style scheme, since -log-file only needs to
+// bound disk use for an overnight run, not archive logs long-term.
+type rotatingFileWriter struct {
+	path     string
+	maxBytes int64
+	file     *os.File
+	size     int64
+}
+
+// newRotatingFileWriter opens path for appending, creating parent
+// directories as needed, and picks up where any existing file left off.
+// maxBytes <= 0 disables rotation.
+func newRotatingFileWriter(path string, maxBytes int64) (*rotatingFileWriter, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &rotatingFileWriter{path: path, maxBytes: maxBytes, file: f, size: info.Size()}, nil
+}
+
+func (w *rotatingFileWriter) Write(p []byte) (int, error) {
+	if w.maxBytes > 0 && w.size+int64(len(p)) > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate closes the current file, moves it to a ".1" backup (replacing any
+// earlier one), and starts a fresh empty file at path.
+func (w *rotatingFileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.size = 0
+	return nil
+}
+
+func (w *rotatingFileWriter) Close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFileWriter_CreatesParentDirectories(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "dir", "run.log")
+
+	w, err := newRotatingFileWriter(path, 0)
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected a log file at %s, err = %v", path, err)
+	}
+}
+
+func TestRotatingFileWriter_AppendsAcrossCalls(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.log")
+
+	w, err := newRotatingFileWriter(path, 0)
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	w.Write([]byte("first\n"))
+	w.Close()
+
+	w2, err := newRotatingFileWriter(path, 0)
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() second open error = %v", err)
+	}
+	defer w2.Close()
+	w2.Write([]byte("second\n"))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first\nsecond\n" {
+		t.Errorf("file contents = %q, want both writes appended", string(data))
+	}
+}
+
+func TestRotatingFileWriter_RotatesPastMaxBytes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.log")
+
+	w, err := newRotatingFileWriter(path, 10)
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := w.Write([]byte("overflow")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	backup, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatalf("expected a %s.1 backup, err = %v", path, err)
+	}
+	if string(backup) != "0123456789" {
+		t.Errorf("backup contents = %q, want the pre-rotation data", string(backup))
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current) != "overflow" {
+		t.Errorf("current contents = %q, want just the post-rotation write", string(current))
+	}
+}
+
+func TestNewLogger_WritesToLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.log")
+	cfg := Config{LogFile: path, LogFormat: "json"}
+
+	logger := newLogger(cfg)
+	logger.Info("hello from the test")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected a log file at %s, err = %v", path, err)
+	}
+	if !strings.Contains(string(data), "hello from the test") {
+		t.Errorf("log file contents = %q, want the logged message", string(data))
+	}
+}
+
+func TestNewLogger_TextFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.log")
+	cfg := Config{LogFile: path, LogFormat: "text"}
+
+	logger := newLogger(cfg)
+	logger.Info("hello")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		t.Errorf("log file contents = %q, want slog's text format, not JSON", string(data))
+	}
+}
+
+func TestParseFlags_LogFormatRejectsUnknownValue(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-log-format", "xml"})
+	if err == nil {
+		t.Fatal("expected an error for an unrecognized -log-format")
+	}
+}
+
+func TestParseFlags_LogFileMaxBytesRejectsNegative(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-log-file-max-bytes", "-1"})
+	if err == nil {
+		t.Fatal("expected an error for a negative -log-file-max-bytes")
+	}
+}
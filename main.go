@@ -0,0 +1,88 @@
+// Command eagleusb fetches a Last.fm collage image and embeds it as a
+// base64 data URI in a GitHub profile README.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "eagleusb:", err)
+		os.Exit(2)
+	}
+
+	if cfg.ListPeriods {
+		for _, period := range conventionalPeriods {
+			fmt.Println(period)
+		}
+		return
+	}
+	if cfg.ListMethods {
+		for _, method := range conventionalMethods {
+			fmt.Println(method)
+		}
+		return
+	}
+
+	if cfg.SampleConfig {
+		fmt.Println(cfg.SampleConfigText)
+		return
+	}
+
+	if cfg.Explain {
+		if err := runExplain(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "eagleusb:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.SelfTest {
+		if !runSelfTest(cfg) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.HealthCheck {
+		if !runHealthCheck(cfg) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.ValidateOnly {
+		if !runValidateOnly(cfg) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.CompareWithRemote != "" {
+		if !runCompareWithRemote(cfg) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.Serve != "" {
+		if err := runServe(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "eagleusb:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "eagleusb:", err)
+		os.Exit(2)
+	}
+	if err := p.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "eagleusb:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// manifest is a small sidecar file tracking bookkeeping across separate
+// invocations of the binary (as opposed to -watch, which stays resident and
+// keeps its own in-memory state): LastRun backs -min-interval, ImageHash
+// backs -image-diff-threshold, Images backs -incremental, and
+// RedirectedFromHost/RedirectedToHost back
+// -warn-on-redirect-to-different-host.
+type manifest struct {
+	LastRun            time.Time              `json:"last_run"`
+	ImageHash          string                 `json:"image_hash,omitempty"`
+	Images             map[string]cachedImage `json:"images,omitempty"`
+	RedirectedFromHost string                 `json:"redirected_from_host,omitempty"`
+	RedirectedToHost   string                 `json:"redirected_to_host,omitempty"`
+}
+
+// manifestPath returns the sidecar manifest path for a given -out: the
+// same path with ".eagleusb-manifest.json" appended, so it sits alongside
+// the generated file without colliding with anything else there.
+func manifestPath(out string) string {
+	return out + ".eagleusb-manifest.json"
+}
+
+// loadManifest reads the manifest at path, returning a zero-value manifest
+// (not an error) if it doesn't exist yet, since that's simply the
+// first-ever run.
+func loadManifest(path string) (manifest, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest{}, nil
+	}
+	if err != nil {
+		return manifest{}, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return manifest{}, err
+	}
+	return m, nil
+}
+
+// saveManifest writes m to path as JSON.
+func saveManifest(path string, m manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
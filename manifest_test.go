@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifest_LoadMissingReturnsZeroValue(t *testing.T) {
+	m, err := loadManifest(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("loadManifest() error = %v", err)
+	}
+	if !m.LastRun.IsZero() {
+		t.Fatalf("LastRun = %v, want zero value", m.LastRun)
+	}
+}
+
+func TestManifest_SaveThenLoadRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	want := time.Now().Truncate(time.Second)
+
+	if err := saveManifest(path, manifest{LastRun: want}); err != nil {
+		t.Fatalf("saveManifest() error = %v", err)
+	}
+
+	got, err := loadManifest(path)
+	if err != nil {
+		t.Fatalf("loadManifest() error = %v", err)
+	}
+	if !got.LastRun.Equal(want) {
+		t.Fatalf("LastRun = %v, want %v", got.LastRun, want)
+	}
+}
+
+func TestPipelineRun_MinIntervalSkipsWithoutForce(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:         srv.URL,
+		Out:         out,
+		Timeout:     5 * time.Second,
+		UserAgent:   defaultUserAgent,
+		MinInterval: time.Hour,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+
+	p2, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p2.Run(); err != nil {
+		t.Fatalf("second Run() error = %v, want a skipped no-op", err)
+	}
+
+	cfg.Force = true
+	p3, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p3.Run(); err != nil {
+		t.Fatalf("forced Run() error = %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// validateMarkdown implements -validate-markdown. eagleusb has no
+// third-party dependencies, so this isn't a full CommonMark parser; it's a
+// dependency-free structural check for the mistakes a broken template is
+// actually likely to produce: an image or link reference with an unbalanced
+// "[...]"/"(...)", and unbalanced <picture>/</picture> tags (from
+// -picture). Errors report the 1-based line number they occurred on.
+func validateMarkdown(rendered []byte) error {
+	var issues []string
+
+	lines := strings.Split(string(rendered), "\n")
+	for i, line := range lines {
+		if err := validateMarkdownLine(line); err != nil {
+			issues = append(issues, fmt.Sprintf("line %d: %v", i+1, err))
+		}
+	}
+
+	if err := validateBalancedTags(rendered); err != nil {
+		issues = append(issues, err.Error())
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w:\n%s", ErrValidateMarkdown, strings.Join(issues, "\n"))
+}
+
+// validateMarkdownLine checks every "[" on line (image references are "!["
+// immediately before it) is followed by a matching "]" and then a
+// parenthesized "(url)", the syntax an image/link reference requires.
+func validateMarkdownLine(line string) error {
+	for i := 0; i < len(line); i++ {
+		if line[i] != '[' {
+			continue
+		}
+
+		kind := "link"
+		if i > 0 && line[i-1] == '!' {
+			kind = "image"
+		}
+
+		closeBracket := strings.IndexByte(line[i:], ']')
+		if closeBracket == -1 {
+			return fmt.Errorf("%s reference missing closing \"]\"", kind)
+		}
+		closeBracket += i
+
+		if closeBracket+1 >= len(line) || line[closeBracket+1] != '(' {
+			return fmt.Errorf("%s reference %q has no following \"(url)\"", kind, line[i:closeBracket+1])
+		}
+
+		closeParen := strings.IndexByte(line[closeBracket+1:], ')')
+		if closeParen == -1 {
+			return fmt.Errorf("%s reference %q missing closing \")\"", kind, line[i:closeBracket+1])
+		}
+
+		i = closeBracket + 1 + closeParen
+	}
+	return nil
+}
+
+// validateBalancedTags checks that every opening <picture> (from -picture)
+// has a matching </picture>, across the whole rendered document.
+func validateBalancedTags(rendered []byte) error {
+	opens := bytes.Count(rendered, []byte("<picture"))
+	closes := bytes.Count(rendered, []byte("</picture>"))
+	if opens != closes {
+		return fmt.Errorf("unbalanced <picture> tags: %d opening, %d closing", opens, closes)
+	}
+	return nil
+}
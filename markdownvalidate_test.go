@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateMarkdown_AcceptsWellFormedImageReference(t *testing.T) {
+	if err := validateMarkdown([]byte("![alt text](data:image/png;base64,AAAA)\n")); err != nil {
+		t.Errorf("validateMarkdown() error = %v, want nil", err)
+	}
+}
+
+func TestValidateMarkdown_ReportsMissingClosingBracket(t *testing.T) {
+	err := validateMarkdown([]byte("first line\n![alt(url)\n"))
+	if err == nil {
+		t.Fatal("expected an error for an unclosed image reference")
+	}
+	if !errors.Is(err, ErrValidateMarkdown) {
+		t.Errorf("error = %v, want it to wrap ErrValidateMarkdown", err)
+	}
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("error = %v, want it to report line 2", err)
+	}
+}
+
+func TestValidateMarkdown_ReportsMissingParens(t *testing.T) {
+	err := validateMarkdown([]byte("![alt]\n"))
+	if err == nil {
+		t.Fatal("expected an error for an image reference with no following (url)")
+	}
+	if !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("error = %v, want it to report line 1", err)
+	}
+}
+
+func TestValidateMarkdown_ReportsUnbalancedPictureTags(t *testing.T) {
+	err := validateMarkdown([]byte("<picture><img src=\"x\"></picture><picture>\n"))
+	if err == nil {
+		t.Fatal("expected an error for an unclosed <picture> tag")
+	}
+	if !strings.Contains(err.Error(), "picture") {
+		t.Errorf("error = %v, want it to mention <picture>", err)
+	}
+}
+
+func TestPipelineRun_ValidateMarkdownRejectsBrokenTemplate(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	tmplDir := t.TempDir()
+	tmplPath := filepath.Join(tmplDir, "broken.tmpl")
+	if err := os.WriteFile(tmplPath, []byte("![broken]({{.ImageURL}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPipeline(Config{
+		URL:              srv.URL,
+		Out:              filepath.Join(t.TempDir(), "README.md"),
+		Template:         tmplPath,
+		Timeout:          5 * time.Second,
+		UserAgent:        defaultUserAgent,
+		ValidateMarkdown: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail on a template that renders unbalanced parens")
+	} else if !errors.Is(err, ErrValidateMarkdown) {
+		t.Errorf("error = %v, want it to wrap ErrValidateMarkdown", err)
+	}
+}
+
+func TestPipelineRun_NoValidateMarkdownAllowsBrokenTemplate(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	tmplDir := t.TempDir()
+	tmplPath := filepath.Join(tmplDir, "broken.tmpl")
+	if err := os.WriteFile(tmplPath, []byte("![broken]({{.ImageURL}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Template:  tmplPath,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want the broken template to pass without -validate-markdown", err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseFlags_MaxBytesRejectsNegative(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-max-bytes", "-1"})
+	if err == nil {
+		t.Fatal("expected an error for a negative -max-bytes")
+	}
+}
+
+func TestPipelineRun_MaxBytesRejectsChunkedResponseOverLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		w.Header().Set("Content-Type", "image/png")
+		for i := 0; i < 5; i++ {
+			w.Write(make([]byte, 100))
+			flusher.Flush()
+		}
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		MaxBytes:  200,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	_, err = p.fetchTemplateData()
+	if !errors.Is(err, ErrFetch) || !errors.Is(err, ErrMaxBytes) {
+		t.Fatalf("fetchTemplateData() error = %v, want ErrFetch wrapping ErrMaxBytes", err)
+	}
+}
+
+func TestPipelineRun_MaxBytesAllowsResponseUnderLimit(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		MaxBytes:  1 << 20,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	if _, err := p.fetchTemplateData(); err != nil {
+		t.Fatalf("fetchTemplateData() error = %v, want nil under the byte limit", err)
+	}
+}
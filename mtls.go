@@ -0,0 +1,26 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// clientCertTLSConfig loads a client certificate/key pair for mutual TLS
+// against internal collage services that require it. certFile and keyFile
+// must both be given together or both left empty; providing just one is
+// rejected rather than silently running without mTLS.
+func clientCertTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("-client-cert and -client-key must both be set")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading client certificate: %w", err)
+	}
+
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestClientCertTLSConfig_NoneGivenIsNoop(t *testing.T) {
+	cfg, err := clientCertTLSConfig("", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg != nil {
+		t.Fatal("expected nil tls.Config when neither flag is set")
+	}
+}
+
+func TestClientCertTLSConfig_RejectsMismatchedPair(t *testing.T) {
+	if _, err := clientCertTLSConfig("cert.pem", ""); err == nil {
+		t.Fatal("expected error when only -client-cert is set")
+	}
+	if _, err := clientCertTLSConfig("", "key.pem"); err == nil {
+		t.Fatal("expected error when only -client-key is set")
+	}
+}
+
+func TestClientCertTLSConfig_RejectsUnreadableFiles(t *testing.T) {
+	if _, err := clientCertTLSConfig("/nonexistent/cert.pem", "/nonexistent/key.pem"); err == nil {
+		t.Fatal("expected error for missing certificate files")
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import "strings"
+
+// normalizeText trims trailing whitespace from every line and ensures the
+// result ends in exactly one newline, for -normalize. It's plain byte/line
+// manipulation with no awareness of markdown or HTML, but that's safe here:
+// a data URI never itself contains a newline, so per-line trailing-space
+// trimming can never reach into the base64 payload.
+func normalizeText(data []byte) []byte {
+	text := strings.TrimRight(string(data), "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
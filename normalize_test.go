@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"trailing spaces", "line one  \nline two\t\n", "line one\nline two\n"},
+		{"no trailing newline", "line one", "line one\n"},
+		{"multiple trailing newlines", "line one\n\n\n", "line one\n"},
+		{"already normalized", "line one\nline two\n", "line one\nline two\n"},
+		{"empty", "", "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(normalizeText([]byte(tt.in))); got != tt.want {
+				t.Fatalf("normalizeText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeText_DoesNotTouchDataURIPayload(t *testing.T) {
+	in := "![collage](data:image/png;base64,iVBORw0KGgoAAAA==)  \n"
+	want := "![collage](data:image/png;base64,iVBORw0KGgoAAAA==)\n"
+	if got := string(normalizeText([]byte(in))); got != want {
+		t.Fatalf("normalizeText(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestPipelineRun_NormalizeTrimsOutput(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	tmplPath := filepath.Join(t.TempDir(), "readme.tmpl")
+	if err := os.WriteFile(tmplPath, []byte("hello   \nworld\t\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:       srv.URL,
+		Template:  tmplPath,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Normalize: true,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello\nworld\n" {
+		t.Fatalf("rendered output = %q, want %q", got, "hello\nworld\n")
+	}
+}
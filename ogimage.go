@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+)
+
+// Open Graph/social preview images are conventionally 1200x630.
+const (
+	ogWidth  = 1200
+	ogHeight = 630
+)
+
+const (
+	glyphColumns = 5
+	glyphRows    = 7
+)
+
+// font5x7 is a minimal bundled bitmap font used to overlay text on the -og
+// social card: uppercase ASCII letters, digits, space and a handful of
+// punctuation, five columns by seven rows apiece, written as row strings of
+// '0'/'1' for readability. This tree carries no font file or
+// golang.org/x/image/font dependency (see makeThumbnail's doc comment for
+// the same no-extra-module constraint), so -og text is limited to what this
+// table covers: lowercase input is folded to uppercase, and any other rune
+// renders as a blank cell.
+var font5x7 = map[rune][glyphRows]string{
+	'0':  {"01110", "10001", "10011", "10101", "11001", "10001", "01110"},
+	'1':  {"00100", "01100", "00100", "00100", "00100", "00100", "01110"},
+	'2':  {"01110", "10001", "00001", "00010", "00100", "01000", "11111"},
+	'3':  {"11111", "00010", "00100", "00010", "00001", "10001", "01110"},
+	'4':  {"00010", "00110", "01010", "10010", "11111", "00010", "00010"},
+	'5':  {"11111", "10000", "11110", "00001", "00001", "10001", "01110"},
+	'6':  {"00110", "01000", "10000", "11110", "10001", "10001", "01110"},
+	'7':  {"11111", "00001", "00010", "00100", "01000", "01000", "01000"},
+	'8':  {"01110", "10001", "10001", "01110", "10001", "10001", "01110"},
+	'9':  {"01110", "10001", "10001", "01111", "00001", "00010", "01100"},
+	'A':  {"01110", "10001", "10001", "11111", "10001", "10001", "10001"},
+	'B':  {"11110", "10001", "10001", "11110", "10001", "10001", "11110"},
+	'C':  {"01111", "10000", "10000", "10000", "10000", "10000", "01111"},
+	'D':  {"11110", "10001", "10001", "10001", "10001", "10001", "11110"},
+	'E':  {"11111", "10000", "10000", "11110", "10000", "10000", "11111"},
+	'F':  {"11111", "10000", "10000", "11110", "10000", "10000", "10000"},
+	'G':  {"01111", "10000", "10000", "10011", "10001", "10001", "01110"},
+	'H':  {"10001", "10001", "10001", "11111", "10001", "10001", "10001"},
+	'I':  {"01110", "00100", "00100", "00100", "00100", "00100", "01110"},
+	'J':  {"00001", "00001", "00001", "00001", "10001", "10001", "01110"},
+	'K':  {"10001", "10010", "10100", "11000", "10100", "10010", "10001"},
+	'L':  {"10000", "10000", "10000", "10000", "10000", "10000", "11111"},
+	'M':  {"10001", "11011", "10101", "10101", "10001", "10001", "10001"},
+	'N':  {"10001", "11001", "10101", "10011", "10001", "10001", "10001"},
+	'O':  {"01110", "10001", "10001", "10001", "10001", "10001", "01110"},
+	'P':  {"11110", "10001", "10001", "11110", "10000", "10000", "10000"},
+	'Q':  {"01110", "10001", "10001", "10001", "10101", "10010", "01101"},
+	'R':  {"11110", "10001", "10001", "11110", "10100", "10010", "10001"},
+	'S':  {"01111", "10000", "10000", "01110", "00001", "00001", "11110"},
+	'T':  {"11111", "00100", "00100", "00100", "00100", "00100", "00100"},
+	'U':  {"10001", "10001", "10001", "10001", "10001", "10001", "01110"},
+	'V':  {"10001", "10001", "10001", "10001", "10001", "01010", "00100"},
+	'W':  {"10001", "10001", "10001", "10101", "10101", "10101", "01010"},
+	'X':  {"10001", "10001", "01010", "00100", "01010", "10001", "10001"},
+	'Y':  {"10001", "10001", "01010", "00100", "00100", "00100", "00100"},
+	'Z':  {"11111", "00001", "00010", "00100", "01000", "10000", "11111"},
+	' ':  {"00000", "00000", "00000", "00000", "00000", "00000", "00000"},
+	'.':  {"00000", "00000", "00000", "00000", "00000", "01100", "01100"},
+	',':  {"00000", "00000", "00000", "00000", "01100", "01100", "01000"},
+	'-':  {"00000", "00000", "00000", "11111", "00000", "00000", "00000"},
+	'_':  {"00000", "00000", "00000", "00000", "00000", "00000", "11111"},
+	':':  {"00000", "01100", "01100", "00000", "01100", "01100", "00000"},
+	'!':  {"00100", "00100", "00100", "00100", "00100", "00000", "00100"},
+	'?':  {"01110", "10001", "00001", "00010", "00100", "00000", "00100"},
+	'\'': {"00100", "00100", "01000", "00000", "00000", "00000", "00000"},
+	'/':  {"00001", "00010", "00100", "01000", "10000", "00000", "00000"},
+}
+
+// glyphAdvance is the horizontal spacing between glyph origins, in glyph
+// cells: one column of blank space after each character.
+const glyphAdvance = glyphColumns + 1
+
+// drawText overlays text onto dst at (x, y) (top-left of the text block) in
+// ink, with each glyph cell scaled to scale pixels wide. Runes missing from
+// font5x7 render as a blank cell rather than an error, so one unsupported
+// character doesn't fail the whole card.
+func drawText(dst draw.Image, text string, x, y, scale int, ink color.Color) {
+	if scale <= 0 {
+		scale = 1
+	}
+
+	cursor := x
+	for _, r := range strings.ToUpper(text) {
+		glyph, ok := font5x7[r]
+		if ok {
+			for row := 0; row < glyphRows; row++ {
+				for col := 0; col < glyphColumns; col++ {
+					if glyph[row][col] != '1' {
+						continue
+					}
+					px0, py0 := cursor+col*scale, y+row*scale
+					for py := py0; py < py0+scale; py++ {
+						for px := px0; px < px0+scale; px++ {
+							dst.Set(px, py, ink)
+						}
+					}
+				}
+			}
+		}
+		cursor += glyphAdvance * scale
+	}
+}
+
+// resizeNearestExact scales img to exactly dstWidth x dstHeight (stretching,
+// not preserving aspect ratio) using nearest-neighbor sampling, unlike
+// resizeNearest which only ever shrinks to a target width. Simple stretch
+// keeps the -og compositing step dependency-free; callers that care about
+// aspect ratio should crop their source first.
+func resizeNearestExact(img image.Image, dstWidth, dstHeight int) *image.RGBA {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		srcY := bounds.Min.Y + y*srcHeight/dstHeight
+		for x := 0; x < dstWidth; x++ {
+			srcX := bounds.Min.X + x*srcWidth/dstWidth
+			dst.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+	return dst
+}
+
+// renderOGImage composites text and subtext onto a 1200x630 copy of body,
+// for -og-out. text is drawn at (x, y); subtext, if non-empty, is drawn
+// directly below it. Both are scaled by fontSize (glyph cells are fontSize
+// pixels per bit).
+func renderOGImage(body []byte, text, subtext string, x, y, fontSize int) (image.Image, error) {
+	src, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	canvas := resizeNearestExact(src, ogWidth, ogHeight)
+
+	drawText(canvas, text, x, y, fontSize, color.White)
+	if subtext != "" {
+		drawText(canvas, subtext, x, y+(glyphRows+2)*fontSize, fontSize, color.White)
+	}
+
+	return canvas, nil
+}
+
+// ogJPEGOptions bundles the -og-jpeg-* tuning flags for a .jpg/.jpeg
+// -og-out, mirroring retryBudget's pattern of grouping a feature's related
+// knobs into one struct instead of a long parameter list.
+type ogJPEGOptions struct {
+	Quality           int
+	ChromaSubsampling string
+	Progressive       bool
+}
+
+// writeOGImage renders the OG card and writes it to path as a PNG or JPEG,
+// chosen by path's extension (JPEG for .jpg/.jpeg, PNG otherwise). jpegOpts
+// is ignored for a PNG path.
+func writeOGImage(path string, body []byte, text, subtext string, x, y, fontSize int, jpegOpts ogJPEGOptions) error {
+	img, err := renderOGImage(body, text, subtext, x, y, fontSize)
+	if err != nil {
+		return fmt.Errorf("%w: og-image: %w", ErrDecode, err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(strings.ToLower(path), ".jpg") || strings.HasSuffix(strings.ToLower(path), ".jpeg") {
+		return encodeOGJPEG(f, img, jpegOpts)
+	}
+	return png.Encode(f, img)
+}
+
+// encodeOGJPEG writes img as a JPEG honoring the quality from jpegOpts.
+//
+// Go's stdlib image/jpeg encoder can only produce a baseline JPEG with
+// 4:2:0 chroma subsampling - it has no option for progressive encoding or
+// 4:4:4 subsampling, the two knobs -og-jpeg-progressive and
+// -og-jpeg-chroma-subsampling "4:4:4" ask for. A more capable encoder
+// (mozjpeg, libjpeg-turbo) would need cgo or an extra module dependency
+// this tree can't fetch, so for now those two settings are accepted at the
+// flag level - for forward compatibility and to document the tradeoff - but
+// have no effect beyond a warning; only quality is actually honored.
+func encodeOGJPEG(w io.Writer, img image.Image, opts ogJPEGOptions) error {
+	if opts.ChromaSubsampling == "4:4:4" {
+		fmt.Fprintln(os.Stderr, "eagleusb: -og-jpeg-chroma-subsampling 4:4:4 requested, but the stdlib JPEG encoder always writes 4:2:0; writing 4:2:0 instead")
+	}
+	if opts.Progressive {
+		fmt.Fprintln(os.Stderr, "eagleusb: -og-jpeg-progressive requested, but the stdlib JPEG encoder only writes baseline JPEGs; writing baseline instead")
+	}
+	if opts.Quality <= 0 {
+		return jpeg.Encode(w, img, nil)
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: opts.Quality})
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenderOGImage_SizeAndTextPixels(t *testing.T) {
+	body := makePNG(100, 50)
+
+	img, err := renderOGImage(body, "HI", "", 10, 10, 4)
+	if err != nil {
+		t.Fatalf("renderOGImage() error = %v", err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() != ogWidth || bounds.Dy() != ogHeight {
+		t.Fatalf("size = %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), ogWidth, ogHeight)
+	}
+
+	var whitePixels int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if r>>8 == 0xff && g>>8 == 0xff && b>>8 == 0xff {
+				whitePixels++
+			}
+		}
+	}
+	if whitePixels == 0 {
+		t.Fatal("expected some white text pixels to be drawn")
+	}
+}
+
+func TestRenderOGImage_UnsupportedRuneRendersBlankNotError(t *testing.T) {
+	body := makePNG(10, 10)
+	if _, err := renderOGImage(body, "hi \xe2\x98\x83 there", "", 0, 0, 2); err != nil {
+		t.Fatalf("renderOGImage() error = %v, want nil even with an unsupported rune", err)
+	}
+}
+
+func TestDrawText_LowercaseFoldsToUppercase(t *testing.T) {
+	dst := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	drawText(dst, "a", 0, 0, 1, color.White)
+
+	var found bool
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			if r, _, _, _ := dst.At(x, y).RGBA(); r>>8 == 0xff {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected lowercase 'a' to draw using the uppercase 'A' glyph")
+	}
+}
+
+func TestPipelineRun_OGOutWritesSocialCard(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ogPath := filepath.Join(dir, "og.png")
+	p, err := NewPipeline(Config{
+		URL:        srv.URL,
+		Out:        filepath.Join(dir, "README.md"),
+		Timeout:    5 * time.Second,
+		UserAgent:  defaultUserAgent,
+		OGOut:      ogPath,
+		OGText:     "EAGLEUSB",
+		OGSubtext:  "7 DAY",
+		OGTextX:    40,
+		OGTextY:    40,
+		OGFontSize: 4,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if info, err := os.Stat(ogPath); err != nil || info.Size() == 0 {
+		t.Fatalf("expected a non-empty OG image at %s, err = %v", ogPath, err)
+	}
+}
+
+func TestWriteOGImage_PNGAndJPEG(t *testing.T) {
+	body := makePNG(20, 20)
+	dir := t.TempDir()
+
+	pngPath := filepath.Join(dir, "og.png")
+	if err := writeOGImage(pngPath, body, "README", "7DAY", 20, 20, 2, ogJPEGOptions{}); err != nil {
+		t.Fatalf("writeOGImage() PNG error = %v", err)
+	}
+	if info, err := os.Stat(pngPath); err != nil || info.Size() == 0 {
+		t.Fatalf("expected a non-empty PNG at %s, err = %v", pngPath, err)
+	}
+
+	jpegPath := filepath.Join(dir, "og.jpg")
+	if err := writeOGImage(jpegPath, body, "README", "7DAY", 20, 20, 2, ogJPEGOptions{}); err != nil {
+		t.Fatalf("writeOGImage() JPEG error = %v", err)
+	}
+	if info, err := os.Stat(jpegPath); err != nil || info.Size() == 0 {
+		t.Fatalf("expected a non-empty JPEG at %s, err = %v", jpegPath, err)
+	}
+}
+
+func TestWriteOGImage_JPEGQualityAffectsSize(t *testing.T) {
+	body := makePNG(40, 40)
+	dir := t.TempDir()
+
+	lowPath := filepath.Join(dir, "low.jpg")
+	if err := writeOGImage(lowPath, body, "README", "7DAY", 20, 20, 2, ogJPEGOptions{Quality: 1}); err != nil {
+		t.Fatalf("writeOGImage() low quality error = %v", err)
+	}
+	highPath := filepath.Join(dir, "high.jpg")
+	if err := writeOGImage(highPath, body, "README", "7DAY", 20, 20, 2, ogJPEGOptions{Quality: 100}); err != nil {
+		t.Fatalf("writeOGImage() high quality error = %v", err)
+	}
+
+	low, err := os.Stat(lowPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	high, err := os.Stat(highPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if low.Size() >= high.Size() {
+		t.Errorf("low-quality JPEG size = %d, high-quality = %d, want low < high", low.Size(), high.Size())
+	}
+}
+
+func TestEncodeOGJPEG_WarnsButSucceedsForUnsupportedOptions(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	var buf bytes.Buffer
+
+	err := encodeOGJPEG(&buf, img, ogJPEGOptions{ChromaSubsampling: "4:4:4", Progressive: true})
+	if err != nil {
+		t.Fatalf("encodeOGJPEG() error = %v, want a warning rather than a failure for unsupported options", err)
+	}
+	if buf.Len() == 0 {
+		t.Error("encodeOGJPEG() wrote no bytes")
+	}
+}
+
+func TestParseFlags_OGJPEGQualityRejectsOutOfRange(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-og-jpeg-quality", "101"})
+	if err == nil {
+		t.Fatal("expected an error for an -og-jpeg-quality above 100")
+	}
+}
+
+func TestParseFlags_OGJPEGChromaSubsamplingRejectsUnknownValue(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-og-jpeg-chroma-subsampling", "4:1:1"})
+	if err == nil {
+		t.Fatal("expected an error for an unrecognized -og-jpeg-chroma-subsampling")
+	}
+}
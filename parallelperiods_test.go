@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun_ParallelPeriodsFetchesEachPeriod(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URLTemplate:     srv.URL + "/?period={period}",
+		ParallelPeriods: "7day,overall",
+		Out:             filepath.Join(t.TempDir(), "README.md"),
+		Timeout:         5 * time.Second,
+		UserAgent:       defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v", err)
+	}
+	if data.Images["7day"] == "" || data.Images["overall"] == "" {
+		t.Fatalf("Images = %v, want both periods keyed", data.Images)
+	}
+}
+
+func TestPipelineRun_ParallelPeriodsPartialKeepsSucceedingPeriods(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("period") == "overall" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(makePNG(1, 1))
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URLTemplate:     srv.URL + "/?period={period}",
+		ParallelPeriods: "7day,overall",
+		Out:             filepath.Join(t.TempDir(), "README.md"),
+		Timeout:         5 * time.Second,
+		UserAgent:       defaultUserAgent,
+		Partial:         true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v, want nil since -partial is set", err)
+	}
+	if data.Images["7day"] == "" {
+		t.Fatalf("Images = %v, want the succeeding period kept", data.Images)
+	}
+	if _, ok := data.Images["overall"]; ok {
+		t.Fatalf("Images = %v, want the failing period dropped", data.Images)
+	}
+}
+
+func TestParseFlags_ParallelPeriodsRequiresURLTemplate(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-parallel-periods", "7day,overall"})
+	if err == nil {
+		t.Fatal("expected an error when -parallel-periods is set without -url-template")
+	}
+}
+
+func TestParseFlags_ParallelPeriodsRejectsEmptyPeriod(t *testing.T) {
+	_, err := parseFlags([]string{"-url-template", "https://example.com/{period}", "-parallel-periods", "7day,,overall"})
+	if err == nil {
+		t.Fatal("expected an error for an empty period in -parallel-periods")
+	}
+}
+
+func TestParseFlags_ParallelPeriodsMutuallyExclusiveWithStack(t *testing.T) {
+	_, err := parseFlags([]string{"-url-template", "https://example.com/{period}", "-parallel-periods", "7day", "-stack", "albums"})
+	if err == nil {
+		t.Fatal("expected an error when -parallel-periods and -stack are both set")
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+)
+
+// pngFallback decodes a stdlib-decodable image (png/jpeg/gif) and re-encodes
+// it as a PNG data URI, for use as the <img> fallback inside a <picture>
+// element built by -picture. WebP isn't handled here: this package has no
+// WebP decoder (see webp.go), so a WebP source can only ever be the <source>,
+// never converted into a fallback.
+func pngFallback(body []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+func TestPngFallback_ReencodesJPEGAsPNG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(0, 0, color.White)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	uri, err := pngFallback(buf.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(uri, "data:image/png;base64,") {
+		t.Fatalf("uri = %q, want a PNG data URI", uri)
+	}
+}
+
+func TestPngFallback_RejectsUndecodable(t *testing.T) {
+	if _, err := pngFallback([]byte("not an image")); err == nil {
+		t.Fatal("expected error for undecodable input")
+	}
+}
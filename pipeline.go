@@ -0,0 +1,1886 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
+)
+
+// Pipeline ties together fetching, encoding and template rendering for a
+// single collage-embedding run. It is the library-level entry point; main()
+// is a thin wrapper around it.
+//
+// A *Pipeline is safe for concurrent Run calls: Client, the cache and the
+// user-agent rotator all guard their own state, the template is parsed once
+// and executed concurrently (text/template.Execute is safe for that), and
+// Config/ImageProcessor/OnEvent/Transports are expected to be set once
+// before the first Run and only read afterward.
+type Pipeline struct {
+	Config Config
+	Client *http.Client
+
+	// primaryCache holds the last validated primary-URL image across
+	// watch-mode cycles, letting a 304 response skip decode entirely.
+	primaryCache *imageCache
+
+	// userAgents rotates through -user-agent-file (or just -user-agent)
+	// once per fetch.
+	userAgents *userAgentRotator
+
+	// logger emits structured retry diagnostics; it's a no-op above debug
+	// level unless -debug is set.
+	logger *slog.Logger
+
+	// decodeSem bounds how many images are decoded/encoded at once,
+	// independent of fetch concurrency, so a multi-image run doesn't spike
+	// CPU even though its network requests are already in flight.
+	decodeSem chan struct{}
+
+	// diagMu guards every field below it up to rng: runOnce's diagnostic and
+	// dispatch-control state. A *Pipeline is documented (and tested, see
+	// concurrency_test.go) as safe for concurrent Run calls, and
+	// -grid-config/-sources-config/-stack already fan fetchAndEncode out
+	// across goroutines sharing one *Pipeline within a single runOnce call,
+	// so every one of these fields needs a lock rather than a plain
+	// assignment. Access them through the setX/isX/getX helpers below, never
+	// directly.
+	diagMu sync.Mutex
+
+	// lastTiming is the most recent primary fetch's -trace breakdown, set
+	// once per runOnce call for runAndReport to surface in the RunReport
+	// JSON.
+	lastTiming fetchTiming
+
+	// stackUnchanged is set by fetchStackTemplateData when -incremental
+	// finds every -stack method's conditional request came back 304,
+	// telling dispatchRunOnce to skip rendering and writing -out entirely
+	// rather than rewrite it with byte-identical embedded images.
+	stackUnchanged bool
+
+	// warned is reset at the start of each runOnce call and set by any
+	// code path that emits a non-fatal warning to stderr (currently just
+	// an animated WebP, see warnIfAnimated, or a -image-diff-threshold
+	// overrun), for -fail-on-warning.
+	warned bool
+
+	// lastImageHash and imageHashValid hold the primary image's average
+	// hash from the most recent runOnce call, for -image-diff-threshold;
+	// recordRun persists it into the manifest for the next run to compare
+	// against. imageHashValid is false when -image-diff-threshold isn't
+	// set or the image isn't a format computeImageHash can decode.
+	lastImageHash  uint64
+	imageHashValid bool
+
+	// redirectedFromHost and redirectedToHost hold the primary URL's host
+	// and the host the response actually came from, for the most recent
+	// runOnce call, whenever -warn-on-redirect-to-different-host finds them
+	// different; recordRun persists both into the manifest. Both are empty
+	// when the flag is off or no redirect crossed hosts.
+	redirectedFromHost string
+	redirectedToHost   string
+
+	// placeholderActive is set by fetchTemplateData when -min-playcount
+	// finds the source reporting fewer scrobbles than the threshold (see
+	// playcountBelowThreshold in playcount.go), telling resolveActiveTemplate
+	// to render -placeholder-template instead of the normal collage
+	// template for this cycle.
+	placeholderActive bool
+
+	// rng backs every randomized decision in a run (-jitter,
+	// -watch-jitter), seeded from -seed (see newSeededRand) so they're
+	// reproducible under a fixed seed instead of drawing from the global
+	// math/rand.
+	rng *seededRand
+
+	// ImageProcessor, when set, runs on the decoded image between fetch and
+	// encode (e.g. BorderProcessor). It is primarily a library-level hook,
+	// not a CLI flag, since processors are Go functions - -watermark is the
+	// one exception, NewPipeline installs WatermarkProcessor here when it's
+	// set, so assigning ImageProcessor afterward overrides -watermark rather
+	// than composing with it. Leaving it nil is a pure pass-through: the
+	// original bytes are embedded with no re-encode.
+	ImageProcessor ImageProcessor
+
+	// OnEvent, when set, is called for each fetch-started, fetch-done,
+	// decode-done and written event, letting a library embedder drive a
+	// progress bar. Like ImageProcessor, it's a library-level hook rather
+	// than a CLI flag; the CLI leaves it nil.
+	OnEvent func(Event)
+
+	// Transports chains RoundTripperMiddleware around Client's transport
+	// (metrics, auth injection, logging, rate-limiting) without forking the
+	// package. Like ImageProcessor and OnEvent, it's a library-level hook
+	// set once before the first Run call; see applyTransports for the
+	// wrapping order.
+	Transports []RoundTripperMiddleware
+
+	// transportOnce guards applyTransports so Transports is wrapped around
+	// Client.Transport at most once, no matter how many Run calls race to
+	// get there first.
+	transportOnce sync.Once
+
+	// tmplOnce/tmpl/tmplErr cache the parsed -template so concurrent Run
+	// calls (see compiledTemplate) each pay the parse cost at most once.
+	// *template.Template is itself safe for concurrent Execute.
+	tmplOnce sync.Once
+	tmpl     *template.Template
+	tmplName string
+	tmplErr  error
+}
+
+// setWarned, isWarned and resetWarned guard Pipeline.warned with diagMu; see
+// its doc comment for why a plain bool field isn't safe here.
+func (p *Pipeline) setWarned() {
+	p.diagMu.Lock()
+	p.warned = true
+	p.diagMu.Unlock()
+}
+
+func (p *Pipeline) isWarned() bool {
+	p.diagMu.Lock()
+	defer p.diagMu.Unlock()
+	return p.warned
+}
+
+func (p *Pipeline) resetWarned() {
+	p.diagMu.Lock()
+	p.warned = false
+	p.diagMu.Unlock()
+}
+
+// setLastTiming and getLastTiming guard Pipeline.lastTiming with diagMu.
+func (p *Pipeline) setLastTiming(timing fetchTiming) {
+	p.diagMu.Lock()
+	p.lastTiming = timing
+	p.diagMu.Unlock()
+}
+
+func (p *Pipeline) getLastTiming() fetchTiming {
+	p.diagMu.Lock()
+	defer p.diagMu.Unlock()
+	return p.lastTiming
+}
+
+// setStackUnchanged and isStackUnchanged guard Pipeline.stackUnchanged with
+// diagMu.
+func (p *Pipeline) setStackUnchanged(v bool) {
+	p.diagMu.Lock()
+	p.stackUnchanged = v
+	p.diagMu.Unlock()
+}
+
+func (p *Pipeline) isStackUnchanged() bool {
+	p.diagMu.Lock()
+	defer p.diagMu.Unlock()
+	return p.stackUnchanged
+}
+
+// setPlaceholderActive and isPlaceholderActive guard Pipeline.placeholderActive
+// with diagMu.
+func (p *Pipeline) setPlaceholderActive(v bool) {
+	p.diagMu.Lock()
+	p.placeholderActive = v
+	p.diagMu.Unlock()
+}
+
+func (p *Pipeline) isPlaceholderActive() bool {
+	p.diagMu.Lock()
+	defer p.diagMu.Unlock()
+	return p.placeholderActive
+}
+
+// setRedirectHosts and redirectHosts guard Pipeline.redirectedFromHost/
+// redirectedToHost with diagMu; both are always read and written together,
+// so one pair of accessors covers both fields.
+func (p *Pipeline) setRedirectHosts(from, to string) {
+	p.diagMu.Lock()
+	p.redirectedFromHost, p.redirectedToHost = from, to
+	p.diagMu.Unlock()
+}
+
+func (p *Pipeline) redirectHosts() (from, to string) {
+	p.diagMu.Lock()
+	defer p.diagMu.Unlock()
+	return p.redirectedFromHost, p.redirectedToHost
+}
+
+// setImageHash and imageHash guard Pipeline.lastImageHash/imageHashValid
+// with diagMu; like redirectHosts, both fields are always set together.
+func (p *Pipeline) setImageHash(hash uint64) {
+	p.diagMu.Lock()
+	p.lastImageHash, p.imageHashValid = hash, true
+	p.diagMu.Unlock()
+}
+
+func (p *Pipeline) imageHash() (hash uint64, valid bool) {
+	p.diagMu.Lock()
+	defer p.diagMu.Unlock()
+	return p.lastImageHash, p.imageHashValid
+}
+
+// watchWait returns how long Run's watch loop should sleep before the next
+// cycle. When -cron is set it takes precedence over -watch-interval,
+// aligning runs to wall-clock time instead of a fixed period since the last
+// run; either way, -watch-jitter is applied to the result. cronSched is
+// parsed once by Run and reused here since cfg.Cron was already validated
+// by parseFlags. rng is the run's -seed-derived source (see newSeededRand).
+func watchWait(cfg Config, cronSched *cronSchedule, now time.Time, rng *seededRand) (time.Duration, error) {
+	if cronSched != nil {
+		next, err := cronSched.next(now)
+		if err != nil {
+			return 0, err
+		}
+		return jitteredInterval(rng, next.Sub(now), cfg.WatchJitter), nil
+	}
+	return jitteredInterval(rng, cfg.WatchInterval, cfg.WatchJitter), nil
+}
+
+// compiledTemplate parses -template on first use and reuses it thereafter.
+// It is safe to call from multiple goroutines: sync.Once guarantees the
+// parse happens exactly once no matter how many Run calls race to get here
+// first.
+func (p *Pipeline) compiledTemplate() (*template.Template, string, error) {
+	p.tmplOnce.Do(func() {
+		p.tmpl, p.tmplName, p.tmplErr = loadTemplateForConfig(p.Config)
+	})
+	return p.tmpl, p.tmplName, p.tmplErr
+}
+
+// resolveActiveTemplate returns the template to execute for this run. With
+// -time-template-config unset it's just compiledTemplate's cached parse.
+// With -time-template-config set, the window containing now's local time
+// picks -template for this call, and the result is parsed fresh every time
+// rather than cached like compiledTemplate - the whole point of the feature
+// is to change across a long-running -watch as the clock crosses a window
+// boundary, which a one-time sync.Once parse would defeat.
+func (p *Pipeline) resolveActiveTemplate(now time.Time) (*template.Template, string, error) {
+	if p.isPlaceholderActive() {
+		tmpl, err := loadPlaceholderTemplate(p.Config.PlaceholderTemplate)
+		if err != nil {
+			return nil, "", err
+		}
+		applyStrictTemplateOption(tmpl, p.Config.StrictTemplate)
+		return tmpl, "", nil
+	}
+
+	if p.Config.TimeTemplateConfig == "" {
+		return p.compiledTemplate()
+	}
+
+	windows, err := loadTimeTemplateConfig(p.Config.TimeTemplateConfig)
+	if err != nil {
+		return nil, "", fmt.Errorf("-time-template-config: %w", err)
+	}
+	selected, err := selectTimeTemplate(windows, now)
+	if err != nil {
+		return nil, "", fmt.Errorf("-time-template-config: %w", err)
+	}
+
+	cfg := p.Config
+	if selected != "" {
+		cfg.Template = selected
+	}
+	return loadTemplateForConfig(cfg)
+}
+
+// withTimeContext fills in data's Hour and Greeting for now's local time,
+// and, when updatedBadge is true (-updated-badge), UpdatedBadgeURL, so every
+// fetchTemplateData result (regardless of which dispatch path built it)
+// carries the same time-of-day and freshness context for templates.
+func withTimeContext(data TemplateData, now time.Time, updatedBadge bool) TemplateData {
+	data.Hour = now.Hour()
+	data.Greeting = greetingForHour(data.Hour)
+	if updatedBadge {
+		data.UpdatedBadgeURL = updatedBadgeURL(now)
+	}
+	return data
+}
+
+// externalTemplateData rewrites data for -external-out: every field a
+// template would otherwise embed as a base64 data URI is replaced with
+// imageOut, the external file -image-out already wrote the same fetched
+// image to, so -out and -external-out can render the identical template
+// against the identical fetch for two distribution channels - one that
+// keeps data URIs inline, and one (e.g. a mirror that strips them) that
+// needs a plain file reference instead. CompareImageURL is left untouched,
+// since -compare-url is an experimentation aid with no external-file
+// equivalent of its own.
+func externalTemplateData(data TemplateData, imageOut string) TemplateData {
+	data.ImageURL = imageOut
+	if data.ThumbnailURL != "" {
+		data.ThumbnailURL = imageOut
+	}
+	if data.WebPImageURL != "" {
+		data.WebPImageURL = imageOut
+	}
+	if data.PNGImageURL != "" {
+		data.PNGImageURL = imageOut
+	}
+	return data
+}
+
+// withCustomData fills in data.Custom from -data-file, reading it fresh so a
+// -watch loop picks up edits without restarting. It's a no-op, leaving
+// data.Custom nil, when -data-file isn't set.
+func (p *Pipeline) withCustomData(data TemplateData) (TemplateData, error) {
+	if p.Config.DataFile == "" {
+		return data, nil
+	}
+	custom, err := loadCustomData(p.Config.DataFile)
+	if err != nil {
+		return TemplateData{}, fmt.Errorf("%w: data-file: %w", ErrFetch, err)
+	}
+	data.Custom = custom
+	return data, nil
+}
+
+// NewPipeline builds a Pipeline for cfg with a default HTTP client. URLs
+// using the "unix://" scheme (for local collage services exposed over a
+// Unix domain socket) are rewritten to plain HTTP URLs dialed through a
+// transport bound to that socket. It fails clearly if -client-cert and
+// -client-key are malformed or only one is given.
+func NewPipeline(cfg Config) (*Pipeline, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	if httpURL, sock, ok := resolveSourceURL(cfg.URL); ok {
+		cfg.URL = httpURL
+		client.Transport = unixTransport(sock)
+	}
+	if httpURL, sock, ok := resolveSourceURL(cfg.CompareURL); ok {
+		cfg.CompareURL = httpURL
+		if client.Transport == nil {
+			client.Transport = unixTransport(sock)
+		}
+	}
+
+	tlsConfig, err := clientCertTLSConfig(cfg.ClientCert, cfg.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport, ok := client.Transport.(*http.Transport)
+		if !ok {
+			transport = &http.Transport{}
+			client.Transport = transport
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if len(cfg.AllowHosts) > 0 {
+		transport, ok := client.Transport.(*http.Transport)
+		if !ok {
+			transport = &http.Transport{}
+			client.Transport = transport
+		}
+		if transport.DialContext == nil {
+			transport.DialContext = allowlistDialContext(cfg.AllowHosts)
+		}
+	}
+
+	rotator, err := newUserAgentRotator(cfg.UserAgentFile, cfg.UserAgent)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "eagleusb:", err, "- falling back to -user-agent")
+		rotator = &userAgentRotator{agents: []string{cfg.UserAgent}}
+	}
+
+	decodeConcurrency := cfg.DecodeConcurrency
+	if decodeConcurrency <= 0 {
+		decodeConcurrency = 1
+	}
+
+	logger := newLogger(cfg)
+	logCapabilities(logger)
+
+	p := &Pipeline{
+		Config:       cfg,
+		Client:       client,
+		primaryCache: &imageCache{},
+		userAgents:   rotator,
+		logger:       logger,
+		decodeSem:    make(chan struct{}, decodeConcurrency),
+		rng:          newSeededRand(cfg.Seed),
+	}
+	if cfg.Watermark != "" {
+		p.ImageProcessor = WatermarkProcessor(cfg.Watermark, cfg.WatermarkOpacity, cfg.WatermarkPosition, cfg.WatermarkFontSize)
+	}
+	return p, nil
+}
+
+// Run executes the pipeline once, or, when -watch is set, forever on
+// -watch-interval, logging cache hit/miss metrics after each cycle. If
+// -min-interval is set and the manifest next to -out shows a more recent
+// last run than that, Run refuses to do any work (exiting cleanly rather
+// than erroring, since this is a rate limit, not a failure) unless -force
+// is given.
+func (p *Pipeline) Run() error {
+	p.applyTransports()
+
+	if !p.Config.NoLock {
+		release, err := acquireRunLock(p.outputPathGuess(), p.Config.LockTimeout)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !p.Config.Watch {
+		if reason, err := p.skipReason(); err != nil {
+			return err
+		} else if reason != "" {
+			fmt.Fprintln(os.Stderr, "eagleusb:", reason)
+			return nil
+		}
+		if err := p.runAndReport(ctx); err != nil {
+			return err
+		}
+		return p.recordRun()
+	}
+
+	var cronSched *cronSchedule
+	if p.Config.Cron != "" {
+		sched, err := parseCronSchedule(p.Config.Cron)
+		if err != nil {
+			return err
+		}
+		cronSched = sched
+	}
+
+	for {
+		if reason, err := p.skipReason(); err != nil {
+			fmt.Fprintln(os.Stderr, "eagleusb: watch cycle failed:", err)
+		} else if reason != "" {
+			fmt.Fprintln(os.Stderr, "eagleusb:", reason)
+		} else if err := p.runAndReport(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "eagleusb: watch cycle failed:", err)
+		} else if err := p.recordRun(); err != nil {
+			fmt.Fprintln(os.Stderr, "eagleusb: recording manifest failed:", err)
+		}
+		fmt.Fprintf(os.Stderr, "eagleusb: cache hits=%d misses=%d\n", p.primaryCache.Hits, p.primaryCache.Misses)
+
+		wait, err := watchWait(p.Config, cronSched, time.Now(), p.rng)
+		if err != nil {
+			return err
+		}
+		if err := sleepOrDone(ctx, wait); err != nil {
+			return nil
+		}
+	}
+}
+
+// runAndReport runs one cycle via runOnceWithGrace (runOnce, plus -grace's
+// retry-on-failure), and, when -output-stdout-json is set, prints a
+// RunReport to stdout afterward (on success or failure - the report's Error
+// field carries a failed run, rather than main() writing its own separate
+// error line that a JSON-consuming caller would have to distinguish from
+// the report). ctx is the process's signal-aware context (see Run), so a
+// Ctrl-C/SIGTERM interrupts a -grace retry's backoff sleep immediately
+// instead of waiting it out.
+func (p *Pipeline) runAndReport(ctx context.Context) error {
+	if !p.Config.OutputStdoutJSON {
+		_, err := p.runOnceWithGrace(ctx)
+		return err
+	}
+
+	outputPath := p.outputPathGuess()
+	var oldSize int64
+	if info, err := os.Stat(outputPath); err == nil {
+		oldSize = info.Size()
+	}
+
+	start := time.Now()
+	result, runErr := p.runOnceWithGrace(ctx)
+	report := RunReport{
+		OutputPath: outputPath,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	if runErr != nil {
+		report.Error = runErr.Error()
+	} else {
+		report.OutputPath = result.OutputPath
+		report.MimeType = result.MimeType
+		report.RunID = result.RunID
+		if p.Config.Trace {
+			report.Trace = &result.Timing
+		}
+		if info, err := os.Stat(result.OutputPath); err == nil {
+			report.Bytes = info.Size()
+			report.Changed = info.Size() != oldSize
+		}
+	}
+
+	if err := printRunReport(os.Stdout, report); err != nil && runErr == nil {
+		return fmt.Errorf("%w: run report: %w", ErrWrite, err)
+	}
+	return runErr
+}
+
+// outputPathGuess returns the path Run will write to, without yet knowing
+// whether the run succeeds - used to capture a "before" size for the run
+// report's Changed field.
+func (p *Pipeline) outputPathGuess() string {
+	switch {
+	case p.Config.TargetFile != "":
+		return p.Config.TargetFile
+	case p.Config.OutputDir != "" && len(p.Config.Templates) > 0:
+		return p.Config.OutputDir
+	default:
+		return p.Config.Out
+	}
+}
+
+// skipReason returns a human-readable reason to skip this cycle entirely
+// (-min-interval hasn't elapsed, or -since shows no recent source update),
+// or "" if the run should proceed.
+func (p *Pipeline) skipReason() (string, error) {
+	if skip, err := p.tooSoon(); err != nil {
+		return "", err
+	} else if skip {
+		return "last run was less than -min-interval ago, skipping", nil
+	}
+
+	if p.Config.Since > 0 && p.Config.URL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), p.Config.Timeout)
+		stale, err := p.sourceHasNoRecentUpdate(ctx, p.Config.URL)
+		cancel()
+		if err != nil {
+			return "", fmt.Errorf("%w: since check: %w", ErrFetch, err)
+		}
+		if stale {
+			return "source has no update within -since, skipping", nil
+		}
+	}
+
+	return "", nil
+}
+
+// tooSoon reports whether -min-interval is set, unexpired and not
+// overridden by -force.
+func (p *Pipeline) tooSoon() (bool, error) {
+	if p.Config.MinInterval <= 0 || p.Config.Force {
+		return false, nil
+	}
+
+	m, err := loadManifest(manifestPath(p.Config.Out))
+	if err != nil {
+		return false, fmt.Errorf("%w: min-interval manifest: %w", ErrWrite, err)
+	}
+	if m.LastRun.IsZero() {
+		return false, nil
+	}
+	return time.Since(m.LastRun) < p.Config.MinInterval, nil
+}
+
+// recordRun updates the manifest next to -out with whatever this run needs
+// to hand its successor: the current time for -min-interval, the primary
+// image's hash for -image-diff-threshold, and/or the redirect hosts for
+// -warn-on-redirect-to-different-host. It's a no-op if none of those are
+// set, since there's nothing to guard, compare or record. It reads the
+// existing manifest first so setting one field doesn't clobber the others.
+func (p *Pipeline) recordRun() error {
+	if p.Config.MinInterval <= 0 && p.Config.ImageDiffThreshold <= 0 && !p.Config.WarnOnRedirectToDifferentHost {
+		return nil
+	}
+
+	path := manifestPath(p.Config.Out)
+	m, err := loadManifest(path)
+	if err != nil {
+		return fmt.Errorf("%w: manifest: %w", ErrWrite, err)
+	}
+
+	if p.Config.MinInterval > 0 {
+		m.LastRun = time.Now()
+	}
+	if from, to := p.redirectHosts(); p.Config.WarnOnRedirectToDifferentHost && to != "" {
+		m.RedirectedFromHost = from
+		m.RedirectedToHost = to
+	}
+	if hash, valid := p.imageHash(); p.Config.ImageDiffThreshold > 0 && valid {
+		m.ImageHash = strconv.FormatUint(hash, 16)
+	}
+
+	if err := saveManifest(path, m); err != nil {
+		return fmt.Errorf("%w: manifest: %w", ErrWrite, err)
+	}
+	return nil
+}
+
+// checkImageDiff computes result's average hash, stashes it on p for
+// recordRun to persist, and, if the manifest next to -out has a hash from a
+// previous run, warns when the two are more than -image-diff-threshold
+// bits apart - a drastic unexpected change (or, just as easily, the image
+// suspiciously not changing at all would show as a 0 distance, which is
+// left to the operator to notice; this only flags "too different"). It's a
+// warning, not an error, unless paired with -fail-on-warning.
+func (p *Pipeline) checkImageDiff(result fetchResult) {
+	hash, ok, err := computeImageHash(result.body, result.mime)
+	if err != nil || !ok {
+		return
+	}
+	p.setImageHash(hash)
+
+	prev, err := loadManifest(manifestPath(p.Config.Out))
+	if err != nil || prev.ImageHash == "" {
+		return
+	}
+	prevHash, err := strconv.ParseUint(prev.ImageHash, 16, 64)
+	if err != nil {
+		return
+	}
+
+	if dist := hammingDistance(hash, prevHash); dist > p.Config.ImageDiffThreshold {
+		fmt.Fprintf(os.Stderr, "eagleusb: collage image changed drastically since last run (hamming distance %d exceeds -image-diff-threshold %d)\n", dist, p.Config.ImageDiffThreshold)
+		p.setWarned()
+	}
+}
+
+// checkRedirectHost implements -warn-on-redirect-to-different-host: it
+// compares originalURL's host against finalURL's (the URL the response
+// actually came from, after following any redirects - see
+// fetchImageConditional) and warns, the same non-fatal-unless--fail-on-
+// warning signal checkImageDiff uses, when they differ. It stashes both
+// hosts on p (via setRedirectHosts, guarded by diagMu - this is called from
+// goroutines fanning out over the same *Pipeline for -grid-config/
+// -sources-config/-stack) so recordRun can persist them into the manifest. A
+// parse failure on either URL is treated as "can't tell" rather than a
+// warning - this is passive monitoring, complementing -allow-host's hard
+// SSRF enforcement, not a replacement for it.
+func (p *Pipeline) checkRedirectHost(originalURL, finalURL string) {
+	if !p.Config.WarnOnRedirectToDifferentHost || finalURL == "" {
+		return
+	}
+
+	original, err := url.Parse(originalURL)
+	if err != nil {
+		return
+	}
+	final, err := url.Parse(finalURL)
+	if err != nil {
+		return
+	}
+	if original.Host == final.Host {
+		return
+	}
+
+	p.setRedirectHosts(original.Host, final.Host)
+	fmt.Fprintf(os.Stderr, "eagleusb: redirected from host %q to %q\n", original.Host, final.Host)
+	p.setWarned()
+}
+
+// runResult is what each of runOnce's dispatch targets reports back, for
+// -output-stdout-json's RunReport.
+type runResult struct {
+	OutputPath string
+	MimeType   string
+
+	// Timing is the primary fetch's -trace breakdown, zero unless -trace
+	// was set and this dispatch target fetched exactly one image.
+	Timing fetchTiming
+
+	// RunID correlates this run's structured log lines and run-report
+	// JSON; see -run-id and runid.go.
+	RunID string
+}
+
+// runOnce fetches the configured collage (and, if -compare-url is set, a
+// second collage for comparison, concurrently), encodes each as a data URI
+// and renders them into the configured template(s). If -fail-on-warning is
+// set and any non-fatal warning (e.g. an animated WebP) was emitted along
+// the way, it turns that warning into an error after the work is otherwise
+// done, so CI can catch it without the warning itself ever being fatal on
+// its own.
+func (p *Pipeline) runOnce() (runResult, error) {
+	p.resetWarned()
+	p.setRedirectHosts("", "")
+	result, err := p.dispatchRunOnce()
+	if err == nil && p.Config.FailOnWarning && p.isWarned() {
+		return result, fmt.Errorf("%w: see stderr for details", ErrWarning)
+	}
+	return result, err
+}
+
+// dispatchRunOnce is runOnce's actual dispatch and work; split out so
+// runOnce can check for warnings once, after every return path.
+func (p *Pipeline) dispatchRunOnce() (runResult, error) {
+	if p.Config.DownloadOnly != "" {
+		return p.runDownloadOnly()
+	}
+
+	if p.Config.TargetFile != "" {
+		return p.runReplaceToken()
+	}
+
+	if p.Config.OutputDir != "" && len(p.Config.Templates) > 0 {
+		return p.runBatchTemplates()
+	}
+
+	if p.Config.GistToken != "" {
+		return p.runGist()
+	}
+
+	now := time.Now()
+
+	p.setStackUnchanged(false)
+	p.setPlaceholderActive(false)
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		return runResult{}, err
+	}
+	if p.Config.Incremental && p.isStackUnchanged() {
+		fmt.Fprintln(os.Stderr, "eagleusb: -incremental: no -stack image changed since the last run, skipping write")
+		return runResult{}, nil
+	}
+	data = withTimeContext(data, now, p.Config.UpdatedBadge)
+	data, err = p.withCustomData(data)
+	if err != nil {
+		return runResult{}, err
+	}
+
+	resolvedOut := resolveDatedOutputPath(p.Config.Out, p.Config.DateLayout, now)
+
+	var oldSize int64
+	if info, err := os.Stat(resolvedOut); err == nil {
+		oldSize = info.Size()
+	}
+
+	tmpl, tmplName, err := p.resolveActiveTemplate(now)
+	if err != nil {
+		return runResult{}, fmt.Errorf("%w: %w", ErrTemplate, err)
+	}
+
+	var infoComment string
+	if p.Config.EmbedBuildInfo {
+		infoComment, err = buildInfoComment(redactURL(p.Config.URL, parseRedactNames(p.Config.Redact)), data.ImageHash, data.ImageFormat, data.ImageWidth, data.ImageHeight, now)
+		if err != nil {
+			return runResult{}, err
+		}
+	}
+
+	if p.budget().exceeded(now, p.Config.Timeout) {
+		return runResult{}, fmt.Errorf("%w: -timeout-budget exhausted before the write stage", ErrWrite)
+	}
+	if err := execTemplate(tmpl, tmplName, resolvedOut, data, p.Config.Normalize, p.Config.LineEnding, p.Config.PostCommand, p.Config.ValidateMarkdown, infoComment); err != nil {
+		return runResult{}, err
+	}
+
+	if p.Config.ExternalOut != "" {
+		if err := execTemplate(tmpl, tmplName, p.Config.ExternalOut, externalTemplateData(data, p.Config.ImageOut), p.Config.Normalize, p.Config.LineEnding, p.Config.PostCommand, p.Config.ValidateMarkdown, infoComment); err != nil {
+			return runResult{}, err
+		}
+	}
+
+	if len(p.Config.Sinks) > 0 {
+		rendered, err := renderBytes(tmpl, tmplName, data, p.Config.Normalize, p.Config.LineEnding, p.Config.PostCommand, p.Config.ValidateMarkdown, infoComment)
+		if err != nil {
+			return runResult{}, err
+		}
+		if err := p.runSinks(context.Background(), rendered); err != nil {
+			return runResult{}, err
+		}
+	}
+
+	var newSize int
+	if info, err := os.Stat(resolvedOut); err == nil {
+		newSize = int(info.Size())
+	}
+	p.emit(Event{Type: EventWritten, URL: resolvedOut, Size: newSize})
+
+	if p.Config.StatsFile != "" {
+		stats := RunStats{
+			Timestamp:       now,
+			Width:           data.ImageWidth,
+			Height:          data.ImageHeight,
+			Bytes:           data.ImageBytes,
+			Format:          data.ImageFormat,
+			FetchDurationMs: data.FetchDurationMs,
+		}
+		if err := appendRunStats(p.Config.StatsFile, stats); err != nil {
+			return runResult{}, fmt.Errorf("%w: -stats-file: %w", ErrWrite, err)
+		}
+	}
+
+	if p.Config.GitCommit {
+		if err := p.commitRenderedOutput(oldSize, data, resolvedOut); err != nil {
+			return runResult{}, err
+		}
+	}
+
+	if err := pruneDatedOutputs(p.Config.Out, p.Config.PruneKeep, p.Config.PruneOlderThan, now); err != nil {
+		return runResult{}, err
+	}
+
+	return runResult{OutputPath: resolvedOut, MimeType: primaryMime(data), Timing: p.getLastTiming(), RunID: data.RunID}, nil
+}
+
+// primaryMime returns the MIME type of the image a TemplateData was built
+// from (preferring the PNG fallback, then WebP, then the plain ImageURL),
+// or "" if none of those are data URIs.
+func primaryMime(data TemplateData) string {
+	format := data.PNGImageURL
+	if format == "" {
+		format = data.WebPImageURL
+	}
+	if format == "" {
+		format = data.ImageURL
+	}
+	short := mimeFromDataURI(format)
+	if short == "unknown" {
+		return ""
+	}
+	return "image/" + short
+}
+
+// commitRenderedOutput implements -git-commit: it stats the freshly written
+// -out, renders -commit-message-template against the size delta, and stages
+// and commits -out in its git repository.
+func (p *Pipeline) commitRenderedOutput(oldSize int64, data TemplateData, outPath string) error {
+	var newSize int64
+	if info, err := os.Stat(outPath); err == nil {
+		newSize = info.Size()
+	}
+
+	format := data.PNGImageURL
+	if format == "" {
+		format = data.WebPImageURL
+	}
+	if format == "" {
+		format = data.ImageURL
+	}
+	format = mimeFromDataURI(format)
+
+	message, err := renderCommitMessage(p.Config.CommitMessageTemplate, CommitData{
+		OldSize:   oldSize,
+		NewSize:   newSize,
+		Format:    format,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: message template: %w", ErrGitCommit, err)
+	}
+
+	if err := commitOutput(outPath, message); err != nil {
+		return fmt.Errorf("%w: %w", ErrGitCommit, err)
+	}
+	return nil
+}
+
+// runBatchTemplates fetches the collage once, then renders every -template
+// given into -output-dir, deriving each output filename by stripping the
+// template's ".tmpl" suffix (e.g. "dark.md.tmpl" -> "dark.md").
+func (p *Pipeline) runBatchTemplates() (runResult, error) {
+	now := time.Now()
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		return runResult{}, err
+	}
+	data = withTimeContext(data, now, p.Config.UpdatedBadge)
+	data, err = p.withCustomData(data)
+	if err != nil {
+		return runResult{}, err
+	}
+
+	if err := os.MkdirAll(p.Config.OutputDir, 0o755); err != nil {
+		return runResult{}, fmt.Errorf("%w: output-dir: %w", ErrWrite, err)
+	}
+
+	var infoComment string
+	if p.Config.EmbedBuildInfo {
+		infoComment, err = buildInfoComment(redactURL(p.Config.URL, parseRedactNames(p.Config.Redact)), data.ImageHash, data.ImageFormat, data.ImageWidth, data.ImageHeight, now)
+		if err != nil {
+			return runResult{}, err
+		}
+	}
+
+	for _, tmplPath := range p.Config.Templates {
+		name := strings.TrimSuffix(filepath.Base(tmplPath), ".tmpl")
+		out := filepath.Join(p.Config.OutputDir, name)
+		if err := renderTemplate(tmplPath, out, data, p.Config.Normalize, p.Config.LineEnding, p.Config.PostCommand, p.Config.ValidateMarkdown, infoComment, p.Config.StrictTemplate); err != nil {
+			return runResult{}, err
+		}
+	}
+	return runResult{OutputPath: p.Config.OutputDir, MimeType: primaryMime(data), RunID: data.RunID}, nil
+}
+
+// fetchTemplateData fetches the configured collage (and, if -compare-url is
+// set, a second collage for comparison, concurrently) and builds the
+// TemplateData common to every render of this run.
+func (p *Pipeline) fetchTemplateData() (TemplateData, error) {
+	if p.Config.ImageBase64 != "" || p.Config.ImageBase64Env != "" {
+		return p.fetchImageBase64TemplateData()
+	}
+	if p.Config.FromCache != "" {
+		return p.fetchFromCacheTemplateData()
+	}
+	if p.Config.GridConfig != "" {
+		return p.fetchGridTemplateData()
+	}
+	if p.Config.SourcesConfig != "" {
+		return p.fetchMultiSourceTemplateData()
+	}
+	if p.Config.Stack != "" {
+		return p.fetchStackTemplateData()
+	}
+	if p.Config.ParallelPeriods != "" {
+		return p.fetchParallelPeriodsTemplateData()
+	}
+
+	runID := p.resolveRunID()
+	ctx, cancel := context.WithTimeout(withRunID(context.Background(), runID), p.budget().fetchTimeout(p.Config.Timeout))
+	defer cancel()
+
+	if p.Config.MinPlaycount > 0 {
+		below, err := p.playcountBelowThreshold(ctx, p.Config.URL, p.Config.MinPlaycount)
+		if err != nil {
+			return TemplateData{}, fmt.Errorf("%w: min-playcount check: %w", ErrFetch, err)
+		}
+		if below {
+			p.setPlaceholderActive(true)
+			return TemplateData{Placeholder: true, AltText: caption(p.Config.Lang, "alt"), RunID: runID}, nil
+		}
+	}
+
+	urls := []string{p.Config.URL}
+	if p.Config.CompareURL != "" {
+		urls = append(urls, p.Config.CompareURL)
+	}
+
+	results := make([]fetchResult, len(urls))
+	durations := make([]time.Duration, len(urls))
+
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			cache := (*imageCache)(nil)
+			if i == 0 {
+				cache = p.primaryCache
+			}
+			start := time.Now()
+			results[i] = p.fetchAndEncode(ctx, url, cache)
+			durations[i] = time.Since(start)
+		}(i, url)
+	}
+	wg.Wait()
+
+	p.setLastTiming(results[0].timing)
+	if p.Config.ImageDiffThreshold > 0 && results[0].err == nil {
+		p.checkImageDiff(results[0])
+	}
+
+	if !p.Config.Partial {
+		for _, r := range results {
+			if r.err != nil {
+				return TemplateData{}, r.err
+			}
+		}
+	}
+
+	if err := enforceMaxTotalBytes(results, p.Config.MaxTotalBytes, p.Config.Optimize); err != nil {
+		return TemplateData{}, err
+	}
+
+	if p.Config.ImageOut != "" && results[0].err == nil {
+		if err := writeImageOut(p.Config.ImageOut, results[0].body, results[0].mime); err != nil {
+			return TemplateData{}, fmt.Errorf("%w: image-out: %w", ErrWrite, err)
+		}
+	}
+
+	if p.Config.OGOut != "" && results[0].err == nil {
+		jpegOpts := ogJPEGOptions{
+			Quality:           p.Config.OGJPEGQuality,
+			ChromaSubsampling: p.Config.OGJPEGChromaSubsampling,
+			Progressive:       p.Config.OGJPEGProgressive,
+		}
+		if err := writeOGImage(p.Config.OGOut, results[0].body, p.Config.OGText, p.Config.OGSubtext, p.Config.OGTextX, p.Config.OGTextY, p.Config.OGFontSize, jpegOpts); err != nil {
+			return TemplateData{}, err
+		}
+	}
+
+	if err := confirmOverwrite(p.Config); err != nil {
+		return TemplateData{}, err
+	}
+
+	data := TemplateData{ImageURL: results[0].dataURI, AlbumCount: results[0].albumCount, Animated: results[0].animated, AltText: caption(p.Config.Lang, "alt"), RunID: runID, FetchDurationMs: durations[0].Milliseconds()}
+	if len(results) > 1 {
+		data.CompareImageURL = results[1].dataURI
+	}
+
+	if p.Config.Responsive {
+		data.Responsive = true
+	}
+	if p.Config.Responsive || p.Config.EmbedBuildInfo || p.Config.StatsFile != "" {
+		data.ImageWidth, data.ImageHeight = results[0].width, results[0].height
+	}
+	if p.Config.StatsFile != "" && results[0].err == nil {
+		data.ImageBytes = len(results[0].body)
+	}
+
+	if results[0].thumbnailURI != "" {
+		data.ThumbnailURL = results[0].thumbnailURI
+		data.FullImageURL = results[0].dataURI
+		if p.Config.ImageOut != "" {
+			data.FullImageURL = p.Config.ImageOut
+		}
+		data.ImageURL = data.ThumbnailURL
+	}
+
+	if results[0].webpURI != "" || results[0].pngFallback != "" {
+		data.Picture = true
+		data.WebPImageURL = results[0].webpURI
+		data.PNGImageURL = results[0].pngFallback
+	}
+
+	if results[0].srcset != "" {
+		data.Srcset = results[0].srcset
+	}
+
+	if p.Config.CacheBust && results[0].err == nil {
+		data.CacheBustHash = contentHash(results[0].body)
+	}
+
+	if (p.Config.EmbedBuildInfo || p.Config.StatsFile != "") && results[0].err == nil {
+		data.ImageFormat = results[0].mime
+	}
+	if p.Config.EmbedBuildInfo && results[0].err == nil {
+		data.ImageHash = contentHash(results[0].body)
+	}
+
+	if p.Config.FallbackLink {
+		data.SourceURL = redactURL(p.Config.URL, parseRedactNames(p.Config.Redact))
+	}
+
+	return data, nil
+}
+
+// fetchMultiSourceTemplateData fetches every source in -sources-config
+// concurrently and builds a TemplateData whose Images map holds one data URI
+// per source name. Per-source album counts and thumbnails aren't supported
+// here; sources are assumed to be simple images laid out by the template.
+func (p *Pipeline) fetchMultiSourceTemplateData() (TemplateData, error) {
+	sources, err := loadSources(p.Config.SourcesConfig)
+	if err != nil {
+		return TemplateData{}, fmt.Errorf("%w: sources-config: %w", ErrFetch, err)
+	}
+
+	runID := p.resolveRunID()
+	ctx, cancel := context.WithTimeout(withRunID(context.Background(), runID), p.budget().fetchTimeout(p.Config.Timeout))
+	defer cancel()
+
+	results := make([]fetchResult, len(sources))
+	durations := make([]time.Duration, len(sources))
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func(i int, src ImageSource) {
+			defer wg.Done()
+			start := time.Now()
+			results[i] = p.fetchAndEncode(ctx, src.URL, nil)
+			durations[i] = time.Since(start)
+		}(i, src)
+	}
+	wg.Wait()
+
+	labels := make([]string, len(sources))
+	for i, src := range sources {
+		labels[i] = src.Name
+	}
+	printRunSummary(p.Config.RunSummary, labels, results, durations)
+
+	if err := enforceMaxTotalBytes(results, p.Config.MaxTotalBytes, p.Config.Optimize); err != nil {
+		return TemplateData{}, err
+	}
+
+	images := make(map[string]string, len(sources))
+	for i, src := range sources {
+		if results[i].err != nil {
+			if p.Config.Partial {
+				continue
+			}
+			return TemplateData{}, fmt.Errorf("source %q: %w", src.Name, results[i].err)
+		}
+		images[src.Name] = results[i].dataURI
+	}
+
+	return TemplateData{Images: images, RunID: runID}, nil
+}
+
+// fetchParallelPeriodsTemplateData implements -parallel-periods: it resolves
+// -url-template once per period in the comma-separated list, fetches every
+// resulting URL concurrently the same way fetchMultiSourceTemplateData fans
+// out over -sources-config, and builds a TemplateData whose Images map holds
+// one data URI per period. It exists because -grid-config/-stack already
+// cover "one method x several periods" and "several methods x one period"
+// respectively, but fetching just several periods of a single, arbitrary
+// (non-songstitch) provider needs -url-template's placeholder substitution,
+// which neither of those paths uses.
+func (p *Pipeline) fetchParallelPeriodsTemplateData() (TemplateData, error) {
+	var periods []string
+	for _, period := range strings.Split(p.Config.ParallelPeriods, ",") {
+		periods = append(periods, strings.TrimSpace(period))
+	}
+
+	urls := make([]string, len(periods))
+	for i, period := range periods {
+		url, err := resolveURLTemplate(p.Config.URLTemplate, map[string]string{
+			"username": p.Config.Username,
+			"period":   period,
+			"rows":     strconv.Itoa(p.Config.Rows),
+			"columns":  strconv.Itoa(p.Config.Columns),
+		})
+		if err != nil {
+			return TemplateData{}, err
+		}
+		urls[i] = url
+	}
+
+	runID := p.resolveRunID()
+	ctx, cancel := context.WithTimeout(withRunID(context.Background(), runID), p.budget().fetchTimeout(p.Config.Timeout))
+	defer cancel()
+
+	results := make([]fetchResult, len(periods))
+	durations := make([]time.Duration, len(periods))
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			start := time.Now()
+			results[i] = p.fetchAndEncode(ctx, url, nil)
+			durations[i] = time.Since(start)
+		}(i, url)
+	}
+	wg.Wait()
+
+	printRunSummary(p.Config.RunSummary, periods, results, durations)
+
+	if err := enforceMaxTotalBytes(results, p.Config.MaxTotalBytes, p.Config.Optimize); err != nil {
+		return TemplateData{}, err
+	}
+
+	images := make(map[string]string, len(periods))
+	for i, period := range periods {
+		if results[i].err != nil {
+			if p.Config.Partial {
+				continue
+			}
+			return TemplateData{}, fmt.Errorf("period %q: %w", period, results[i].err)
+		}
+		images[period] = results[i].dataURI
+	}
+
+	return TemplateData{Images: images, RunID: runID}, nil
+}
+
+// fetchStackTemplateData implements -stack: an ergonomic shortcut over the
+// general -sources-config/-grid-config matrix for the single most common
+// request, "top albums over top artists" — it builds one URL per -stack
+// method via buildCollageURL (varying "method", holding -stack-period
+// fixed), fetches them all concurrently, and exposes them to the template
+// both as TemplateData.StackImages (in -stack's order, for the built-in
+// default template to render vertically stacked) and TemplateData.Images
+// (keyed by method, for custom templates, the same as -sources-config).
+func (p *Pipeline) fetchStackTemplateData() (TemplateData, error) {
+	var methods []string
+	for _, method := range strings.Split(p.Config.Stack, ",") {
+		methods = append(methods, strings.TrimSpace(method))
+	}
+
+	runID := p.resolveRunID()
+	ctx, cancel := context.WithTimeout(withRunID(context.Background(), runID), p.budget().fetchTimeout(p.Config.Timeout))
+	defer cancel()
+
+	urls := make([]string, len(methods))
+	for i, method := range methods {
+		url, err := buildCollageURL(p.Config.URL, method, p.Config.StackPeriod, resolvedTextLocation(p.Config))
+		if err != nil {
+			return TemplateData{}, fmt.Errorf("-stack method %q: %w", method, err)
+		}
+		urls[i] = url
+	}
+
+	var prevManifest manifest
+	var caches []*imageCache
+	if p.Config.Incremental {
+		prevManifest, _ = loadManifest(manifestPath(p.Config.Out))
+		caches = incrementalCaches(methods, prevManifest)
+	}
+
+	// results is indexed by each method's position in -stack, not appended
+	// as goroutines finish, so StackImages always comes out in the order
+	// the user specified regardless of which fetch happens to complete
+	// first - see TestPipelineRun_StackOrderIsDeterministicUnderVariedLatency.
+	results := make([]fetchResult, len(methods))
+	durations := make([]time.Duration, len(methods))
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			var cache *imageCache
+			if caches != nil {
+				cache = caches[i]
+			}
+			start := time.Now()
+			results[i] = p.fetchAndEncode(ctx, url, cache)
+			durations[i] = time.Since(start)
+		}(i, url)
+	}
+	wg.Wait()
+	printRunSummary(p.Config.RunSummary, methods, results, durations)
+
+	if p.Config.Incremental {
+		changed, err := recordIncrementalManifest(p.Config.Out, methods, results, caches, prevManifest)
+		if err != nil {
+			return TemplateData{}, fmt.Errorf("%w: incremental manifest: %w", ErrWrite, err)
+		}
+		p.setStackUnchanged(!changed)
+	}
+
+	if err := enforceMaxTotalBytes(results, p.Config.MaxTotalBytes, p.Config.Optimize); err != nil {
+		return TemplateData{}, err
+	}
+
+	images := make(map[string]string, len(methods))
+	stackImages := make([]StackImage, 0, len(methods))
+	bodies := make([][]byte, 0, len(methods))
+	for i, method := range methods {
+		if results[i].err != nil {
+			if p.Config.Partial {
+				continue
+			}
+			return TemplateData{}, fmt.Errorf("-stack method %q: %w", method, results[i].err)
+		}
+		images[method] = results[i].dataURI
+		stackImages = append(stackImages, StackImage{Method: method, ImageURL: results[i].dataURI})
+		bodies = append(bodies, results[i].body)
+	}
+
+	if !p.Config.StackComposite {
+		if p.Config.Dedupe {
+			stackImages = dedupeStackImages(stackImages)
+		}
+		return TemplateData{Images: images, StackImages: stackImages, AltText: caption(p.Config.Lang, "alt"), RunID: runID}, nil
+	}
+
+	composite, err := compositeImages(bodies, p.Config.StackDirection, p.Config.StackSpacing)
+	if err != nil {
+		return TemplateData{}, err
+	}
+	dataURI, _, _, err := encodeImageToBase64(composite, "image/png", "image/png", false)
+	if err != nil {
+		return TemplateData{}, err
+	}
+
+	return TemplateData{ImageURL: dataURI, AltText: caption(p.Config.Lang, "alt"), RunID: runID}, nil
+}
+
+// fetchFromCacheTemplateData builds a TemplateData from a -download-only
+// cache on disk, via -from-cache, without making any network request. It
+// runs the cached body through the same processFetchedImage pipeline a live
+// fetch would use, so -picture, -thumbnail-width, -png-colors etc. all
+// still apply offline.
+func (p *Pipeline) fetchFromCacheTemplateData() (TemplateData, error) {
+	body, cached, err := loadDownloadCache(p.Config.FromCache)
+	if err != nil {
+		return TemplateData{}, err
+	}
+
+	result := p.processFetchedImage(p.Config.FromCache, body, cached.ContentType, cached.AlbumCount)
+	if result.err != nil {
+		return TemplateData{}, result.err
+	}
+	return p.templateDataFromResult(result), nil
+}
+
+// fetchImageBase64TemplateData builds a TemplateData from -image-base64 or
+// the env var named by -image-base64-env, without making any network
+// request. It runs the decoded bytes through the same processFetchedImage
+// pipeline a live fetch would use, so -picture, -thumbnail-width,
+// -png-colors etc. all still apply.
+func (p *Pipeline) fetchImageBase64TemplateData() (TemplateData, error) {
+	encoded := p.Config.ImageBase64
+	label := "-image-base64"
+	if p.Config.ImageBase64Env != "" {
+		encoded = os.Getenv(p.Config.ImageBase64Env)
+		label = fmt.Sprintf("-image-base64-env %s", p.Config.ImageBase64Env)
+	}
+	if encoded == "" {
+		return TemplateData{}, fmt.Errorf("%s is empty", label)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return TemplateData{}, fmt.Errorf("%s: invalid base64: %w", label, err)
+	}
+
+	result := p.processFetchedImage(label, body, "", 0)
+	if result.err != nil {
+		return TemplateData{}, result.err
+	}
+	return p.templateDataFromResult(result), nil
+}
+
+// templateDataFromResult builds the TemplateData common to every offline or
+// network source, from an already-processed fetchResult.
+func (p *Pipeline) templateDataFromResult(result fetchResult) TemplateData {
+	data := TemplateData{ImageURL: result.dataURI, AlbumCount: result.albumCount, Animated: result.animated, AltText: caption(p.Config.Lang, "alt"), RunID: p.resolveRunID()}
+	if result.webpURI != "" || result.pngFallback != "" {
+		data.Picture = true
+		data.WebPImageURL = result.webpURI
+		data.PNGImageURL = result.pngFallback
+	}
+	if result.srcset != "" {
+		data.Srcset = result.srcset
+	}
+	if p.Config.CacheBust {
+		data.CacheBustHash = contentHash(result.body)
+	}
+	if p.Config.Responsive {
+		data.Responsive = true
+		data.ImageWidth, data.ImageHeight = result.width, result.height
+	}
+	return data
+}
+
+// runDownloadOnly fetches -url and writes its raw bytes and manifest to
+// -download-only, for -from-cache to pick up later without any network
+// access. The fetch is validated the same way a normal run would be
+// (decoded via encodeImageToBase64, respecting -fast-validate), so a bad
+// download is caught on the connected machine, not the offline one.
+func (p *Pipeline) runDownloadOnly() (runResult, error) {
+	runID := p.resolveRunID()
+	ctx, cancel := context.WithTimeout(withRunID(context.Background(), runID), p.budget().fetchTimeout(p.Config.Timeout))
+	defer cancel()
+
+	budget := retryBudget{ConnectRetries: p.Config.ConnectRetries, StatusRetries: p.Config.StatusRetries, JitterStrategy: p.Config.Jitter}
+	authToken, err := resolveAccessToken(p.Config.AuthTokenFile, p.Config.AuthRefreshCommand, time.Now())
+	if err != nil {
+		return runResult{}, fmt.Errorf("%w: %w", ErrAuthRefresh, err)
+	}
+	body, header, _, timing, finalURL, err := fetchImageConditional(ctx, p.Client, p.Config.URL, p.userAgents.Next(), p.Config.Accept, acceptLanguageFor(p.Config.Lang), budget, "", p.Config.Trace, p.Config.MaxBytes, authToken, nil, p.Config.Cookie, parseRedactNames(p.Config.Redact), p.logger, p.rng)
+	if err != nil {
+		return runResult{}, fmt.Errorf("%w: %w", ErrFetch, err)
+	}
+	p.checkRedirectHost(p.Config.URL, finalURL)
+
+	contentType := header.Get("Content-Type")
+	_, animated, jpegFallback, err := encodeImageToBase64(body, contentType, p.Config.ForceMime, p.Config.FastValidate)
+	if err != nil {
+		return runResult{}, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+	if animated || jpegFallback {
+		p.setWarned()
+	}
+
+	albumCount := albumCountFromResponse(header, p.Config.Rows, p.Config.Columns)
+	manifest := downloadCacheManifest{ContentType: contentType, AlbumCount: albumCount}
+	if err := saveDownloadCache(p.Config.DownloadOnly, body, manifest); err != nil {
+		return runResult{}, err
+	}
+
+	p.emit(Event{Type: EventWritten, URL: p.Config.DownloadOnly, Size: len(body)})
+	return runResult{OutputPath: p.Config.DownloadOnly, MimeType: resolveMime(body, contentType, p.Config.ForceMime), Timing: timing, RunID: runID}, nil
+}
+
+// renderOutput fetches and renders a README the same way the default
+// fetch+render path does, but to an in-memory []byte instead of a file,
+// for sinks that don't write to -out: -gist-token (runGist) and
+// -compare-with-remote (runCompareWithRemote).
+func (p *Pipeline) renderOutput() (TemplateData, []byte, error) {
+	now := time.Now()
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		return TemplateData{}, nil, err
+	}
+	data = withTimeContext(data, now, p.Config.UpdatedBadge)
+	data, err = p.withCustomData(data)
+	if err != nil {
+		return TemplateData{}, nil, err
+	}
+
+	tmpl, tmplName, err := p.resolveActiveTemplate(now)
+	if err != nil {
+		return TemplateData{}, nil, fmt.Errorf("%w: %w", ErrTemplate, err)
+	}
+
+	var buf bytes.Buffer
+	if tmplName == "" {
+		err = tmpl.Execute(&buf, data)
+	} else {
+		err = tmpl.ExecuteTemplate(&buf, tmplName, data)
+	}
+	if err != nil {
+		return TemplateData{}, nil, fmt.Errorf("%w: %w", ErrTemplate, err)
+	}
+
+	rendered := buf.Bytes()
+	if p.Config.Normalize {
+		rendered = normalizeText(rendered)
+	}
+	rendered = applyLineEnding(rendered, p.Config.LineEnding)
+
+	if p.Config.PostCommand != "" {
+		rendered, err = runPostCommand(rendered, p.Config.PostCommand)
+		if err != nil {
+			return TemplateData{}, nil, err
+		}
+	}
+
+	if p.Config.EmbedBuildInfo {
+		comment, err := buildInfoComment(redactURL(p.Config.URL, parseRedactNames(p.Config.Redact)), data.ImageHash, data.ImageFormat, data.ImageWidth, data.ImageHeight, now)
+		if err != nil {
+			return TemplateData{}, nil, err
+		}
+		rendered = append(rendered, '\n')
+		rendered = append(rendered, []byte(comment)...)
+		rendered = append(rendered, '\n')
+	}
+
+	if p.Config.ValidateMarkdown {
+		if err := validateMarkdown(rendered); err != nil {
+			return TemplateData{}, nil, err
+		}
+	}
+
+	return data, rendered, nil
+}
+
+// runGist implements -gist-token: it renders -template the same way the
+// default path does, but uploads the result to a GitHub Gist instead of
+// writing -out, creating a new gist (when -gist-id is empty) or updating the
+// one named by -gist-id. The gist's HTML URL becomes runResult.OutputPath,
+// so -output-stdout-json and the normal log message report it the way they
+// report a local path.
+func (p *Pipeline) runGist() (runResult, error) {
+	data, rendered, err := p.renderOutput()
+	if err != nil {
+		return runResult{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.Config.Timeout)
+	defer cancel()
+	url, err := uploadGist(ctx, p.Client, p.Config.GistToken, p.Config.GistID, p.Config.GistFilename, rendered)
+	if err != nil {
+		return runResult{}, fmt.Errorf("%w: %w", ErrGist, err)
+	}
+
+	p.emit(Event{Type: EventWritten, URL: url, Size: len(rendered)})
+	return runResult{OutputPath: url, MimeType: primaryMime(data), Timing: p.getLastTiming(), RunID: data.RunID}, nil
+}
+
+// fetchGridTemplateData fetches every method+period combination declared by
+// -grid-config concurrently and builds a TemplateData whose Grid holds one
+// row per period and one column per method.
+func (p *Pipeline) fetchGridTemplateData() (TemplateData, error) {
+	cfg, err := loadGridConfig(p.Config.GridConfig)
+	if err != nil {
+		return TemplateData{}, fmt.Errorf("%w: grid-config: %w", ErrFetch, err)
+	}
+
+	runID := p.resolveRunID()
+	ctx, cancel := context.WithTimeout(withRunID(context.Background(), runID), p.budget().fetchTimeout(p.Config.Timeout))
+	defer cancel()
+
+	type cell struct {
+		row, col int
+	}
+
+	urls := make([]string, 0, len(cfg.Periods)*len(cfg.Methods))
+	cells := make([]cell, 0, cap(urls))
+	labels := make([]string, 0, cap(urls))
+	for r, period := range cfg.Periods {
+		for c, method := range cfg.Methods {
+			collageURL, err := buildCollageURL(cfg.BaseURL, method, period, resolvedTextLocation(p.Config))
+			if err != nil {
+				return TemplateData{}, fmt.Errorf("%w: grid-config: %w", ErrFetch, err)
+			}
+			urls = append(urls, collageURL)
+			cells = append(cells, cell{row: r, col: c})
+			labels = append(labels, period+"/"+method)
+		}
+	}
+
+	results := make([]fetchResult, len(urls))
+	durations := make([]time.Duration, len(urls))
+	var wg sync.WaitGroup
+	for i, collageURL := range urls {
+		wg.Add(1)
+		go func(i int, collageURL string) {
+			defer wg.Done()
+			start := time.Now()
+			results[i] = p.fetchAndEncode(ctx, collageURL, nil)
+			durations[i] = time.Since(start)
+		}(i, collageURL)
+	}
+	wg.Wait()
+	printRunSummary(p.Config.RunSummary, labels, results, durations)
+
+	if err := enforceMaxTotalBytes(results, p.Config.MaxTotalBytes, p.Config.Optimize); err != nil {
+		return TemplateData{}, err
+	}
+
+	grid := make([][]string, len(cfg.Periods))
+	for r := range grid {
+		grid[r] = make([]string, len(cfg.Methods))
+	}
+
+	for i, c := range cells {
+		if results[i].err != nil {
+			if p.Config.Partial {
+				continue
+			}
+			return TemplateData{}, fmt.Errorf("grid[%d][%d]: %w", c.row, c.col, results[i].err)
+		}
+		grid[c.row][c.col] = results[i].dataURI
+	}
+
+	return TemplateData{Grid: grid, AltText: caption(p.Config.Lang, "alt"), RunID: runID}, nil
+}
+
+// albumCountFromResponse returns how many album cells the collage actually
+// populated. Sources that expose it (e.g. local Last.fm-backed generators)
+// report it via an X-Album-Count header; otherwise we approximate it as a
+// full grid, since that's all an opaque image response tells us.
+func albumCountFromResponse(header http.Header, rows, columns int) int {
+	if raw := header.Get("X-Album-Count"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil {
+			return n
+		}
+	}
+	return rows * columns
+}
+
+// fetchResult bundles everything known about a single fetched image: its
+// raw bytes and MIME (for -image-out), the encoded data URI (for templates)
+// and any error encountered along the way.
+type fetchResult struct {
+	body         []byte
+	mime         string
+	dataURI      string
+	thumbnailURI string
+	webpURI      string
+	pngFallback  string
+	srcset       string
+	albumCount   int
+	animated     bool
+	width        int
+	height       int
+	timing       fetchTiming
+	finalURL     string
+	err          error
+}
+
+// fetchAndEncode fetches url and returns it encoded as a data URI, along
+// with the raw bytes and resolved MIME type. When cache is non-nil, a
+// conditional request is made and a 304 response reuses the cached result
+// without redecoding. A WebP response whose RIFF container declares a
+// different length than it actually has (see validateWebP) is a classic
+// symptom of a connection that dropped mid-download while still returning
+// a 200 with a complete-looking body; since that looks nothing like a
+// regular decode failure, it's retried against budget.ConnectRetries just
+// like a dial/TLS failure, rather than being reported as a corrupt image.
+func (p *Pipeline) fetchAndEncode(ctx context.Context, url string, cache *imageCache) fetchResult {
+	if p.canSpool(cache) {
+		return p.fetchAndEncodeSpooled(ctx, url)
+	}
+
+	if p.Config.TimeoutPerImage > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Config.TimeoutPerImage)
+		defer cancel()
+	}
+
+	budget := retryBudget{ConnectRetries: p.Config.ConnectRetries, StatusRetries: p.Config.StatusRetries, JitterStrategy: p.Config.Jitter}
+
+	var ifNoneMatch string
+	if cache != nil && !cache.stale(p.Config.StaleAfter) {
+		ifNoneMatch, _, _ = cache.get()
+	}
+
+	authToken, err := resolveAccessToken(p.Config.AuthTokenFile, p.Config.AuthRefreshCommand, time.Now())
+	if err != nil {
+		return fetchResult{err: fmt.Errorf("%w: %w", ErrAuthRefresh, err)}
+	}
+
+	if formats, _ := parseFormatFallback(p.Config.FormatFallback); len(formats) > 0 {
+		p.emit(Event{Type: EventFetchStarted, URL: url})
+		body, header, timing, finalURL, err := fetchWithFormatFallback(ctx, p.Client, url, p.userAgents.Next(), p.Config.Accept, acceptLanguageFor(p.Config.Lang), formats, budget, p.Config.MaxBytes, authToken, p.Config.Cookie, p.Config.Trace, parseRedactNames(p.Config.Redact), p.logger, p.rng)
+		if err != nil {
+			p.emit(Event{Type: EventFetchDone, URL: url, Err: err})
+			return fetchResult{err: fmt.Errorf("%w: %w", ErrFetch, err)}
+		}
+		p.emit(Event{Type: EventFetchDone, URL: url, Size: len(body)})
+
+		contentType := header.Get("Content-Type")
+		albumCount := albumCountFromResponse(header, p.Config.Rows, p.Config.Columns)
+		result := p.processFetchedImage(url, body, contentType, albumCount)
+		result.timing = timing
+		result.finalURL = finalURL
+		p.checkRedirectHost(url, finalURL)
+		return result
+	}
+
+	for attempt := 0; ; attempt++ {
+		p.emit(Event{Type: EventFetchStarted, URL: url})
+
+		body, header, notModified, timing, finalURL, err := fetchImageConditional(ctx, p.Client, url, p.userAgents.Next(), p.Config.Accept, acceptLanguageFor(p.Config.Lang), budget, ifNoneMatch, p.Config.Trace, p.Config.MaxBytes, authToken, nil, p.Config.Cookie, parseRedactNames(p.Config.Redact), p.logger, p.rng)
+		if err != nil {
+			p.emit(Event{Type: EventFetchDone, URL: url, Err: err})
+			return fetchResult{err: fmt.Errorf("%w: %w", ErrFetch, err)}
+		}
+		p.emit(Event{Type: EventFetchDone, URL: url, Size: len(body)})
+		p.checkRedirectHost(url, finalURL)
+
+		if notModified && cache != nil {
+			return cache.hit()
+		}
+
+		contentType := header.Get("Content-Type")
+		etag := header.Get("ETag")
+		albumCount := albumCountFromResponse(header, p.Config.Rows, p.Config.Columns)
+
+		result := p.processFetchedImage(url, body, contentType, albumCount)
+		if errors.Is(result.err, errTruncatedWebP) && attempt < budget.ConnectRetries {
+			fmt.Fprintf(os.Stderr, "eagleusb: %v, retrying fetch (attempt %d/%d)\n", result.err, attempt+1, budget.ConnectRetries)
+			continue
+		}
+		result.timing = timing
+		result.finalURL = finalURL
+		if cache != nil && result.err == nil {
+			cache.store(etag, result)
+		}
+		return result
+	}
+}
+
+// canSpool reports whether -spool's disk-backed fetch path (see
+// fetchAndEncodeSpooled) can stand in for fetchAndEncode's normal
+// in-memory one. Every feature below needs the full raw image bytes in
+// memory regardless of -spool - an image processor hook, -strip-exif,
+// -optimize, -png-colors, -thumbnail-width, -responsive/-embed-buildinfo's
+// dimension probe, -srcset, -picture's format conversion, -cache-bust's
+// content hash, or writing the bytes back out via -image-out/-og-out/
+// -image-diff-threshold - so there is nothing left for -spool to skip in
+// that case, and fetchAndEncode takes its normal path instead. cache is
+// also required to be nil: conditional-request caching stores the decoded
+// result (including its body) for reuse on a future 304, which defeats
+// the point of not keeping the bytes around.
+func (p *Pipeline) canSpool(cache *imageCache) bool {
+	return p.Config.Spool &&
+		cache == nil &&
+		p.ImageProcessor == nil &&
+		!p.Config.StripEXIF &&
+		!p.Config.Optimize &&
+		p.Config.PNGColors == 0 &&
+		p.Config.ThumbnailWidth == 0 &&
+		!p.Config.Responsive &&
+		!p.Config.EmbedBuildInfo &&
+		!p.Config.Srcset &&
+		!p.Config.Picture &&
+		!p.Config.CacheBust &&
+		p.Config.ImageOut == "" &&
+		p.Config.OGOut == "" &&
+		p.Config.ImageDiffThreshold == 0
+}
+
+// fetchAndEncodeSpooled is fetchAndEncode's -spool path: the response body
+// is streamed straight to a temp file instead of being buffered in memory
+// (see doFetch's spoolFile parameter), then validated and base64-encoded
+// by reading back from that file (see spool.go). The temp file is removed
+// before returning either way, including on every error path, since a
+// failed decode or a canceled context shouldn't leave spool files behind.
+func (p *Pipeline) fetchAndEncodeSpooled(ctx context.Context, url string) fetchResult {
+	if p.Config.TimeoutPerImage > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Config.TimeoutPerImage)
+		defer cancel()
+	}
+
+	f, err := os.CreateTemp("", "eagleusb-spool-*")
+	if err != nil {
+		return fetchResult{err: fmt.Errorf("%w: spool: %w", ErrFetch, err)}
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	budget := retryBudget{ConnectRetries: p.Config.ConnectRetries, StatusRetries: p.Config.StatusRetries, JitterStrategy: p.Config.Jitter}
+	authToken, err := resolveAccessToken(p.Config.AuthTokenFile, p.Config.AuthRefreshCommand, time.Now())
+	if err != nil {
+		return fetchResult{err: fmt.Errorf("%w: %w", ErrAuthRefresh, err)}
+	}
+
+	p.emit(Event{Type: EventFetchStarted, URL: url})
+	_, header, _, timing, finalURL, err := fetchImageConditional(ctx, p.Client, url, p.userAgents.Next(), p.Config.Accept, acceptLanguageFor(p.Config.Lang), budget, "", p.Config.Trace, p.Config.MaxBytes, authToken, f, p.Config.Cookie, parseRedactNames(p.Config.Redact), p.logger, p.rng)
+	if err != nil {
+		p.emit(Event{Type: EventFetchDone, URL: url, Err: err})
+		return fetchResult{err: fmt.Errorf("%w: %w", ErrFetch, err)}
+	}
+	size, _ := f.Seek(0, io.SeekCurrent)
+	p.emit(Event{Type: EventFetchDone, URL: url, Size: int(size)})
+	p.checkRedirectHost(url, finalURL)
+
+	contentType := header.Get("Content-Type")
+	albumCount := albumCountFromResponse(header, p.Config.Rows, p.Config.Columns)
+
+	dataURI, mime, animated, jpegFallback, err := processSpooledImage(f, contentType, p.Config.ForceMime, p.Config.FastValidate)
+	if err != nil {
+		p.emit(Event{Type: EventDecodeDone, URL: url, Err: err})
+		return fetchResult{err: fmt.Errorf("%w: %w", ErrDecode, err)}
+	}
+	p.emit(Event{Type: EventDecodeDone, URL: url, Size: len(dataURI)})
+	if animated || jpegFallback {
+		p.setWarned()
+	}
+
+	return fetchResult{mime: mime, dataURI: dataURI, albumCount: albumCount, animated: animated, timing: timing, finalURL: finalURL}
+}
+
+// processFetchedImage runs the decode/transform/encode pipeline shared by
+// every image source (a live -url fetch, a -sources-config/-grid-config
+// entry, or a -from-cache replay): image processor hook, EXIF stripping,
+// palette quantization, base64 encoding, thumbnailing and <picture>
+// fallback generation. It holds decodeSem for the duration, since this is
+// the CPU-bound part fetchAndEncode's semaphore exists to bound.
+func (p *Pipeline) processFetchedImage(url string, body []byte, contentType string, albumCount int) fetchResult {
+	p.decodeSem <- struct{}{}
+	defer func() { <-p.decodeSem }()
+
+	if p.ImageProcessor != nil && resolveMime(body, contentType, p.Config.ForceMime) != "image/svg+xml" {
+		processed, processedMime, err := applyImageProcessor(body, p.ImageProcessor)
+		if err != nil {
+			return fetchResult{err: fmt.Errorf("%w: %w", ErrDecode, err)}
+		}
+		body, contentType = processed, processedMime
+	}
+
+	if p.Config.StripEXIF && resolveMime(body, contentType, p.Config.ForceMime) == "image/jpeg" {
+		stripped, err := stripJPEGEXIF(body)
+		if err != nil {
+			return fetchResult{err: fmt.Errorf("%w: strip-exif: %w", ErrDecode, err)}
+		}
+		body = stripped
+	}
+
+	if p.Config.ColorProfile == "strip" {
+		mime := resolveMime(body, contentType, p.Config.ForceMime)
+		stripped, saved, err := stripColorProfile(body, mime)
+		if err != nil {
+			return fetchResult{err: fmt.Errorf("%w: color-profile: %w", ErrDecode, err)}
+		}
+		if saved > 0 {
+			fmt.Fprintf(os.Stderr, "eagleusb: -color-profile strip removed a %d-byte ICC profile\n", saved)
+			body = stripped
+		}
+	}
+
+	if p.Config.Optimize && resolveMime(body, contentType, p.Config.ForceMime) == "image/png" {
+		if deep, err := isDeepColorPNG(body); err == nil && deep {
+			converted, err := downconvertPNGTo8Bit(body)
+			if err != nil {
+				return fetchResult{err: fmt.Errorf("%w: optimize: %w", ErrDecode, err)}
+			}
+			body, contentType = converted, "image/png"
+		}
+	}
+
+	if p.Config.PNGColors > 0 {
+		mime := resolveMime(body, contentType, p.Config.ForceMime)
+		if mime == "image/svg+xml" {
+			// Not a raster format; nothing to quantize.
+		} else if !canDecodeToPixels(mime) {
+			return fetchResult{err: fmt.Errorf("%w: -png-colors requires pixel data, but this build has no decoder for %s (container-validation only, see capabilities.go); use -accept to request a PNG/JPEG/GIF source instead", ErrDecode, mime)}
+		} else {
+			quantized, err := quantizeToPalette(body, p.Config.PNGColors)
+			if err != nil {
+				return fetchResult{err: fmt.Errorf("%w: %w", ErrDecode, err)}
+			}
+			body, contentType = quantized, "image/png"
+		}
+	}
+
+	if p.Config.TargetBytes > 0 {
+		mime := resolveMime(body, contentType, p.Config.ForceMime)
+		if !canDecodeToPixels(mime) {
+			return fetchResult{err: fmt.Errorf("%w: -target-bytes requires pixel data, but this build has no decoder for %s (container-validation only, see capabilities.go); use -accept to request a PNG/JPEG/GIF source instead", ErrDecode, mime)}
+		}
+		encoded, _, err := encodeToTargetBytes(body, p.Config.TargetBytes)
+		if err != nil {
+			return fetchResult{err: err}
+		}
+		body, contentType = encoded, "image/jpeg"
+	}
+
+	mime := resolveMime(body, contentType, p.Config.ForceMime)
+	dataURI, animated, jpegFallback, err := encodeImageToBase64(body, contentType, p.Config.ForceMime, p.Config.FastValidate)
+	if err != nil {
+		p.emit(Event{Type: EventDecodeDone, URL: url, Err: err})
+		return fetchResult{err: fmt.Errorf("%w: %w", ErrDecode, err)}
+	}
+	p.emit(Event{Type: EventDecodeDone, URL: url, Size: len(dataURI)})
+	if animated || jpegFallback {
+		p.setWarned()
+	}
+
+	result := fetchResult{body: body, mime: mime, dataURI: dataURI, albumCount: albumCount, animated: animated}
+
+	if (p.Config.Responsive || p.Config.EmbedBuildInfo) && canDecodeToPixels(mime) {
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(body)); err == nil {
+			result.width, result.height = cfg.Width, cfg.Height
+		}
+	}
+
+	if p.Config.ThumbnailWidth > 0 && canDecodeToPixels(mime) {
+		thumbnailURI, err := makeThumbnail(body, p.Config.ThumbnailWidth)
+		if err != nil {
+			return fetchResult{err: fmt.Errorf("%w: thumbnail: %w", ErrDecode, err)}
+		}
+		result.thumbnailURI = thumbnailURI
+	}
+
+	if p.Config.Picture {
+		switch mime {
+		case "image/webp":
+			result.webpURI = dataURI
+		case "image/png":
+			result.pngFallback = dataURI
+		case "image/jpeg", "image/gif":
+			pngURI, err := pngFallback(body)
+			if err != nil {
+				return fetchResult{err: fmt.Errorf("%w: picture fallback: %w", ErrDecode, err)}
+			}
+			result.pngFallback = pngURI
+		}
+	}
+
+	if p.Config.Srcset && canDecodeToPixels(mime) {
+		oneXURI, twoXURI, err := makeSrcsetVariants(body)
+		if err != nil {
+			return fetchResult{err: fmt.Errorf("%w: srcset: %w", ErrDecode, err)}
+		}
+		result.srcset = fmt.Sprintf("%s 1x, %s 2x", oneXURI, twoXURI)
+	}
+
+	return result
+}
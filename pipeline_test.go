@@ -0,0 +1,235 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func tinyPNGServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	// A 1x1 transparent PNG.
+	png := []byte{
+		0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+		0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+		0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4, 0x89, 0x00, 0x00, 0x00,
+		0x0a, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0x00, 0x01, 0x00, 0x00,
+		0x05, 0x00, 0x01, 0x0d, 0x0a, 0x2d, 0xb4, 0x00, 0x00, 0x00, 0x00, 0x49,
+		0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+}
+
+func TestPipelineRun_CompareURL(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:        srv.URL,
+		CompareURL: srv.URL,
+		Out:        out,
+		Timeout:    5 * time.Second,
+		UserAgent:  defaultUserAgent,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	if len(contents) == 0 {
+		t.Fatal("expected non-empty output")
+	}
+}
+
+func TestPipelineRun_PictureEmbedsPictureElement(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Picture:   true,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), "<picture>") {
+		t.Fatalf("output = %q, want a <picture> element", contents)
+	}
+}
+
+func TestPipelineRun_EmitsLifecycleEvents(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	var events []EventType
+	var mu sync.Mutex
+	p.OnEvent = func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e.Type)
+	}
+
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := map[EventType]bool{EventFetchStarted: false, EventFetchDone: false, EventDecodeDone: false, EventWritten: false}
+	for _, e := range events {
+		want[e] = true
+	}
+	for eventType, seen := range want {
+		if !seen {
+			t.Fatalf("expected %q event, got events %v", eventType, events)
+		}
+	}
+}
+
+func TestPipelineRun_SourcesConfigGrid(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	tmpDir := t.TempDir()
+	sourcesPath := filepath.Join(tmpDir, "sources.json")
+	sourcesJSON := `[{"name":"albums","url":"` + srv.URL + `"},{"name":"artists","url":"` + srv.URL + `"}]`
+	if err := os.WriteFile(sourcesPath, []byte(sourcesJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	templatePath := filepath.Join(tmpDir, "grid.tmpl")
+	if err := os.WriteFile(templatePath, []byte("albums={{.Images.albums}} artists={{.Images.artists}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(tmpDir, "README.md")
+	cfg := Config{
+		SourcesConfig: sourcesPath,
+		Template:      templatePath,
+		Out:           out,
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), "albums=data:image/png") || !strings.Contains(string(contents), "artists=data:image/png") {
+		t.Fatalf("output = %q, want both sources rendered", contents)
+	}
+}
+
+func TestPipelineRun_BatchTemplatesIntoOutputDir(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	tmpDir := t.TempDir()
+	darkTmpl := filepath.Join(tmpDir, "dark.md.tmpl")
+	lightTmpl := filepath.Join(tmpDir, "light.md.tmpl")
+	if err := os.WriteFile(darkTmpl, []byte("dark: {{.ImageURL}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lightTmpl, []byte("light: {{.ImageURL}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := filepath.Join(tmpDir, "out")
+	cfg := Config{
+		URL:       srv.URL,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		OutputDir: outDir,
+		Templates: []string{darkTmpl, lightTmpl},
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	for _, name := range []string{"dark.md", "light.md"} {
+		if _, err := os.Stat(filepath.Join(outDir, name)); err != nil {
+			t.Fatalf("expected %s to be rendered: %v", name, err)
+		}
+	}
+}
+
+func TestPipelineRun_DecodeConcurrencyOfOneStillCompletes(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:               srv.URL,
+		CompareURL:        srv.URL,
+		Out:               out,
+		Timeout:           5 * time.Second,
+		UserAgent:         defaultUserAgent,
+		DecodeConcurrency: 1,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if cap(p.decodeSem) != 1 {
+		t.Fatalf("decodeSem capacity = %d, want 1", cap(p.decodeSem))
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
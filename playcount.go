@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// playcountBelowThreshold implements -min-playcount: it HEADs url and checks
+// an X-Total-Playcount response header against min, the same generic
+// response-header convention X-Album-Count (see albumCountFromResponse) and
+// -since's Last-Modified check (see sourceHasNoRecentUpdate in since.go) use
+// for a Last.fm concept this tree otherwise has no API client to query
+// directly - it talks to whatever collage image URL it's given, not a
+// specific Last.fm/songstitch endpoint. A source that doesn't send the
+// header is never gated: the threshold only ever applies when the source
+// actually reports a number.
+func (p *Pipeline) playcountBelowThreshold(ctx context.Context, url string, min int) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("User-Agent", p.userAgents.Next())
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	raw := resp.Header.Get("X-Total-Playcount")
+	if raw == "" {
+		return false, nil
+	}
+	playcount, err := strconv.Atoi(raw)
+	if err != nil {
+		return false, nil
+	}
+	return playcount < min, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func playcountServer(t *testing.T, playcount int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Total-Playcount", strconv.Itoa(playcount))
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(makePNG(1, 1))
+		}
+	}))
+}
+
+func TestPipelineRun_MinPlaycountRendersPlaceholderBelowThreshold(t *testing.T) {
+	srv := playcountServer(t, 0)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:          srv.URL,
+		Out:          out,
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+		MinPlaycount: 10,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	rendered, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(rendered) != defaultPlaceholderTemplate {
+		t.Errorf("rendered = %q, want the built-in placeholder template %q", rendered, defaultPlaceholderTemplate)
+	}
+}
+
+func TestPipelineRun_MinPlaycountUsesCustomPlaceholderTemplate(t *testing.T) {
+	srv := playcountServer(t, 0)
+	defer srv.Close()
+
+	placeholderPath := filepath.Join(t.TempDir(), "placeholder.md")
+	if err := os.WriteFile(placeholderPath, []byte("start scrobbling!"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:                 srv.URL,
+		Out:                 out,
+		Timeout:             5 * time.Second,
+		UserAgent:           defaultUserAgent,
+		MinPlaycount:        10,
+		PlaceholderTemplate: placeholderPath,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	rendered, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(rendered) != "start scrobbling!" {
+		t.Errorf("rendered = %q, want the custom placeholder template's contents", rendered)
+	}
+}
+
+func TestPipelineRun_MinPlaycountEmbedsCollageAtOrAboveThreshold(t *testing.T) {
+	srv := playcountServer(t, 25)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:          srv.URL,
+		Out:          out,
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+		MinPlaycount: 10,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	rendered, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(rendered) == defaultPlaceholderTemplate {
+		t.Error("expected the normal collage template to render, got the placeholder")
+	}
+}
+
+func TestPipelineRun_MinPlaycountIgnoredWithoutHeader(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:          srv.URL,
+		Out:          out,
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+		MinPlaycount: 10,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	rendered, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(rendered) == defaultPlaceholderTemplate {
+		t.Error("expected a source without an X-Total-Playcount header to never be gated")
+	}
+}
+
+func TestParseFlags_MinPlaycountRejectsNegative(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "https://example.com", "-min-playcount", "-1"}); err == nil {
+		t.Fatal("expected an error for a negative -min-playcount")
+	}
+}
+
+func TestParseFlags_MinPlaycountDefaultsToZero(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if cfg.MinPlaycount != 0 {
+		t.Errorf("MinPlaycount = %d, want 0", cfg.MinPlaycount)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// runPostCommand pipes rendered into command's stdin via "sh -c" and returns
+// its stdout as the replacement content, for -post-command. This lets users
+// pipe the generated markdown through an existing formatter (e.g. prettier)
+// without eagleusb building formatting in. A non-zero exit is reported with
+// the command's stderr, wrapped in ErrPostCommand.
+func runPostCommand(rendered []byte, command string) ([]byte, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(rendered)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q: %w", ErrPostCommand, command, err)
+		}
+		return nil, fmt.Errorf("%w: %q exited %d: %s", ErrPostCommand, command, exitErr.ExitCode(), stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
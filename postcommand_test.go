@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunPostCommand_ReplacesContentWithStdout(t *testing.T) {
+	out, err := runPostCommand([]byte("hello"), "tr a-z A-Z")
+	if err != nil {
+		t.Fatalf("runPostCommand() error = %v", err)
+	}
+	if string(out) != "HELLO" {
+		t.Errorf("output = %q, want %q", out, "HELLO")
+	}
+}
+
+func TestRunPostCommand_ReportsExitCodeAndStderr(t *testing.T) {
+	_, err := runPostCommand([]byte("hello"), "echo formatting failed >&2; exit 3")
+	if err == nil {
+		t.Fatal("expected an error for a non-zero exit")
+	}
+	if !errors.Is(err, ErrPostCommand) {
+		t.Errorf("error = %v, want it to wrap ErrPostCommand", err)
+	}
+	if !strings.Contains(err.Error(), "exited 3") || !strings.Contains(err.Error(), "formatting failed") {
+		t.Errorf("error = %v, want it to include the exit code and stderr", err)
+	}
+}
+
+func TestPipelineRun_PostCommandTransformsOutput(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:         srv.URL,
+		Out:         out,
+		Timeout:     5 * time.Second,
+		UserAgent:   defaultUserAgent,
+		PostCommand: "tr a-z A-Z",
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	rendered, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(string(rendered), "abcdefghijklmnopqrstuvwxyz") {
+		t.Errorf("expected -post-command to uppercase every lowercase letter, got %q", rendered)
+	}
+}
+
+func TestPipelineRun_PostCommandFailureAbortsRun(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:         srv.URL,
+		Out:         out,
+		Timeout:     5 * time.Second,
+		UserAgent:   defaultUserAgent,
+		PostCommand: "exit 1",
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail when -post-command exits non-zero")
+	}
+}
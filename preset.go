@@ -0,0 +1,27 @@
+package main
+
+import "fmt"
+
+// presetPNGColors maps each -preset name to the -png-colors value it sets,
+// the only lossy compression knob this tree actually has: there's no WebP
+// encoder here (see webp.go), so "webp q60"-style presets aren't
+// implementable, and PNG quantization is the honest stand-in. "quality"
+// maps to 0 (full, lossless color), since that's -png-colors's own meaning
+// for "don't quantize".
+var presetPNGColors = map[string]int{
+	"small":    32,
+	"balanced": 128,
+	"quality":  0,
+}
+
+// validatePreset rejects anything other than a key of presetPNGColors, empty
+// meaning "no preset".
+func validatePreset(preset string) error {
+	if preset == "" {
+		return nil
+	}
+	if _, ok := presetPNGColors[preset]; !ok {
+		return fmt.Errorf("-preset must be one of small, balanced, quality, got %q", preset)
+	}
+	return nil
+}
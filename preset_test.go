@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestValidatePreset(t *testing.T) {
+	for _, preset := range []string{"", "small", "balanced", "quality"} {
+		if err := validatePreset(preset); err != nil {
+			t.Errorf("validatePreset(%q) error = %v, want nil", preset, err)
+		}
+	}
+	if err := validatePreset("bogus"); err == nil {
+		t.Error("validatePreset(\"bogus\") error = nil, want an error")
+	}
+}
+
+func TestParseFlags_PresetSetsPNGColors(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com/collage.png", "-preset", "small"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if cfg.PNGColors != 32 {
+		t.Fatalf("PNGColors = %d, want 32", cfg.PNGColors)
+	}
+}
+
+func TestParseFlags_ExplicitPNGColorsOverridesPreset(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com/collage.png", "-preset", "small", "-png-colors", "16"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if cfg.PNGColors != 16 {
+		t.Fatalf("PNGColors = %d, want 16 (explicit flag should win)", cfg.PNGColors)
+	}
+}
+
+func TestParseFlags_RejectsUnknownPreset(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "https://example.com/collage.png", "-preset", "bogus"}); err == nil {
+		t.Fatal("expected an error for an unknown -preset value")
+	}
+}
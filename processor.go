@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+// ImageProcessor transforms a decoded image before it is re-encoded and
+// embedded, e.g. to add a watermark, border or rounded corners. Setting
+// Pipeline.ImageProcessor runs it between decode and encode; leaving it nil
+// (the default) keeps the original bytes untouched with no re-encode.
+type ImageProcessor func(image.Image) (image.Image, error)
+
+// BorderProcessor returns an ImageProcessor that draws a solid border of
+// width pixels around the image in the given color. It's provided as a
+// worked example of the ImageProcessor hook.
+func BorderProcessor(width int, c color.Color) ImageProcessor {
+	return func(src image.Image) (image.Image, error) {
+		b := src.Bounds()
+		dst := image.NewRGBA(b)
+		draw.Draw(dst, b, src, b.Min, draw.Src)
+
+		border := image.NewUniform(c)
+		draw.Draw(dst, image.Rect(b.Min.X, b.Min.Y, b.Max.X, b.Min.Y+width), border, image.Point{}, draw.Src)
+		draw.Draw(dst, image.Rect(b.Min.X, b.Max.Y-width, b.Max.X, b.Max.Y), border, image.Point{}, draw.Src)
+		draw.Draw(dst, image.Rect(b.Min.X, b.Min.Y, b.Min.X+width, b.Max.Y), border, image.Point{}, draw.Src)
+		draw.Draw(dst, image.Rect(b.Max.X-width, b.Min.Y, b.Max.X, b.Max.Y), border, image.Point{}, draw.Src)
+
+		return dst, nil
+	}
+}
+
+// applyImageProcessor decodes data, runs proc over it and re-encodes the
+// result as PNG, returning the new bytes and the "image/png" mime. It is
+// only called when an ImageProcessor is configured - the pass-through path
+// never decodes or re-encodes.
+func applyImageProcessor(data []byte, proc ImageProcessor) ([]byte, string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding image for processing: %w", err)
+	}
+
+	processed, err := proc(img)
+	if err != nil {
+		return nil, "", fmt.Errorf("processing image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, processed); err != nil {
+		return nil, "", fmt.Errorf("re-encoding processed image: %w", err)
+	}
+
+	return buf.Bytes(), "image/png", nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBorderProcessor(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	proc := BorderProcessor(2, color.Black)
+
+	out, err := proc(src)
+	if err != nil {
+		t.Fatalf("BorderProcessor() error = %v", err)
+	}
+
+	if got := out.At(0, 0); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Fatalf("corner pixel = %v, want opaque black", got)
+	}
+}
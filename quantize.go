@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"os"
+)
+
+// quantizeToPalette decodes data as a raster image, maps it onto an
+// n-color palette with Floyd-Steinberg dithering, and re-encodes it as a
+// paletted PNG. n is clamped to [2, 256]. A warning is printed when the
+// source has many more distinct colors than n, since the result will show
+// visible banding.
+func quantizeToPalette(data []byte, n int) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image for quantization: %w", err)
+	}
+
+	if distinct := countDistinctColors(img); distinct > n*4 {
+		fmt.Fprintf(os.Stderr, "eagleusb: quantizing ~%d colors down to %d will noticeably degrade this image\n", distinct, n)
+	}
+
+	pal := buildPalette(n)
+	bounds := img.Bounds()
+	paletted := image.NewPaletted(bounds, pal)
+	draw.FloydSteinberg.Draw(paletted, bounds, img, bounds.Min)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, paletted); err != nil {
+		return nil, fmt.Errorf("encoding paletted PNG: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// buildPalette returns an n-entry subset of the standard Plan9 palette.
+// This is an approximation, not a true median-cut quantizer, but it's
+// dependency-free and good enough for shrinking data URI size.
+func buildPalette(n int) color.Palette {
+	if n < 2 {
+		n = 2
+	}
+	full := palette.Plan9
+	if n >= len(full) {
+		return full
+	}
+
+	p := make(color.Palette, n)
+	step := float64(len(full)) / float64(n)
+	for i := range p {
+		p[i] = full[int(float64(i)*step)]
+	}
+	return p
+}
+
+// countDistinctColors samples every pixel of img and returns how many
+// distinct colors it contains.
+func countDistinctColors(img image.Image) int {
+	seen := make(map[color.Color]struct{})
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			seen[img.At(x, y)] = struct{}{}
+		}
+	}
+	return len(seen)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestQuantizeToPalette(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			src.Set(x, y, color.RGBA{uint8(x * 60), uint8(y * 60), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	out, err := quantizeToPalette(buf.Bytes(), 4)
+	if err != nil {
+		t.Fatalf("quantizeToPalette() error = %v", err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decoding quantized output: %v", err)
+	}
+	paletted, ok := img.(*image.Paletted)
+	if !ok {
+		t.Fatalf("output is %T, want *image.Paletted", img)
+	}
+	if len(paletted.Palette) != 4 {
+		t.Fatalf("palette length = %d, want 4", len(paletted.Palette))
+	}
+}
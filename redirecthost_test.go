@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckRedirectHost_WarnsWhenHostsDiffer(t *testing.T) {
+	p := &Pipeline{Config: Config{WarnOnRedirectToDifferentHost: true}}
+	p.checkRedirectHost("https://a.example.com/x", "https://b.example.com/x")
+
+	if !p.isWarned() {
+		t.Error("isWarned() = false, want true when the final host differs from the original")
+	}
+	if from, to := p.redirectHosts(); from != "a.example.com" || to != "b.example.com" {
+		t.Errorf("redirectHosts() = %q/%q, want a.example.com/b.example.com", from, to)
+	}
+}
+
+func TestCheckRedirectHost_NoWarnWhenHostsMatch(t *testing.T) {
+	p := &Pipeline{Config: Config{WarnOnRedirectToDifferentHost: true}}
+	p.checkRedirectHost("https://a.example.com/x", "https://a.example.com/y")
+
+	if p.isWarned() {
+		t.Error("isWarned() = true, want false when the final host matches the original")
+	}
+	if from, to := p.redirectHosts(); from != "" || to != "" {
+		t.Errorf("redirectHosts() = %q/%q, want both empty", from, to)
+	}
+}
+
+func TestCheckRedirectHost_NoWarnWhenFlagOff(t *testing.T) {
+	p := &Pipeline{}
+	p.checkRedirectHost("https://a.example.com/x", "https://b.example.com/x")
+
+	if p.isWarned() {
+		t.Error("isWarned() = true, want false when -warn-on-redirect-to-different-host isn't set")
+	}
+}
+
+func TestCheckRedirectHost_NoWarnOnEmptyFinalURL(t *testing.T) {
+	p := &Pipeline{Config: Config{WarnOnRedirectToDifferentHost: true}}
+	p.checkRedirectHost("https://a.example.com/x", "")
+
+	if p.isWarned() {
+		t.Error("isWarned() = true, want false when finalURL is empty (e.g. the fetch never completed)")
+	}
+}
+
+func TestPipelineRun_WarnOnRedirectToDifferentHost(t *testing.T) {
+	var target string
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target, http.StatusFound)
+	}))
+	defer origin.Close()
+
+	final := tinyPNGServer(t)
+	defer final.Close()
+	target = final.URL
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:                           origin.URL,
+		Out:                           out,
+		Timeout:                       5 * time.Second,
+		UserAgent:                     defaultUserAgent,
+		WarnOnRedirectToDifferentHost: true,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want nil since -fail-on-warning wasn't set", err)
+	}
+
+	m, err := loadManifest(manifestPath(out))
+	if err != nil {
+		t.Fatalf("loadManifest() error = %v", err)
+	}
+	if m.RedirectedFromHost == "" || m.RedirectedToHost == "" {
+		t.Fatalf("manifest RedirectedFromHost/RedirectedToHost = %q/%q, want both populated", m.RedirectedFromHost, m.RedirectedToHost)
+	}
+
+	cfg.FailOnWarning = true
+	p2, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p2.Run(); err == nil {
+		t.Fatal("expected Run() to fail: -fail-on-warning set and the response came from a different host")
+	}
+}
+
+func TestPipelineRun_NoWarnWhenNoRedirect(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:                           srv.URL,
+		Out:                           out,
+		Timeout:                       5 * time.Second,
+		UserAgent:                     defaultUserAgent,
+		WarnOnRedirectToDifferentHost: true,
+		FailOnWarning:                 true,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want nil since the response never redirected", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// replaceTokenInFile replaces every occurrence of token in the file at path
+// with replacement and writes the result back atomically (via a temp file
+// and rename, so a crash mid-write can't leave path truncated). It errors
+// if token doesn't appear in the file at all, since a silent no-op usually
+// means the token was mistyped.
+func replaceTokenInFile(path, token, replacement string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	if !bytes.Contains(data, []byte(token)) {
+		return fmt.Errorf("%w: token %q not found in %s", ErrWrite, token, path)
+	}
+
+	replaced := bytes.ReplaceAll(data, []byte(token), []byte(replacement))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(replaced); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode()); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	return nil
+}
+
+// runReplaceToken implements -target-file/-replace-token: it fetches the
+// collage the same way a template render would, then substitutes the data
+// URI for every occurrence of -replace-token in -target-file in place. This
+// is the escape hatch for embedding into something that isn't a generated
+// README, e.g. a hand-maintained HTML profile page.
+func (p *Pipeline) runReplaceToken() (runResult, error) {
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		return runResult{}, err
+	}
+
+	if err := replaceTokenInFile(p.Config.TargetFile, p.Config.ReplaceToken, data.ImageURL); err != nil {
+		return runResult{}, err
+	}
+
+	p.emit(Event{Type: EventWritten, URL: p.Config.TargetFile, Size: len(data.ImageURL)})
+	return runResult{OutputPath: p.Config.TargetFile, MimeType: primaryMime(data), RunID: data.RunID}, nil
+}
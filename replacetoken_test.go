@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplaceTokenInFile_ReplacesAllOccurrences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.html")
+	original := `<img src="__TOKEN__"><img src="__TOKEN__">`
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replaceTokenInFile(path, "__TOKEN__", "data:image/png;base64,Zm9v"); err != nil {
+		t.Fatalf("replaceTokenInFile() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<img src="data:image/png;base64,Zm9v"><img src="data:image/png;base64,Zm9v">`
+	if string(contents) != want {
+		t.Fatalf("contents = %q, want %q", contents, want)
+	}
+}
+
+func TestReplaceTokenInFile_ErrorsWhenTokenMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.html")
+	if err := os.WriteFile(path, []byte("no token here"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replaceTokenInFile(path, "__TOKEN__", "x"); err == nil {
+		t.Fatal("expected an error when the token isn't found")
+	}
+}
+
+func TestPipelineRun_ReplaceTokenMode(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "profile.html")
+	if err := os.WriteFile(target, []byte(`<img src="COLLAGE_TOKEN">`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		URL:          srv.URL,
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+		TargetFile:   target,
+		ReplaceToken: "COLLAGE_TOKEN",
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(contents), "COLLAGE_TOKEN") {
+		t.Fatal("expected the token to be replaced")
+	}
+	if !strings.Contains(string(contents), "data:image/png") {
+		t.Fatalf("contents = %q, want a data URI in place of the token", contents)
+	}
+}
+
+func TestParseFlags_ReplaceTokenRequiresTargetFileToo(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "http://example.com", "-target-file", "out.html"}); err == nil {
+		t.Fatal("expected an error when -target-file is set without -replace-token")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sizedPNGServer(t *testing.T, w, h int) *httptest.Server {
+	t.Helper()
+	body := makePNG(w, h)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(body)
+	}))
+}
+
+func TestPipelineRun_ResponsiveEmitsStyleAndIntrinsicDimensions(t *testing.T) {
+	srv := sizedPNGServer(t, 40, 20)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:        srv.URL,
+		Out:        out,
+		Timeout:    5 * time.Second,
+		UserAgent:  defaultUserAgent,
+		Responsive: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, rendered, err := p.renderOutput()
+	if err != nil {
+		t.Fatalf("renderOutput() error = %v", err)
+	}
+	if data.ImageWidth != 40 || data.ImageHeight != 20 {
+		t.Errorf("ImageWidth/Height = %d/%d, want 40/20", data.ImageWidth, data.ImageHeight)
+	}
+
+	got := string(rendered)
+	if !strings.Contains(got, `style="max-width:100%;height:auto"`) {
+		t.Errorf("rendered = %q, want the responsive style attribute", got)
+	}
+	if !strings.Contains(got, `width="40" height="20"`) {
+		t.Errorf("rendered = %q, want intrinsic width/height attributes", got)
+	}
+}
+
+func TestPipelineRun_NoResponsiveUsesPlainMarkdownImage(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	_, rendered, err := p.renderOutput()
+	if err != nil {
+		t.Fatalf("renderOutput() error = %v", err)
+	}
+	if strings.Contains(string(rendered), "<img") {
+		t.Errorf("rendered = %q, want plain markdown image syntax without -responsive", rendered)
+	}
+}
+
+func TestPipelineRun_ResponsiveWithUndecodableFormatOmitsDimensions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/webp")
+		w.Write(makeWebP())
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:        srv.URL,
+		Out:        filepath.Join(t.TempDir(), "README.md"),
+		Timeout:    5 * time.Second,
+		UserAgent:  defaultUserAgent,
+		Responsive: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, rendered, err := p.renderOutput()
+	if err != nil {
+		t.Fatalf("renderOutput() error = %v", err)
+	}
+	if data.ImageWidth != 0 || data.ImageHeight != 0 {
+		t.Errorf("ImageWidth/Height = %d/%d, want 0/0 for a format this build can't decode to pixels", data.ImageWidth, data.ImageHeight)
+	}
+	if strings.Contains(string(rendered), `width="`) {
+		t.Errorf("rendered = %q, want no width/height attributes when dimensions are unknown", rendered)
+	}
+	if !strings.Contains(string(rendered), `style="max-width:100%;height:auto"`) {
+		t.Errorf("rendered = %q, want the responsive style attribute even without known dimensions", rendered)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// seededRand is a mutex-guarded *rand.Rand: concurrent fetches (see
+// -stack/-grid-config/-sources-config, which fan out goroutines in
+// pipeline.go) can call into the same Pipeline's jitter logic at once, and
+// math/rand.Rand itself isn't safe for concurrent use.
+type seededRand struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// newSeededRand builds the seededRand backing every randomized decision in
+// a run (-jitter, -watch-jitter) from -seed. A nonzero seed makes those
+// decisions reproducible, for tests and debugging. seed == 0, -seed's
+// default, instead draws from crypto/rand (falling back to the current
+// time on the read failing), so an unseeded run stays as non-deterministic
+// as the global math/rand it replaces.
+func newSeededRand(seed int64) *seededRand {
+	if seed == 0 {
+		seed = randomSeed()
+	}
+	return &seededRand{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// randomSeed returns 8 bytes of crypto/rand as an int64 seed.
+func randomSeed() int64 {
+	var b [8]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+// Int63n and Float64 mirror math/rand.Rand's methods of the same name,
+// guarded by a mutex for callers racing across goroutines.
+func (r *seededRand) Int63n(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Int63n(n)
+}
+
+func (r *seededRand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Float64()
+}
@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func TestNewSeededRand_SameSeedReproducesSequence(t *testing.T) {
+	a := newSeededRand(42)
+	b := newSeededRand(42)
+	for i := 0; i < 20; i++ {
+		if got, want := a.Int63n(1_000_000), b.Int63n(1_000_000); got != want {
+			t.Fatalf("draw %d: %d != %d for the same seed", i, got, want)
+		}
+	}
+}
+
+func TestNewSeededRand_DifferentSeedsDiverge(t *testing.T) {
+	a := newSeededRand(1)
+	b := newSeededRand(2)
+
+	same := true
+	for i := 0; i < 20; i++ {
+		if a.Int63n(1_000_000) != b.Int63n(1_000_000) {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Fatal("expected different seeds to diverge within 20 draws")
+	}
+}
+
+func TestNewSeededRand_ZeroSeedIsNonDeterministic(t *testing.T) {
+	a := newSeededRand(0)
+	b := newSeededRand(0)
+
+	same := true
+	for i := 0; i < 20; i++ {
+		if a.Int63n(1_000_000) != b.Int63n(1_000_000) {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Fatal("expected -seed 0 to draw from a fresh, non-reproducible source each time")
+	}
+}
+
+func TestParseFlags_SeedDefaultsToZero(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if cfg.Seed != 0 {
+		t.Errorf("Seed = %d, want 0", cfg.Seed)
+	}
+}
+
+func TestParseFlags_SeedIsSettable(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com", "-seed", "42"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if cfg.Seed != 42 {
+		t.Errorf("Seed = %d, want 42", cfg.Seed)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "net/http"
+
+// RoundTripperMiddleware wraps an http.RoundTripper to layer behavior -
+// metrics, auth injection, custom logging, rate-limiting - around every
+// request the Pipeline's Client makes, without forking the package. This is
+// separate from the fetch loop's own built-in retry/backoff (retryBudget)
+// and -auth-token/-cookie handling, which run inside fetchImageConditional
+// regardless of Transports; Transports is for behavior a library caller
+// wants layered on top of that, not a replacement for it.
+type RoundTripperMiddleware func(http.RoundTripper) http.RoundTripper
+
+// applyTransports wraps p.Client.Transport with each middleware in
+// p.Transports. It runs at most once (see compiledTemplate for the same
+// sync.Once pattern guarding another deferred-until-first-use library
+// hook), so a concurrent Run racing the first one can't wrap it twice.
+// Transports[0] is the outermost layer - the first to see the outgoing
+// request and the last to see the incoming response - and
+// Transports[len(Transports)-1] sits closest to the real network round
+// trip, the same inside-out order net/http middleware chains conventionally
+// use. It is a no-op when Transports is empty, leaving Client.Transport
+// (nil, meaning http.DefaultTransport) untouched.
+func (p *Pipeline) applyTransports() {
+	p.transportOnce.Do(func() {
+		if len(p.Transports) == 0 {
+			return
+		}
+		rt := p.Client.Transport
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+		for i := len(p.Transports) - 1; i >= 0; i-- {
+			rt = p.Transports[i](rt)
+		}
+		p.Client.Transport = rt
+	})
+}
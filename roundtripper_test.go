@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingMiddleware returns a RoundTripperMiddleware that increments n for
+// every request it sees, and a RoundTripperMiddleware that records the
+// order calls arrive in, for asserting Transports' wrapping order.
+func recordingMiddleware(order *[]string, name string) RoundTripperMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			*order = append(*order, name)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestPipelineRun_TransportsWrapInOutermostFirstOrder(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	var order []string
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	p.Transports = []RoundTripperMiddleware{
+		recordingMiddleware(&order, "outer"),
+		recordingMiddleware(&order, "inner"),
+	}
+
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("call order = %v, want [outer inner]", order)
+	}
+}
+
+func TestApplyTransports_NoopWhenEmpty(t *testing.T) {
+	p := &Pipeline{Client: &http.Client{}}
+	p.applyTransports()
+	if p.Client.Transport != nil {
+		t.Errorf("Client.Transport = %v, want nil (unchanged) when Transports is empty", p.Client.Transport)
+	}
+}
+
+func TestApplyTransports_RunsOnlyOnce(t *testing.T) {
+	calls := 0
+	p := &Pipeline{Client: &http.Client{}}
+	p.Transports = []RoundTripperMiddleware{
+		func(next http.RoundTripper) http.RoundTripper {
+			calls++
+			return next
+		},
+	}
+	p.applyTransports()
+	p.applyTransports()
+	if calls != 1 {
+		t.Errorf("middleware invoked %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// newRunID generates a short random identifier for correlating one run's
+// (or, for -serve, one request's) log lines and run-report JSON, when
+// neither -run-id nor an incoming request header supplies one explicitly.
+func newRunID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// runIDContextKey is an unexported type so withRunID's context key can't
+// collide with one set by another package.
+type runIDContextKey struct{}
+
+// withRunID attaches runID to ctx, so it's available wherever ctx is
+// threaded - in particular to logRetry/logTiming inside
+// fetchImageConditional, which read it back out via runIDFromContext to tag
+// every structured log line for the fetch. This is the one place in this
+// tree a value (rather than just a cancellation signal) rides on a
+// context.Context; everywhere else, values are threaded as ordinary
+// parameters.
+func withRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDContextKey{}, runID)
+}
+
+// runIDFromContext returns the run ID attached by withRunID, or "" if none
+// was attached.
+func runIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(runIDContextKey{}).(string)
+	return id
+}
+
+// resolveRunID returns -run-id if set, otherwise a freshly generated one -
+// the run ID used to correlate this run's log lines and run-report JSON.
+func (p *Pipeline) resolveRunID() string {
+	if p.Config.RunID != "" {
+		return p.Config.RunID
+	}
+	return newRunID()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewRunID_NonEmptyAndUnique(t *testing.T) {
+	a := newRunID()
+	b := newRunID()
+	if a == "" || b == "" {
+		t.Fatal("newRunID() returned an empty string")
+	}
+	if a == b {
+		t.Errorf("newRunID() returned the same ID twice: %q", a)
+	}
+}
+
+func TestRunIDFromContext_RoundTripsAndDefaultsEmpty(t *testing.T) {
+	if got := runIDFromContext(context.Background()); got != "" {
+		t.Errorf("runIDFromContext() on a bare context = %q, want empty", got)
+	}
+
+	ctx := withRunID(context.Background(), "abc123")
+	if got := runIDFromContext(ctx); got != "abc123" {
+		t.Errorf("runIDFromContext() = %q, want %q", got, "abc123")
+	}
+}
+
+func TestPipeline_ResolveRunID_PrefersConfigOverGenerated(t *testing.T) {
+	p := &Pipeline{Config: Config{RunID: "explicit-id"}}
+	if got := p.resolveRunID(); got != "explicit-id" {
+		t.Errorf("resolveRunID() = %q, want the configured -run-id", got)
+	}
+
+	p = &Pipeline{}
+	if got := p.resolveRunID(); got == "" {
+		t.Error("resolveRunID() with no -run-id set returned an empty string, want a generated one")
+	}
+}
+
+func TestPipelineRun_TagsFetchLogsWithRunID(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	logPath := filepath.Join(t.TempDir(), "run.log")
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		RunID:     "run-under-test",
+		Trace:     true,
+		Debug:     true,
+		LogFile:   logPath,
+		LogFormat: "json",
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("expected a log file at %s, err = %v", logPath, err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	if len(lines) == 0 || len(lines[0]) == 0 {
+		t.Fatal("expected at least one log line from the -trace fetch")
+	}
+	found := false
+	for _, line := range lines {
+		var rec map[string]any
+		if err := json.Unmarshal(line, &rec); err != nil {
+			t.Fatalf("unmarshal log line: %v; line = %s", err, line)
+		}
+		if rec["msg"] != "fetch timing" {
+			continue
+		}
+		found = true
+		if rec["run_id"] != "run-under-test" {
+			t.Errorf("log line run_id = %v, want %q: %s", rec["run_id"], "run-under-test", line)
+		}
+	}
+	if !found {
+		t.Fatal("expected a \"fetch timing\" log line from the -trace fetch")
+	}
+}
+
+func TestPipelineRun_ReportIncludesRunID(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:              srv.URL,
+		Out:              out,
+		Timeout:          5 * time.Second,
+		RunID:            "reported-run",
+		OutputStdoutJSON: true,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	runErr := p.Run()
+	w.Close()
+	os.Stdout = oldStdout
+	if runErr != nil {
+		t.Fatalf("Run() error = %v", runErr)
+	}
+
+	var captured bytes.Buffer
+	if _, err := captured.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+
+	var report RunReport
+	if err := json.Unmarshal(captured.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal stdout: %v; stdout = %q", err, captured.String())
+	}
+	if report.RunID != "reported-run" {
+		t.Errorf("report.RunID = %q, want %q", report.RunID, "reported-run")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// RunReport is the machine-readable summary -output-stdout-json prints to
+// stdout after a run: enough for a CI system to tell what happened without
+// parsing logs or diffing files itself.
+type RunReport struct {
+	Changed    bool   `json:"changed"`
+	Bytes      int64  `json:"bytes"`
+	MimeType   string `json:"mimeType"`
+	OutputPath string `json:"outputPath"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+
+	// RunID correlates this run with its structured log lines; see -run-id
+	// and runid.go. Omitted when the run failed before a run ID was
+	// resolved (e.g. an early validation error).
+	RunID string `json:"runId,omitempty"`
+
+	// Trace is the primary fetch's DNS/connect/TLS-handshake/TTFB
+	// breakdown, set only when -trace was passed; omitted otherwise.
+	Trace *fetchTiming `json:"trace,omitempty"`
+}
+
+// printRunReport writes report to w as a single line of JSON. It's the only
+// thing -output-stdout-json mode writes to stdout; everything else (logs,
+// watch-mode stats, skip notices) goes to stderr, so a caller doesn't have
+// to disentangle them.
+func printRunReport(w io.Writer, report RunReport) error {
+	return json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintRunReport_IsSingleLineJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printRunReport(&buf, RunReport{Changed: true, Bytes: 42, MimeType: "image/png", OutputPath: "README.md", DurationMs: 7}); err != nil {
+		t.Fatalf("printRunReport() error = %v", err)
+	}
+
+	var report RunReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal: %v; output = %q", err, buf.String())
+	}
+	if report.Bytes != 42 || report.MimeType != "image/png" || !report.Changed {
+		t.Fatalf("report = %+v, want the values passed in", report)
+	}
+	if report.Error != "" {
+		t.Fatalf("Error = %q, want empty (omitempty) for a successful run", report.Error)
+	}
+}
+
+func TestPipelineRun_OutputStdoutJSONPrintsReportOnSuccess(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:              srv.URL,
+		Out:              out,
+		Timeout:          5 * time.Second,
+		UserAgent:        defaultUserAgent,
+		OutputStdoutJSON: true,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	runErr := p.Run()
+	w.Close()
+	os.Stdout = oldStdout
+	if runErr != nil {
+		t.Fatalf("Run() error = %v", runErr)
+	}
+
+	var captured bytes.Buffer
+	if _, err := captured.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+
+	var report RunReport
+	if err := json.Unmarshal(captured.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal stdout: %v; stdout = %q", err, captured.String())
+	}
+	if report.OutputPath != out {
+		t.Fatalf("OutputPath = %q, want %q", report.OutputPath, out)
+	}
+	if report.MimeType != "image/png" {
+		t.Fatalf("MimeType = %q, want image/png", report.MimeType)
+	}
+	if !report.Changed {
+		t.Fatal("expected Changed=true for a fresh write")
+	}
+	if report.Bytes == 0 {
+		t.Fatal("expected a non-zero Bytes")
+	}
+	if report.Error != "" {
+		t.Fatalf("Error = %q, want empty", report.Error)
+	}
+	if strings.Count(strings.TrimSpace(captured.String()), "\n") != 0 {
+		t.Fatalf("expected exactly one line of stdout, got %q", captured.String())
+	}
+}
+
+func TestPipelineRun_OutputStdoutJSONReportsFetchError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	cfg := Config{
+		URL:              srv.URL,
+		Out:              out,
+		Timeout:          5 * time.Second,
+		UserAgent:        defaultUserAgent,
+		StatusRetries:    0,
+		OutputStdoutJSON: true,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	runErr := p.Run()
+	w.Close()
+	os.Stdout = oldStdout
+	if runErr == nil {
+		t.Fatal("expected Run() to return the underlying fetch error")
+	}
+
+	var captured bytes.Buffer
+	if _, err := captured.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+
+	var report RunReport
+	if err := json.Unmarshal(captured.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal stdout: %v; stdout = %q", err, captured.String())
+	}
+	if report.Error == "" {
+		t.Fatal("expected a non-empty Error field in the report")
+	}
+}
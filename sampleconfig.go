@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// generateSampleConfig renders every flag registered on fs as a commented,
+// ready-to-edit sample: one "# <usage>" comment followed by a commented-out
+// "# -<name> <default>" line, sorted alphabetically by flag name.
+//
+// It reads directly off fs via VisitAll instead of off the Config struct,
+// which carries no struct tags to reflect on - flags are registered
+// imperatively in parseFlags, one fs.*Var call per field. Generating from
+// the live flag set rather than a hand-maintained second list is what
+// guarantees this can't drift: every flag parseFlags registers shows up
+// here automatically, including ones added after this file was written.
+func generateSampleConfig(fs *flag.FlagSet) string {
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	var b strings.Builder
+	b.WriteString("# eagleusb sample configuration\n#\n")
+	b.WriteString("# Every flag is listed below at its default value, commented out. Uncomment\n")
+	b.WriteString("# and edit the ones you need, then pass them on the command line (this file\n")
+	b.WriteString("# isn't read directly - eagleusb has no -config flag, only individual ones).\n")
+	for _, f := range flags {
+		fmt.Fprintf(&b, "\n# %s\n# -%s %s\n", f.Usage, f.Name, f.DefValue)
+	}
+	return b.String()
+}
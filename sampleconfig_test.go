@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFlags_SampleConfigSkipsURLRequiredCheck(t *testing.T) {
+	cfg, err := parseFlags([]string{"-sample-config"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if !cfg.SampleConfig {
+		t.Fatal("cfg.SampleConfig = false, want true")
+	}
+	if cfg.SampleConfigText == "" {
+		t.Fatal("cfg.SampleConfigText is empty")
+	}
+}
+
+func TestParseFlags_SampleConfigListsKnownFlags(t *testing.T) {
+	cfg, err := parseFlags([]string{"-sample-config"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	for _, want := range []string{"-url ", "-strict-template true", "-updated-badge false", "-cookie "} {
+		if !strings.Contains(cfg.SampleConfigText, want) {
+			t.Errorf("SampleConfigText missing %q:\n%s", want, cfg.SampleConfigText)
+		}
+	}
+}
+
+func TestGenerateSampleConfig_SortedAlphabeticallyByFlagName(t *testing.T) {
+	cfg, err := parseFlags([]string{"-sample-config"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	cookieIdx := strings.Index(cfg.SampleConfigText, "# -cookie ")
+	urlIdx := strings.Index(cfg.SampleConfigText, "\n# -url ")
+	if cookieIdx == -1 || urlIdx == -1 || cookieIdx > urlIdx {
+		t.Errorf("expected -cookie to sort before -url, got cookieIdx=%d urlIdx=%d", cookieIdx, urlIdx)
+	}
+}
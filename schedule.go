@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed 5-field cron expression (minute hour
+// day-of-month month day-of-week), used by -cron as an alternative to a
+// plain -watch-interval for deployments that want runs aligned to wall-clock
+// time (e.g. "0 * * * *" for the top of every hour) rather than a fixed
+// period since the last run.
+type cronSchedule struct {
+	minutes map[int]bool
+	hours   map[int]bool
+	doms    map[int]bool
+	months  map[int]bool
+	dows    map[int]bool
+}
+
+// parseCronSchedule parses a standard 5-field cron expression. Each field
+// accepts "*", a single number, a comma-separated list, a range ("1-5") or a
+// step ("*/15", "1-10/2"); fields combine as in crontab(5) (dom and dow are
+// OR'd together when both are restricted, AND'd when at least one is "*").
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("-cron must have 5 fields (minute hour dom month dow), got %d in %q", len(fields), expr)
+	}
+
+	minutes, err := parseCronField(fields[0], 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("-cron minute field: %w", err)
+	}
+	hours, err := parseCronField(fields[1], 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("-cron hour field: %w", err)
+	}
+	doms, err := parseCronField(fields[2], 1, 31)
+	if err != nil {
+		return nil, fmt.Errorf("-cron day-of-month field: %w", err)
+	}
+	months, err := parseCronField(fields[3], 1, 12)
+	if err != nil {
+		return nil, fmt.Errorf("-cron month field: %w", err)
+	}
+	dows, err := parseCronField(fields[4], 0, 6)
+	if err != nil {
+		return nil, fmt.Errorf("-cron day-of-week field: %w", err)
+	}
+
+	return &cronSchedule{minutes: minutes, hours: hours, doms: doms, months: months, dows: dows}, nil
+}
+
+// parseCronField expands one cron field into the set of values it matches,
+// bounded to [min, max].
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	result := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			rangePart = before
+			n, err := strconv.Atoi(after)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid step %q", after)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			if before, after, ok := strings.Cut(rangePart, "-"); ok {
+				loN, err1 := strconv.Atoi(before)
+				hiN, err2 := strconv.Atoi(after)
+				if err1 != nil || err2 != nil {
+					return nil, fmt.Errorf("invalid range %q", rangePart)
+				}
+				lo, hi = loN, hiN
+			} else {
+				n, err := strconv.Atoi(rangePart)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q", rangePart)
+				}
+				lo, hi = n, n
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("value %q out of range [%d, %d]", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			result[v] = true
+		}
+	}
+	return result, nil
+}
+
+// next returns the next time strictly after from that this schedule
+// matches, searching minute by minute up to four years out (enough to cross
+// any leap-year "Feb 29" schedule at least once) before giving up.
+func (s *cronSchedule) next(from time.Time) (time.Time, error) {
+	t := from.Truncate(time.Minute).Add(time.Minute)
+	limit := from.AddDate(4, 0, 0)
+	for !t.After(limit) {
+		domMatches := s.doms[t.Day()]
+		dowMatches := s.dows[int(t.Weekday())]
+		domRestricted := len(s.doms) < 31
+		dowRestricted := len(s.dows) < 7
+		dayMatches := domMatches && dowMatches
+		if domRestricted && !dowRestricted {
+			dayMatches = domMatches
+		} else if !domRestricted && dowRestricted {
+			dayMatches = dowMatches
+		}
+
+		if s.minutes[t.Minute()] && s.hours[t.Hour()] && s.months[int(t.Month())] && dayMatches {
+			return t, nil
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}, fmt.Errorf("no matching time found within 4 years")
+}
+
+// jitteredInterval randomizes d by up to jitterPercent in each direction, so
+// that many -watch deployments with the same -watch-interval don't all fetch
+// at the same moment. jitterPercent <= 0 returns d unchanged. rng is the
+// run's -seed-derived source (see newSeededRand), so the jittered interval
+// is reproducible under a fixed seed.
+func jitteredInterval(rng *seededRand, d time.Duration, jitterPercent float64) time.Duration {
+	if jitterPercent <= 0 {
+		return d
+	}
+	spread := float64(d) * (jitterPercent / 100)
+	delta := (rng.Float64()*2 - 1) * spread
+	jittered := time.Duration(float64(d) + delta)
+	if jittered < 0 {
+		return 0
+	}
+	return jittered
+}
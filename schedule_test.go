@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronField_Wildcard(t *testing.T) {
+	set, err := parseCronField("*", 0, 3)
+	if err != nil {
+		t.Fatalf("parseCronField() error = %v", err)
+	}
+	for i := 0; i <= 3; i++ {
+		if !set[i] {
+			t.Errorf("expected %d in wildcard set", i)
+		}
+	}
+}
+
+func TestParseCronField_StepAndRange(t *testing.T) {
+	set, err := parseCronField("10-20/5", 0, 59)
+	if err != nil {
+		t.Fatalf("parseCronField() error = %v", err)
+	}
+	want := map[int]bool{10: true, 15: true, 20: true}
+	if len(set) != len(want) {
+		t.Fatalf("set = %v, want %v", set, want)
+	}
+	for v := range want {
+		if !set[v] {
+			t.Errorf("expected %d in %v", v, set)
+		}
+	}
+}
+
+func TestParseCronField_OutOfRange(t *testing.T) {
+	if _, err := parseCronField("99", 0, 59); err == nil {
+		t.Fatal("expected an error for an out-of-range value")
+	}
+}
+
+func TestParseCronSchedule_RejectsWrongFieldCount(t *testing.T) {
+	if _, err := parseCronSchedule("* * *"); err == nil {
+		t.Fatal("expected an error for a 3-field expression")
+	}
+}
+
+func TestCronSchedule_NextTopOfEveryHour(t *testing.T) {
+	sched, err := parseCronSchedule("0 * * * *")
+	if err != nil {
+		t.Fatalf("parseCronSchedule() error = %v", err)
+	}
+
+	from := time.Date(2026, 8, 9, 14, 23, 0, 0, time.UTC)
+	next, err := sched.next(from)
+	if err != nil {
+		t.Fatalf("next() error = %v", err)
+	}
+	want := time.Date(2026, 8, 9, 15, 0, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("next() = %v, want %v", next, want)
+	}
+}
+
+func TestCronSchedule_NextSpecificDayOfWeek(t *testing.T) {
+	sched, err := parseCronSchedule("30 9 * * 1")
+	if err != nil {
+		t.Fatalf("parseCronSchedule() error = %v", err)
+	}
+
+	from := time.Date(2026, 8, 9, 0, 0, 0, 0, time.UTC) // a Sunday
+	next, err := sched.next(from)
+	if err != nil {
+		t.Fatalf("next() error = %v", err)
+	}
+	if next.Weekday() != time.Monday || next.Hour() != 9 || next.Minute() != 30 {
+		t.Errorf("next() = %v, want the following Monday at 09:30", next)
+	}
+}
+
+func TestJitteredInterval_ZeroPercentIsUnchanged(t *testing.T) {
+	d := 10 * time.Minute
+	if got := jitteredInterval(newSeededRand(1), d, 0); got != d {
+		t.Errorf("jitteredInterval(d, 0) = %v, want %v", got, d)
+	}
+}
+
+func TestJitteredInterval_StaysWithinBounds(t *testing.T) {
+	d := 10 * time.Minute
+	rng := newSeededRand(1)
+	for i := 0; i < 100; i++ {
+		got := jitteredInterval(rng, d, 20)
+		min := d - d*20/100
+		max := d + d*20/100
+		if got < min || got > max {
+			t.Fatalf("jitteredInterval(d, 20) = %v, want within [%v, %v]", got, min, max)
+		}
+	}
+}
+
+func TestParseFlags_CronRequiresWatch(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-cron", "0 * * * *"})
+	if err == nil {
+		t.Fatal("expected an error when -cron is set without -watch")
+	}
+}
+
+func TestParseFlags_CronRejectsInvalidExpression(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-watch", "-cron", "not a cron"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid -cron expression")
+	}
+}
+
+func TestParseFlags_WatchJitterOutOfRange(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-watch-jitter", "150"})
+	if err == nil {
+		t.Fatal("expected an error for -watch-jitter > 100")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// selfTestCheck is one pass/fail line of a -self-test run.
+type selfTestCheck struct {
+	Name string
+	Err  error
+}
+
+// runSelfTest checks that cfg's template is readable/parseable, its output
+// directory is writable, the source host is reachable, and a tiny sample
+// encode works. It prints a checklist and reports whether every check
+// passed, without touching p.Config.Out.
+func runSelfTest(cfg Config) bool {
+	checks := []selfTestCheck{
+		{"template parses", checkTemplate(cfg)},
+		{"output directory is writable", checkOutputDir(cfg.Out)},
+		{"source host is reachable", checkReachable(cfg.URL, cfg.UserAgent)},
+		{"sample image encodes", checkSampleEncode()},
+	}
+
+	ok := true
+	for _, c := range checks {
+		status := "PASS"
+		if c.Err != nil {
+			status = "FAIL"
+			ok = false
+		}
+		fmt.Printf("[%s] %s\n", status, c.Name)
+		if c.Err != nil {
+			fmt.Printf("      %v\n", c.Err)
+		}
+	}
+
+	return ok
+}
+
+func checkTemplate(cfg Config) error {
+	_, _, err := loadTemplateForConfig(cfg)
+	return err
+}
+
+func checkOutputDir(out string) error {
+	if out == stdioPlaceholder {
+		return nil
+	}
+	dir := filepath.Dir(out)
+	probe := filepath.Join(dir, ".eagleusb-selftest")
+	if err := os.WriteFile(probe, []byte{}, 0o644); err != nil {
+		return err
+	}
+	return os.Remove(probe)
+}
+
+func checkReachable(url, userAgent string) error {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return nil
+}
+
+func checkSampleEncode() error {
+	// A 1x1 transparent PNG, just enough to exercise the decode path.
+	sample := []byte{
+		0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+		0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+		0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4, 0x89, 0x00, 0x00, 0x00,
+		0x0a, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0x00, 0x01, 0x00, 0x00,
+		0x05, 0x00, 0x01, 0x0d, 0x0a, 0x2d, 0xb4, 0x00, 0x00, 0x00, 0x00, 0x49,
+		0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+	}
+	_, _, _, err := encodeImageToBase64(sample, "image/png", "", false)
+	return err
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunSelfTest_AllPass(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		UserAgent: defaultUserAgent,
+	}
+
+	if !runSelfTest(cfg) {
+		t.Fatal("expected all self-test checks to pass")
+	}
+}
+
+func TestRunSelfTest_FailsOnUnreachableHost(t *testing.T) {
+	cfg := Config{
+		URL:       "http://127.0.0.1:1/unreachable",
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		UserAgent: defaultUserAgent,
+	}
+
+	if runSelfTest(cfg) {
+		t.Fatal("expected self-test to fail for an unreachable host")
+	}
+}
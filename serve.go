@@ -0,0 +1,369 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// serveCacheEntry holds a rendered response for -serve's in-memory cache,
+// keyed by the request's normalized query params (see normalizeCacheKey),
+// so repeating an identical request within -serve-cache-ttl skips
+// re-running the pipeline.
+type serveCacheEntry struct {
+	body        []byte
+	contentType string
+	etag        string
+	expires     time.Time
+}
+
+// serveCache is a bounded, mutex-guarded LRU+TTL cache for -serve. It is
+// deliberately simpler than imageCache, which is about conditional HTTP
+// requests to the upstream source; this one is about not re-running the
+// whole pipeline for two requests with identical query params. capacity
+// entries are kept at most, evicting the least recently used once full, in
+// addition to the TTL expiry already applied by store/get; hits and misses
+// are tracked for -serve's "/metrics" endpoint.
+type serveCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front = most recently used; element.Value is a *serveCacheElem
+	elems    map[string]*list.Element
+	hits     int64
+	misses   int64
+}
+
+type serveCacheElem struct {
+	key   string
+	entry serveCacheEntry
+}
+
+func newServeCache(capacity int) *serveCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &serveCache{capacity: capacity, order: list.New(), elems: make(map[string]*list.Element)}
+}
+
+func (c *serveCache) get(key string) (serveCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.elems[key]
+	if !ok {
+		atomic.AddInt64(&c.misses, 1)
+		return serveCacheEntry{}, false
+	}
+	entry := elem.Value.(*serveCacheElem).entry
+	if time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.elems, key)
+		atomic.AddInt64(&c.misses, 1)
+		return serveCacheEntry{}, false
+	}
+	c.order.MoveToFront(elem)
+	atomic.AddInt64(&c.hits, 1)
+	return entry, true
+}
+
+func (c *serveCache) store(key string, entry serveCacheEntry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	entry.expires = time.Now().Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.elems[key]; ok {
+		elem.Value.(*serveCacheElem).entry = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&serveCacheElem{key: key, entry: entry})
+	c.elems[key] = elem
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.elems, oldest.Value.(*serveCacheElem).key)
+	}
+}
+
+// stats returns the cache's cumulative hit/miss counters and its current
+// entry count, for the "/metrics" handler.
+func (c *serveCache) stats() (hits, misses int64, size int) {
+	c.mu.Lock()
+	size = c.order.Len()
+	c.mu.Unlock()
+	return atomic.LoadInt64(&c.hits), atomic.LoadInt64(&c.misses), size
+}
+
+// normalizeCacheKey builds a stable -serve cache key from a request's query
+// params: sorted by name so "?rows=3&columns=3" and "?columns=3&rows=3" hit
+// the same entry, and with "nocache" itself excluded since it controls
+// whether the cache is consulted at all, not what's being requested.
+func normalizeCacheKey(prefix string, query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		if name == "nocache" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, v := range values {
+			b.WriteByte('\x00')
+			b.WriteString(name)
+			b.WriteByte('=')
+			b.WriteString(v)
+		}
+	}
+	return b.String()
+}
+
+// bypassCache reports whether the request asked to skip -serve's cache
+// entirely via ?nocache=1, for both reading and writing.
+func bypassCache(query url.Values) bool {
+	return query.Get("nocache") == "1"
+}
+
+// requestIDHeader is the incoming header -serve reads a caller-supplied
+// request ID from, overriding -run-id for that one request; it's the same
+// header name most reverse proxies (nginx, AWS ALB, ...) already forward
+// or generate, so a caller's existing tracing setup typically needs no
+// changes to correlate into eagleusb's own logs and run-report JSON.
+const requestIDHeader = "X-Request-Id"
+
+// configFromQuery overrides base's collage options with ones found in
+// query, for -serve's request handlers. Only the options that make sense
+// to vary per request are exposed; everything else -serve was started
+// with (auth, templates, output paths, ...) stays fixed. requestID, when
+// non-empty (see requestIDHeader), overrides cfg.RunID for this request.
+func configFromQuery(base Config, query url.Values, requestID string) (Config, error) {
+	cfg := base
+	if requestID != "" {
+		cfg.RunID = requestID
+	}
+	if v := query.Get("url"); v != "" {
+		cfg.URL = v
+	}
+	if v := query.Get("rows"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("rows: %w", err)
+		}
+		cfg.Rows = n
+	}
+	if v := query.Get("columns"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("columns: %w", err)
+		}
+		cfg.Columns = n
+	}
+	if v := query.Get("lang"); v != "" {
+		cfg.Lang = v
+	}
+	if cfg.URL == "" {
+		return Config{}, fmt.Errorf("no collage url: pass ?url= or start -serve with a default -url")
+	}
+	return cfg, nil
+}
+
+// serveETag returns a quoted ETag for body, reusing the same content-hash
+// logic -cache-bust uses to detect when the image itself has changed
+// (excluding anything timestamp-like, since there's nothing timestamped in
+// a data URI).
+func serveETag(body []byte) string {
+	return `"` + contentHash(body) + `"`
+}
+
+// writeCacheableResponse sets Content-Type, X-Cache, ETag and Cache-Control
+// on w for entry, honoring the request's If-None-Match with a bodyless 304
+// when it matches entry's ETag; ttl (-serve-cache-ttl) drives Cache-Control,
+// since a client has no reason to hold onto a response longer than -serve
+// itself would before regenerating it.
+func writeCacheableResponse(w http.ResponseWriter, r *http.Request, entry serveCacheEntry, cacheStatus string, ttl time.Duration) {
+	w.Header().Set("Content-Type", entry.contentType)
+	w.Header().Set("X-Cache", cacheStatus)
+	w.Header().Set("ETag", entry.etag)
+	if ttl > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(ttl.Seconds())))
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	if entry.etag != "" && r.Header.Get("If-None-Match") == entry.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write(entry.body)
+}
+
+// runServe implements -serve: an HTTP server that renders the README at
+// "/" and the raw collage image at "/image" on demand, both generated by
+// the same Pipeline.renderOutput path the CLI's default run uses, with
+// query params overriding a handful of collage options (see
+// configFromQuery) and -serve-cache-ttl avoiding a full re-render for
+// repeated identical requests. It blocks until SIGINT/SIGTERM, then shuts
+// the server down gracefully.
+func runServe(cfg Config) error {
+	cache := newServeCache(cfg.ServeCacheSize)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", serveReadmeHandler(cfg, cache))
+	mux.HandleFunc("/image", serveImageHandler(cfg, cache))
+	mux.HandleFunc("/metrics", serveMetricsHandler(cache))
+
+	server := &http.Server{Addr: cfg.Serve, Handler: mux}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Fprintln(os.Stderr, "eagleusb: serving on", cfg.Serve)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+			return
+		}
+		errCh <- nil
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+func serveReadmeHandler(base Config, cache *serveCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg, err := configFromQuery(base, r.URL.Query(), r.Header.Get(requestIDHeader))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		noCache := bypassCache(r.URL.Query())
+		key := normalizeCacheKey("readme", r.URL.Query())
+		if !noCache {
+			if entry, ok := cache.get(key); ok {
+				writeCacheableResponse(w, r, entry, "HIT", base.ServeCacheTTL)
+				return
+			}
+		}
+
+		p, err := NewPipeline(cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, rendered, err := p.renderOutput()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		entry := serveCacheEntry{body: rendered, contentType: "text/markdown; charset=utf-8", etag: serveETag(rendered)}
+		if !noCache {
+			cache.store(key, entry, base.ServeCacheTTL)
+		}
+		writeCacheableResponse(w, r, entry, "MISS", base.ServeCacheTTL)
+	}
+}
+
+func serveImageHandler(base Config, cache *serveCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg, err := configFromQuery(base, r.URL.Query(), r.Header.Get(requestIDHeader))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		noCache := bypassCache(r.URL.Query())
+		key := normalizeCacheKey("image", r.URL.Query())
+		if !noCache {
+			if entry, ok := cache.get(key); ok {
+				writeCacheableResponse(w, r, entry, "HIT", base.ServeCacheTTL)
+				return
+			}
+		}
+
+		p, err := NewPipeline(cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _, err := p.renderOutput()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		mime, body, err := decodeDataURI(data.ImageURL)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		entry := serveCacheEntry{body: body, contentType: mime, etag: serveETag(body)}
+		if !noCache {
+			cache.store(key, entry, base.ServeCacheTTL)
+		}
+		writeCacheableResponse(w, r, entry, "MISS", base.ServeCacheTTL)
+	}
+}
+
+// serveMetricsHandler reports -serve's cache hit/miss counters and current
+// size in a plain-text, one-metric-per-line format, so an operator can
+// curl it without pulling in a metrics dependency this tree otherwise has
+// no use for.
+func serveMetricsHandler(cache *serveCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hits, misses, size := cache.stats()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "eagleusb_serve_cache_hits %d\n", hits)
+		fmt.Fprintf(w, "eagleusb_serve_cache_misses %d\n", misses)
+		fmt.Fprintf(w, "eagleusb_serve_cache_size %d\n", size)
+	}
+}
+
+// decodeDataURI splits a "data:<mime>;base64,<payload>" URI, as produced by
+// encodeImageToBase64, back into its MIME type and raw bytes, so -serve's
+// /image endpoint can respond with the actual image instead of markdown.
+func decodeDataURI(dataURI string) (mime string, body []byte, err error) {
+	const prefix = "data:"
+	if !strings.HasPrefix(dataURI, prefix) {
+		return "", nil, fmt.Errorf("not a data URI")
+	}
+	meta, payload, ok := strings.Cut(dataURI[len(prefix):], ",")
+	if !ok {
+		return "", nil, fmt.Errorf("malformed data URI")
+	}
+	mime = strings.TrimSuffix(meta, ";base64")
+	body, err = base64.StdEncoding.DecodeString(payload)
+	return mime, body, err
+}
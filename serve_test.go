@@ -0,0 +1,288 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConfigFromQuery_OverridesAndRequiresURL(t *testing.T) {
+	base := Config{URL: "https://example.com/default.png", Rows: 3, Columns: 3}
+
+	cfg, err := configFromQuery(base, url.Values{"rows": {"5"}, "columns": {"2"}, "lang": {"fr"}}, "")
+	if err != nil {
+		t.Fatalf("configFromQuery() error = %v", err)
+	}
+	if cfg.Rows != 5 || cfg.Columns != 2 || cfg.Lang != "fr" {
+		t.Errorf("cfg = %+v, want overridden rows/columns/lang", cfg)
+	}
+	if cfg.URL != base.URL {
+		t.Errorf("URL = %q, want the base default %q when not overridden", cfg.URL, base.URL)
+	}
+
+	if _, err := configFromQuery(Config{}, url.Values{}, ""); err == nil {
+		t.Fatal("expected an error when neither base nor query supplies a url")
+	}
+
+	if _, err := configFromQuery(base, url.Values{"rows": {"not-a-number"}}, ""); err == nil {
+		t.Fatal("expected an error for a non-numeric rows override")
+	}
+}
+
+func TestConfigFromQuery_RequestIDOverridesRunID(t *testing.T) {
+	base := Config{URL: "https://example.com/default.png", RunID: "from-flag"}
+
+	cfg, err := configFromQuery(base, url.Values{}, "from-header")
+	if err != nil {
+		t.Fatalf("configFromQuery() error = %v", err)
+	}
+	if cfg.RunID != "from-header" {
+		t.Errorf("RunID = %q, want the header value to override -run-id", cfg.RunID)
+	}
+
+	cfg, err = configFromQuery(base, url.Values{}, "")
+	if err != nil {
+		t.Fatalf("configFromQuery() error = %v", err)
+	}
+	if cfg.RunID != "from-flag" {
+		t.Errorf("RunID = %q, want -run-id preserved when no header is sent", cfg.RunID)
+	}
+}
+
+func TestDecodeDataURI(t *testing.T) {
+	mime, body, err := decodeDataURI("data:image/png;base64,AAEC")
+	if err != nil {
+		t.Fatalf("decodeDataURI() error = %v", err)
+	}
+	if mime != "image/png" {
+		t.Errorf("mime = %q, want image/png", mime)
+	}
+	if string(body) != "\x00\x01\x02" {
+		t.Errorf("body = %v, want decoded bytes", body)
+	}
+
+	if _, _, err := decodeDataURI("not a data uri"); err == nil {
+		t.Fatal("expected an error for a non-data URI")
+	}
+}
+
+func TestServeReadmeHandler_RendersAndCaches(t *testing.T) {
+	var requests int32
+	imgSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(makePNG(1, 1))
+	}))
+	defer imgSrv.Close()
+
+	base := Config{URL: imgSrv.URL, Timeout: 5 * time.Second, UserAgent: defaultUserAgent, ServeCacheTTL: time.Minute}
+	handler := serveReadmeHandler(base, newServeCache(10))
+
+	wantCache := []string{"MISS", "HIT"}
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, body = %q", i, rec.Code, rec.Body.String())
+		}
+		if !strings.Contains(rec.Body.String(), "data:image/png;base64,") {
+			t.Errorf("request %d: body = %q, want an embedded data URI", i, rec.Body.String())
+		}
+		if got := rec.Header().Get("X-Cache"); got != wantCache[i] {
+			t.Errorf("request %d: X-Cache = %q, want %q", i, got, wantCache[i])
+		}
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("upstream requests = %d, want 1 (second request should be served from cache)", got)
+	}
+}
+
+func TestServeReadmeHandler_NocacheBypassesCache(t *testing.T) {
+	var requests int32
+	imgSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(makePNG(1, 1))
+	}))
+	defer imgSrv.Close()
+
+	base := Config{URL: imgSrv.URL, Timeout: 5 * time.Second, UserAgent: defaultUserAgent, ServeCacheTTL: time.Minute}
+	handler := serveReadmeHandler(base, newServeCache(10))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/?nocache=1", nil)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, body = %q", i, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("X-Cache"); got != "MISS" {
+			t.Errorf("request %d: X-Cache = %q, want MISS with ?nocache=1", i, got)
+		}
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("upstream requests = %d, want 2 (?nocache=1 should bypass the cache every time)", got)
+	}
+}
+
+func TestNormalizeCacheKey_SortsParamsAndExcludesNocache(t *testing.T) {
+	a := normalizeCacheKey("readme", url.Values{"rows": {"3"}, "columns": {"3"}, "nocache": {"1"}})
+	b := normalizeCacheKey("readme", url.Values{"columns": {"3"}, "rows": {"3"}})
+	if a != b {
+		t.Errorf("normalizeCacheKey() = %q and %q, want them equal regardless of param order or ?nocache", a, b)
+	}
+
+	c := normalizeCacheKey("readme", url.Values{"rows": {"4"}, "columns": {"3"}})
+	if a == c {
+		t.Errorf("normalizeCacheKey() = %q, want a different key for different params", c)
+	}
+}
+
+func TestServeCache_EvictsLeastRecentlyUsedOverCapacity(t *testing.T) {
+	cache := newServeCache(2)
+	cache.store("a", serveCacheEntry{body: []byte("a")}, time.Minute)
+	cache.store("b", serveCacheEntry{body: []byte("b")}, time.Minute)
+	if _, ok := cache.get("a"); !ok {
+		t.Fatal("expected \"a\" to still be cached")
+	}
+	cache.store("c", serveCacheEntry{body: []byte("c")}, time.Minute)
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("expected \"b\" to have been evicted as the least recently used entry")
+	}
+	if _, ok := cache.get("a"); !ok {
+		t.Error("expected \"a\" to still be cached after being touched before the eviction")
+	}
+	if _, ok := cache.get("c"); !ok {
+		t.Error("expected \"c\" to still be cached")
+	}
+}
+
+func TestServeMetricsHandler_ReportsHitsAndMisses(t *testing.T) {
+	cache := newServeCache(10)
+	cache.store("k", serveCacheEntry{body: []byte("x")}, time.Minute)
+	cache.get("k")
+	cache.get("missing")
+
+	rec := httptest.NewRecorder()
+	serveMetricsHandler(cache)(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "eagleusb_serve_cache_hits 1") {
+		t.Errorf("metrics = %q, want 1 hit", body)
+	}
+	if !strings.Contains(body, "eagleusb_serve_cache_misses 1") {
+		t.Errorf("metrics = %q, want 1 miss", body)
+	}
+	if !strings.Contains(body, "eagleusb_serve_cache_size 1") {
+		t.Errorf("metrics = %q, want cache size 1", body)
+	}
+}
+
+func TestServeImageHandler_ServesRawImageBytes(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	base := Config{URL: imgSrv.URL, Timeout: 5 * time.Second, UserAgent: defaultUserAgent}
+	handler := serveImageHandler(base, newServeCache(10))
+
+	req := httptest.NewRequest(http.MethodGet, "/image", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", rec.Header().Get("Content-Type"))
+	}
+	if !strings.HasPrefix(rec.Body.String(), "\x89PNG") {
+		t.Errorf("body does not look like a raw PNG: %q", rec.Body.String())
+	}
+}
+
+func TestServeReadmeHandler_IfNoneMatchReturns304(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	base := Config{URL: imgSrv.URL, Timeout: 5 * time.Second, UserAgent: defaultUserAgent, ServeCacheTTL: time.Minute}
+	handler := serveReadmeHandler(base, newServeCache(10))
+
+	first := httptest.NewRecorder()
+	handler(first, httptest.NewRequest(http.MethodGet, "/", nil))
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag header on the first response")
+	}
+	if got := first.Header().Get("Cache-Control"); got != "max-age=60" {
+		t.Errorf("Cache-Control = %q, want max-age=60 for a 1-minute -serve-cache-ttl", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want 304 when If-None-Match matches the current ETag", rec.Code)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("body = %q, want an empty 304 body", rec.Body.String())
+	}
+
+	stale := httptest.NewRequest(http.MethodGet, "/", nil)
+	stale.Header.Set("If-None-Match", `"stale"`)
+	rec2 := httptest.NewRecorder()
+	handler(rec2, stale)
+	if rec2.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 when If-None-Match doesn't match", rec2.Code)
+	}
+}
+
+func TestWriteCacheableResponse_NoStoreWithoutTTL(t *testing.T) {
+	entry := serveCacheEntry{body: []byte("x"), contentType: "text/plain", etag: `"abc"`}
+	rec := httptest.NewRecorder()
+	writeCacheableResponse(rec, httptest.NewRequest(http.MethodGet, "/", nil), entry, "MISS", 0)
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store when -serve-cache-ttl is 0", got)
+	}
+}
+
+func TestParseFlags_ServeDoesNotRequireURL(t *testing.T) {
+	cfg, err := parseFlags([]string{"-serve", ":8080"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v, want -serve to make -url optional", err)
+	}
+	if cfg.Serve != ":8080" {
+		t.Errorf("Serve = %q, want :8080", cfg.Serve)
+	}
+}
+
+func TestParseFlags_ServeCacheTTLRejectsNegative(t *testing.T) {
+	_, err := parseFlags([]string{"-serve", ":8080", "-serve-cache-ttl", "-1s"})
+	if err == nil {
+		t.Fatal("expected an error for a negative -serve-cache-ttl")
+	}
+}
+
+func TestServeImageHandler_QueryOverridesURL(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	base := Config{Timeout: 5 * time.Second, UserAgent: defaultUserAgent}
+	handler := serveImageHandler(base, newServeCache(10))
+
+	req := httptest.NewRequest(http.MethodGet, "/image?url="+url.QueryEscape(imgSrv.URL), nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+}
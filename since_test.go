@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun_SinceSkipsWhenLastModifiedIsOld(t *testing.T) {
+	var gets int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", time.Now().Add(-48*time.Hour).UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			gets++
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(makePNG(1, 1))
+		}
+	}))
+	defer srv.Close()
+
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Since:     time.Hour,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if gets != 0 {
+		t.Fatalf("expected the run to be skipped before any GET, got %d GETs", gets)
+	}
+}
+
+func TestPipelineRun_SinceProceedsWhenLastModifiedIsRecent(t *testing.T) {
+	var gets int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			gets++
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(makePNG(1, 1))
+		}
+	}))
+	defer srv.Close()
+
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Since:     time.Hour,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if gets != 1 {
+		t.Fatalf("expected the run to proceed, got %d GETs, want 1", gets)
+	}
+}
+
+func TestPipelineRun_SinceProceedsWithoutLastModifiedHeader(t *testing.T) {
+	var gets int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets++
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(makePNG(1, 1))
+		}
+	}))
+	defer srv.Close()
+
+	cfg := Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Since:     time.Hour,
+	}
+
+	p, err := NewPipeline(cfg)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if gets != 1 {
+		t.Fatalf("expected the run to proceed when the source sends no Last-Modified header, got %d GETs, want 1", gets)
+	}
+}
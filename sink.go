@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// sinksFlag implements flag.Value for the repeatable -sink flag, appending
+// each occurrence to Config.Sinks, the same pattern as -allow-host's
+// allowHostsFlag and -template's templateFlag.
+type sinksFlag struct {
+	cfg *Config
+}
+
+func newSinksFlag(cfg *Config) *sinksFlag {
+	return &sinksFlag{cfg: cfg}
+}
+
+func (f *sinksFlag) String() string {
+	if f.cfg == nil {
+		return ""
+	}
+	return strings.Join(f.cfg.Sinks, ",")
+}
+
+func (f *sinksFlag) Set(value string) error {
+	f.cfg.Sinks = append(f.cfg.Sinks, value)
+	return nil
+}
+
+// outputSink is one destination -sink delivers the rendered template body
+// to. Several can run off a single render - e.g. "-sink file:./mirror.md
+// -sink gist -sink http-post:https://example.com/hook" writes a second
+// local copy, pushes a gist and notifies a webhook, all from the same run,
+// alongside the normal -out write. This generalizes what -out (always one
+// file) and the older, exclusive -gist-token (replaces -out entirely) each
+// did on their own into a composable list.
+type outputSink interface {
+	// describe names this sink for error/log messages.
+	describe() string
+	// write delivers content - the same fully rendered, post-processed
+	// bytes -out itself receives - to this sink's destination.
+	write(ctx context.Context, p *Pipeline, content []byte) error
+}
+
+// parseSinks parses -sink's repeatable values into outputSinks. A spec is
+// one of:
+//   - "file:<path>": writes content to path via the same atomic
+//     write-then-rename -out uses (see writeFileAtomic)
+//   - "stdout": writes content to os.Stdout
+//   - "gist": pushes content via uploadGist, reusing -gist-token/-gist-id/
+//     -gist-filename the same way the older, exclusive -gist-token mode
+//     does, but without taking over the whole run the way that mode does
+//   - "http-post:<url>": POSTs content to url as text/plain
+//
+// An empty specs returns a nil slice, meaning -sink is unused. Parsing is
+// called both eagerly in parseFlags (so a typo'd -sink fails at startup)
+// and again in dispatchRunOnce, mirroring -format-fallback and -jitter.
+func parseSinks(specs []string) ([]outputSink, error) {
+	var sinks []outputSink
+	for _, spec := range specs {
+		kind, arg, _ := strings.Cut(spec, ":")
+		switch kind {
+		case "file":
+			if arg == "" {
+				return nil, fmt.Errorf("-sink file: requires a path, e.g. \"file:./mirror.md\"")
+			}
+			sinks = append(sinks, fileSink{path: arg})
+		case "stdout":
+			sinks = append(sinks, stdoutSink{})
+		case "gist":
+			sinks = append(sinks, gistSink{})
+		case "http-post":
+			if arg == "" {
+				return nil, fmt.Errorf("-sink http-post: requires a URL, e.g. \"http-post:https://example.com/hook\"")
+			}
+			sinks = append(sinks, httpPostSink{url: arg})
+		default:
+			return nil, fmt.Errorf("-sink must be \"file:<path>\", \"stdout\", \"gist\" or \"http-post:<url>\", got %q", spec)
+		}
+	}
+	return sinks, nil
+}
+
+type fileSink struct {
+	path string
+}
+
+func (s fileSink) describe() string { return "file:" + s.path }
+
+func (s fileSink) write(_ context.Context, _ *Pipeline, content []byte) error {
+	return writeFileAtomic(s.path, content)
+}
+
+type stdoutSink struct{}
+
+func (stdoutSink) describe() string { return "stdout" }
+
+func (stdoutSink) write(_ context.Context, _ *Pipeline, content []byte) error {
+	_, err := os.Stdout.Write(content)
+	return err
+}
+
+type gistSink struct{}
+
+func (gistSink) describe() string { return "gist" }
+
+func (gistSink) write(ctx context.Context, p *Pipeline, content []byte) error {
+	if p.Config.GistToken == "" {
+		return fmt.Errorf("-sink gist requires -gist-token")
+	}
+	_, err := uploadGist(ctx, p.Client, p.Config.GistToken, p.Config.GistID, p.Config.GistFilename, content)
+	return err
+}
+
+type httpPostSink struct {
+	url string
+}
+
+func (s httpPostSink) describe() string { return "http-post:" + s.url }
+
+func (s httpPostSink) write(ctx context.Context, p *Pipeline, content []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+// runSinks delivers content to every -sink destination. A failing sink is
+// reported to stderr and, by default, doesn't stop the rest (matching
+// -partial's "report but don't abort" precedent); -sink-fail-fast makes the
+// first failure abort the run instead, returning that sink's error.
+func (p *Pipeline) runSinks(ctx context.Context, content []byte) error {
+	sinks, err := parseSinks(p.Config.Sinks)
+	if err != nil {
+		return err
+	}
+
+	for _, sink := range sinks {
+		if err := sink.write(ctx, p, content); err != nil {
+			if p.Config.SinkFailFast {
+				return fmt.Errorf("-sink %s: %w", sink.describe(), err)
+			}
+			fmt.Fprintf(os.Stderr, "eagleusb: -sink %s failed: %v\n", sink.describe(), err)
+			p.setWarned()
+		}
+	}
+	return nil
+}
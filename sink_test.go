@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSinks_UnknownKindIsRejected(t *testing.T) {
+	if _, err := parseSinks([]string{"carrier-pigeon"}); err == nil {
+		t.Fatal("expected an error for an unrecognized -sink kind")
+	}
+}
+
+func TestParseSinks_FileWithoutPathIsRejected(t *testing.T) {
+	if _, err := parseSinks([]string{"file:"}); err == nil {
+		t.Fatal("expected an error for \"file:\" with no path")
+	}
+}
+
+func TestParseSinks_HTTPPostWithoutURLIsRejected(t *testing.T) {
+	if _, err := parseSinks([]string{"http-post:"}); err == nil {
+		t.Fatal("expected an error for \"http-post:\" with no URL")
+	}
+}
+
+func TestParseSinks_EmptyIsNil(t *testing.T) {
+	sinks, err := parseSinks(nil)
+	if err != nil {
+		t.Fatalf("parseSinks(nil) error = %v", err)
+	}
+	if sinks != nil {
+		t.Errorf("sinks = %v, want nil", sinks)
+	}
+}
+
+func TestParseSinks_ParsesEveryKind(t *testing.T) {
+	sinks, err := parseSinks([]string{"file:./mirror.md", "stdout", "gist", "http-post:https://example.com/hook"})
+	if err != nil {
+		t.Fatalf("parseSinks() error = %v", err)
+	}
+	if len(sinks) != 4 {
+		t.Fatalf("got %d sinks, want 4", len(sinks))
+	}
+	want := []string{"file:./mirror.md", "stdout", "gist", "http-post:https://example.com/hook"}
+	for i, sink := range sinks {
+		if got := sink.describe(); got != want[i] {
+			t.Errorf("sinks[%d].describe() = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestHTTPPostSink_PostsRenderedContent(t *testing.T) {
+	var gotBody []byte
+	var gotContentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotContentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	p := &Pipeline{Client: http.DefaultClient}
+	sink := httpPostSink{url: srv.URL}
+	if err := sink.write(context.Background(), p, []byte("hello")); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if string(gotBody) != "hello" {
+		t.Errorf("posted body = %q, want %q", gotBody, "hello")
+	}
+	if gotContentType != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/plain; charset=utf-8", gotContentType)
+	}
+}
+
+func TestHTTPPostSink_NonOKStatusReturnsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	p := &Pipeline{Client: http.DefaultClient}
+	sink := httpPostSink{url: srv.URL}
+	if err := sink.write(context.Background(), p, []byte("hello")); err == nil {
+		t.Fatal("expected an error for a 500 response")
+	}
+}
+
+func TestGistSink_RequiresGistToken(t *testing.T) {
+	p := &Pipeline{Client: http.DefaultClient}
+	sink := gistSink{}
+	if err := sink.write(context.Background(), p, []byte("hello")); err == nil {
+		t.Fatal("expected an error when -gist-token isn't set")
+	}
+}
+
+func TestPipelineRun_SinkWritesAlongsideOut(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	var posted []byte
+	postSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer postSrv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	mirror := filepath.Join(t.TempDir(), "mirror.md")
+	p, err := NewPipeline(Config{
+		URL:       imgSrv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Sinks:     []string{"file:" + mirror, "http-post:" + postSrv.URL},
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	outContent, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("reading -out: %v", err)
+	}
+	mirrorContent, err := os.ReadFile(mirror)
+	if err != nil {
+		t.Fatalf("reading the file sink's output: %v", err)
+	}
+	if string(outContent) != string(mirrorContent) {
+		t.Error("expected the file sink's content to match -out's")
+	}
+	if string(posted) != string(outContent) {
+		t.Error("expected the http-post sink's content to match -out's")
+	}
+}
+
+func TestPipelineRun_SinkFailureIsReportedButDoesNotAbortByDefault(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:       imgSrv.URL,
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Sinks:     []string{"http-post:http://127.0.0.1:1"},
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want nil since -sink-fail-fast wasn't set", err)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("expected -out to still be written despite the failing sink: %v", err)
+	}
+}
+
+func TestPipelineRun_SinkFailFastAbortsRun(t *testing.T) {
+	imgSrv := tinyPNGServer(t)
+	defer imgSrv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:          imgSrv.URL,
+		Out:          out,
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+		Sinks:        []string{"http-post:http://127.0.0.1:1"},
+		SinkFailFast: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail: -sink-fail-fast set and the http-post sink can't connect")
+	}
+}
+
+func TestParseFlags_SinkRejectsUnknownKind(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-sink", "carrier-pigeon"})
+	if err == nil {
+		t.Fatal("expected an error for an unrecognized -sink kind")
+	}
+}
+
+func TestParseFlags_SinkIsRepeatable(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com", "-sink", "stdout", "-sink", "gist"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if len(cfg.Sinks) != 2 {
+		t.Errorf("Sinks = %v, want 2 entries", cfg.Sinks)
+	}
+}
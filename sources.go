@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// ImageSource names one collage to fetch as part of a -sources-config run,
+// letting a single README arrange several different collages (albums,
+// artists, tracks, ...) in a grid.
+type ImageSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// loadSources reads a JSON array of ImageSource from path, e.g.:
+//
+//	[{"name": "albums", "url": "https://example.com/albums.png"},
+//	 {"name": "artists", "url": "https://example.com/artists.png"}]
+func loadSources(path string) ([]ImageSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []ImageSource
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"strings"
+)
+
+// spoolResponseBody truncates f to empty, seeks it to the start and copies
+// r into it, returning the number of bytes written. Truncating first
+// matters for a retried fetch (see fetchImageConditional): without it, a
+// short partial write left by a failed earlier attempt would still be
+// sitting at the front of the file under the new attempt's bytes.
+func spoolResponseBody(f *os.File, r io.Reader) (int64, error) {
+	if err := f.Truncate(0); err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(f, r)
+}
+
+// processSpooledImage validates f's contents and base64-encodes them into a
+// "data:<mime>;base64,..." URI, the -spool counterpart to
+// encodeImageToBase64 that reads from disk instead of an in-memory []byte.
+//
+// Format sniffing only needs a small prefix, and canDecodeToPixels formats
+// (PNG/JPEG/GIF) validate by streaming straight through image.Decode, so
+// neither step needs f's full contents in memory. WebP and SVG are the
+// exception: validateWebP and validateSVG only exist as full-byte-slice/
+// full-document checks (see webp.go and encode.go), so for those two
+// formats processSpooledImage reads f entirely into memory before
+// validating, same as the normal in-memory path would - -spool's saving
+// for them is just skipping the initial buffered download, not the
+// validation step. A JPEG whose streamed image.Decode fails gets the same
+// decodeJPEGConfigOnly header-only fallback as the in-memory path (see
+// jpegfallback.go), re-reading f from the start since DecodeConfig needs
+// its own pass over the headers.
+func processSpooledImage(f *os.File, contentType, forcedMime string, fastValidate bool) (dataURI, mime string, animated, jpegFallback bool, err error) {
+	prefix := make([]byte, 512)
+	n, err := f.ReadAt(prefix, 0)
+	if err != nil && err != io.EOF {
+		return "", "", false, false, err
+	}
+	prefix = prefix[:n]
+
+	mime = resolveMime(prefix, contentType, forcedMime)
+	if forcedMime != "" && !supportedMimes[mime] {
+		return "", "", false, false, fmt.Errorf("encoding image: unsupported forced mime %q", mime)
+	}
+
+	switch mime {
+	case "image/svg+xml", "image/webp":
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return "", "", false, false, err
+		}
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return "", "", false, false, err
+		}
+		if _, _, _, err := sniffAndValidate(data, mime, fastValidate); err != nil {
+			return "", "", false, false, err
+		}
+		if mime == "image/webp" {
+			animated = isAnimatedWebP(data)
+			warnIfAnimated(animated)
+		}
+	default:
+		if looksLikeAntiBotChallengeBody(prefix) {
+			return "", "", false, false, fmt.Errorf("%w: try setting a browser-like -user-agent or a -cookie", ErrAntiBot)
+		}
+		if fastValidate {
+			if err := validateSignature(prefix, mime); err != nil {
+				return "", "", false, false, fmt.Errorf("decoding image: %w", err)
+			}
+		} else if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return "", "", false, false, err
+		} else if _, _, decodeErr := image.Decode(f); decodeErr != nil {
+			recovered := false
+			if mime == "image/jpeg" {
+				if _, err := f.Seek(0, io.SeekStart); err == nil {
+					if _, _, ok := decodeJPEGConfigOnly(f); ok {
+						jpegFallback = logJPEGLenientDecode(true)
+						recovered = true
+					}
+				}
+			}
+			if !recovered {
+				return "", "", false, false, fmt.Errorf("decoding image: %w", decodeErr)
+			}
+		}
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", "", false, false, err
+	}
+	var b strings.Builder
+	b.WriteString("data:")
+	b.WriteString(mime)
+	b.WriteString(";base64,")
+	enc := base64.NewEncoder(base64.StdEncoding, &b)
+	if _, err := io.Copy(enc, f); err != nil {
+		return "", "", false, false, err
+	}
+	if err := enc.Close(); err != nil {
+		return "", "", false, false, err
+	}
+
+	return b.String(), mime, animated, jpegFallback, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun_SpoolProducesSameOutputAsInMemory(t *testing.T) {
+	png := makePNG(4, 3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	wantURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+
+	for _, spool := range []bool{false, true} {
+		out := filepath.Join(t.TempDir(), "README.md")
+		p, err := NewPipeline(Config{
+			URL:       srv.URL,
+			Out:       out,
+			Timeout:   5 * time.Second,
+			UserAgent: defaultUserAgent,
+			Spool:     spool,
+		})
+		if err != nil {
+			t.Fatalf("NewPipeline() error = %v", err)
+		}
+		if err := p.Run(); err != nil {
+			t.Fatalf("Run() (spool=%v) error = %v", spool, err)
+		}
+
+		contents, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(contents), wantURI) {
+			t.Errorf("(spool=%v) output missing expected data URI", spool)
+		}
+	}
+}
+
+func TestPipelineRun_SpoolLeavesNoTempFilesBehind(t *testing.T) {
+	png := makePNG(2, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	before, err := filepath.Glob(filepath.Join(os.TempDir(), "eagleusb-spool-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{URL: srv.URL, Out: out, Timeout: 5 * time.Second, UserAgent: defaultUserAgent, Spool: true})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	after, err := filepath.Glob(filepath.Join(os.TempDir(), "eagleusb-spool-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("spool temp files after Run() = %v, want no more than before (%v)", after, before)
+	}
+}
+
+func TestPipelineRun_SpoolCleansUpTempFileOnDecodeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("not a real png"))
+	}))
+	defer srv.Close()
+
+	before, err := filepath.Glob(filepath.Join(os.TempDir(), "eagleusb-spool-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{URL: srv.URL, Out: out, Timeout: 5 * time.Second, UserAgent: defaultUserAgent, Spool: true})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail decoding a corrupt image")
+	}
+
+	after, err := filepath.Glob(filepath.Join(os.TempDir(), "eagleusb-spool-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("spool temp files after a failed Run() = %v, want none left behind", after)
+	}
+}
+
+func TestCanSpool_FalseWhenAnyRawBytesFeatureIsEnabled(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  Config
+	}{
+		{"strip-exif", Config{Spool: true, StripEXIF: true}},
+		{"optimize", Config{Spool: true, Optimize: true}},
+		{"png-colors", Config{Spool: true, PNGColors: 32}},
+		{"thumbnail-width", Config{Spool: true, ThumbnailWidth: 100}},
+		{"responsive", Config{Spool: true, Responsive: true}},
+		{"embed-buildinfo", Config{Spool: true, EmbedBuildInfo: true}},
+		{"srcset", Config{Spool: true, Srcset: true}},
+		{"picture", Config{Spool: true, Picture: true}},
+		{"cache-bust", Config{Spool: true, CacheBust: true}},
+		{"image-out", Config{Spool: true, ImageOut: "out.png"}},
+		{"og-out", Config{Spool: true, OGOut: "og.jpg"}},
+		{"image-diff-threshold", Config{Spool: true, ImageDiffThreshold: 4}},
+	}
+	for _, c := range cases {
+		p := &Pipeline{Config: c.cfg}
+		if p.canSpool(nil) {
+			t.Errorf("%s: canSpool() = true, want false", c.name)
+		}
+	}
+}
+
+func TestCanSpool_TrueForPlainSpoolConfig(t *testing.T) {
+	p := &Pipeline{Config: Config{Spool: true}}
+	if !p.canSpool(nil) {
+		t.Error("canSpool() = false, want true for a plain -spool config")
+	}
+	if p.canSpool(&imageCache{}) {
+		t.Error("canSpool() = true with a non-nil cache, want false")
+	}
+}
+
+func TestProcessSpooledImage_FastValidateChecksSignatureOnly(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "spool-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte{0xff, 0xd8, 0xff, 0xe0, 0, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	dataURI, mime, _, _, err := processSpooledImage(f, "image/jpeg", "", true)
+	if err != nil {
+		t.Fatalf("processSpooledImage() error = %v", err)
+	}
+	if mime != "image/jpeg" {
+		t.Errorf("mime = %q, want image/jpeg", mime)
+	}
+	if !strings.HasPrefix(dataURI, "data:image/jpeg;base64,") {
+		t.Errorf("dataURI = %q, want a data:image/jpeg;base64,... prefix", dataURI)
+	}
+}
+
+func TestProcessSpooledImage_RejectsCorruptRasterImage(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "spool-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte("definitely not an image")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, _, err := processSpooledImage(f, "image/png", "", false); err == nil {
+		t.Fatal("expected an error decoding corrupt image bytes")
+	}
+}
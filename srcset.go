@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+)
+
+// makeSrcsetVariants decodes body once and returns two PNG data URIs for a
+// Retina-ready srcset (see -srcset): a full-resolution "2x" variant (the
+// image exactly as decoded) and a "1x" variant downscaled to half width,
+// preserving aspect ratio, using the same nearest-neighbor resizeNearest
+// as -thumbnail-width.
+func makeSrcsetVariants(body []byte) (oneXURI, twoXURI string, err error) {
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+
+	half := resizeNearest(img, img.Bounds().Dx()/2)
+
+	var twoXBuf bytes.Buffer
+	if err := png.Encode(&twoXBuf, img); err != nil {
+		return "", "", err
+	}
+	var oneXBuf bytes.Buffer
+	if err := png.Encode(&oneXBuf, half); err != nil {
+		return "", "", err
+	}
+
+	twoXURI = fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(twoXBuf.Bytes()))
+	oneXURI = fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(oneXBuf.Bytes()))
+	return oneXURI, twoXURI, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMakeSrcsetVariants_ReturnsHalfAndFullResolutionPNGs(t *testing.T) {
+	oneX, twoX, err := makeSrcsetVariants(makePNG(40, 20))
+	if err != nil {
+		t.Fatalf("makeSrcsetVariants() error = %v", err)
+	}
+	if !strings.HasPrefix(oneX, "data:image/png;base64,") {
+		t.Errorf("oneX = %q, want a PNG data URI", oneX)
+	}
+	if !strings.HasPrefix(twoX, "data:image/png;base64,") {
+		t.Errorf("twoX = %q, want a PNG data URI", twoX)
+	}
+	if len(oneX) >= len(twoX) {
+		t.Errorf("expected the 1x variant to be smaller than the 2x variant, got len(oneX)=%d len(twoX)=%d", len(oneX), len(twoX))
+	}
+}
+
+func TestPipelineRun_SrcsetEmitsOneXAndTwoXDataURIs(t *testing.T) {
+	srv := sizedPNGServer(t, 40, 20)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Srcset:    true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, rendered, err := p.renderOutput()
+	if err != nil {
+		t.Fatalf("renderOutput() error = %v", err)
+	}
+	if data.Srcset == "" {
+		t.Fatal("expected TemplateData.Srcset to be set")
+	}
+	if !strings.Contains(data.Srcset, " 1x, ") || !strings.HasSuffix(data.Srcset, " 2x") {
+		t.Errorf("Srcset = %q, want a \"...1x, ...2x\" pair", data.Srcset)
+	}
+
+	got := string(rendered)
+	if !strings.Contains(got, `srcset="`) {
+		t.Errorf("rendered = %q, want an <img srcset=...> attribute", got)
+	}
+}
+
+func TestPipelineRun_NoSrcsetOmitsSrcsetAttribute(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	_, rendered, err := p.renderOutput()
+	if err != nil {
+		t.Fatalf("renderOutput() error = %v", err)
+	}
+	if strings.Contains(string(rendered), "srcset=") {
+		t.Errorf("rendered = %q, want no srcset attribute without -srcset", rendered)
+	}
+}
+
+func TestPipelineRun_SrcsetWithUndecodableFormatOmitsAttribute(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/webp")
+		w.Write(makeWebP())
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		Srcset:    true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, _, err := p.renderOutput()
+	if err != nil {
+		t.Fatalf("renderOutput() error = %v", err)
+	}
+	if data.Srcset != "" {
+		t.Errorf("Srcset = %q, want empty for an undecodable format", data.Srcset)
+	}
+}
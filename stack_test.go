@@ -0,0 +1,173 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun_StackFetchesEachMethodInOrder(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Stack:     "albums,artists",
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v", err)
+	}
+
+	if len(data.StackImages) != 2 {
+		t.Fatalf("StackImages = %v, want 2 entries", data.StackImages)
+	}
+	if data.StackImages[0].Method != "albums" || data.StackImages[1].Method != "artists" {
+		t.Fatalf("StackImages order = %+v, want albums then artists", data.StackImages)
+	}
+	for _, img := range data.StackImages {
+		if img.ImageURL == "" {
+			t.Errorf("StackImages[%q].ImageURL is empty", img.Method)
+		}
+	}
+	if data.Images["albums"] == "" || data.Images["artists"] == "" {
+		t.Fatalf("Images = %v, want both methods keyed", data.Images)
+	}
+}
+
+func TestPipelineRun_StackRendersStackedInDefaultTemplate(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Stack:     "albums,artists",
+		Out:       out,
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), "![albums]") || !strings.Contains(string(contents), "![artists]") {
+		t.Fatalf("output = %q, want both stack methods rendered", contents)
+	}
+}
+
+// TestPipelineRun_StackOrderIsDeterministicUnderVariedLatency guards against
+// a concurrent-gather bug where completion order leaks into StackImages:
+// the server deliberately answers the first -stack method slower than the
+// rest, so if the gather step ever appended results as goroutines finished
+// instead of writing each into its own slot by input index, this would
+// catch it by seeing "artists" (or another faster method) ahead of
+// "albums" in StackImages.
+func TestPipelineRun_StackOrderIsDeterministicUnderVariedLatency(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("method") == "albums" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Stack:     "albums,artists,tracks,users",
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v", err)
+	}
+
+	if len(data.StackImages) != 4 {
+		t.Fatalf("StackImages = %v, want 4 entries", data.StackImages)
+	}
+	want := []string{"albums", "artists", "tracks", "users"}
+	for i, method := range want {
+		if data.StackImages[i].Method != method {
+			t.Fatalf("StackImages order = %+v, want %v regardless of which method's fetch finished first", data.StackImages, want)
+		}
+	}
+}
+
+func TestPipelineRun_StackPartialToleratesFailure(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("method") == "artists" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:           srv.URL,
+		Stack:         "albums,artists",
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+		StatusRetries: 0,
+		Partial:       true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v, want nil with -partial", err)
+	}
+	if len(data.StackImages) != 1 || data.StackImages[0].Method != "albums" {
+		t.Fatalf("StackImages = %+v, want only albums to have succeeded", data.StackImages)
+	}
+}
+
+func TestParseFlags_StackRequiresURL(t *testing.T) {
+	if _, err := parseFlags([]string{"-stack", "albums,artists"}); err == nil {
+		t.Fatal("expected an error when -stack is set without -url")
+	}
+}
+
+func TestParseFlags_StackRejectsEmptyMethod(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "https://example.com", "-stack", "albums,,artists"}); err == nil {
+		t.Fatal("expected an error for an empty -stack method")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// statsFileHeader is written once, the first time -stats-file creates its
+// file, naming each column for whatever's charting it.
+const statsFileHeader = "timestamp,width,height,bytes,format,fetch_duration_ms\n"
+
+// RunStats is one row -stats-file appends per run.
+type RunStats struct {
+	Timestamp       time.Time
+	Width           int
+	Height          int
+	Bytes           int
+	Format          string
+	FetchDurationMs int64
+}
+
+// appendRunStats appends one CSV row for stats to path, writing a header
+// first if the file is new or empty. The header (when needed) and the row
+// are issued as a single O_APPEND write, which POSIX guarantees is atomic
+// up to PIPE_BUF bytes - comfortably more than one row needs - so
+// concurrent appends (a -watch loop and a separately cron'd run sharing the
+// same -stats-file) can't interleave into a corrupt line without any extra
+// locking.
+func appendRunStats(path string, stats RunStats) error {
+	needsHeader := true
+	if info, err := os.Stat(path); err == nil {
+		needsHeader = info.Size() == 0
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var line string
+	if needsHeader {
+		line = statsFileHeader
+	}
+	line += fmt.Sprintf("%s,%d,%d,%d,%s,%d\n",
+		stats.Timestamp.UTC().Format(time.RFC3339), stats.Width, stats.Height, stats.Bytes, stats.Format, stats.FetchDurationMs)
+
+	_, err = f.WriteString(line)
+	return err
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendRunStats_WritesHeaderOnFirstCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.csv")
+
+	if err := appendRunStats(path, RunStats{Timestamp: time.Unix(0, 0), Width: 300, Height: 300, Bytes: 1024, Format: "image/png", FetchDurationMs: 42}); err != nil {
+		t.Fatalf("appendRunStats() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines = %v, want a header and one row", lines)
+	}
+	if lines[0] != "timestamp,width,height,bytes,format,fetch_duration_ms" {
+		t.Errorf("header = %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], ",300,300,1024,image/png,42") {
+		t.Errorf("row = %q, want it to end with the stats fields", lines[1])
+	}
+}
+
+func TestAppendRunStats_AppendsWithoutRepeatingHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.csv")
+
+	for i := 0; i < 3; i++ {
+		if err := appendRunStats(path, RunStats{Timestamp: time.Unix(int64(i), 0), Width: 10, Height: 10, Bytes: 1, Format: "image/jpeg"}); err != nil {
+			t.Fatalf("appendRunStats() call %d error = %v", i, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("lines = %v, want 1 header + 3 rows", lines)
+	}
+	if strings.Count(string(data), "timestamp,width,height") != 1 {
+		t.Errorf("header repeated across appends: %q", data)
+	}
+}
+
+func TestPipelineRun_StatsFileRecordsImageCharacteristics(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	statsPath := filepath.Join(t.TempDir(), "stats.csv")
+	p, err := NewPipeline(Config{
+		URL:       srv.URL,
+		Out:       filepath.Join(t.TempDir(), "README.md"),
+		Timeout:   5 * time.Second,
+		UserAgent: defaultUserAgent,
+		StatsFile: statsPath,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(statsPath)
+	if err != nil {
+		t.Fatalf("expected a stats file at %s, err = %v", statsPath, err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines = %v, want a header and one row", lines)
+	}
+	if !strings.Contains(lines[1], "image/png") {
+		t.Errorf("row = %q, want it to mention image/png", lines[1])
+	}
+	if strings.HasSuffix(lines[1], ",0,0,0,,0") {
+		t.Errorf("row = %q, want non-zero width/height/bytes for a real fetch", lines[1])
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate_StrictTemplateFailsOnUndefinedImagesKey(t *testing.T) {
+	dir := t.TempDir()
+	tmplPath := filepath.Join(dir, "out.tmpl")
+	if err := os.WriteFile(tmplPath, []byte(`{{.Images.typo}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "README.md")
+	data := TemplateData{Images: map[string]string{"albums": "abc"}}
+
+	err := renderTemplate(tmplPath, out, data, false, "", "", false, "", true)
+	if err == nil {
+		t.Fatal("expected an error for an undefined .Images key under -strict-template")
+	}
+}
+
+func TestRenderTemplate_NonStrictTemplateRendersNoValue(t *testing.T) {
+	dir := t.TempDir()
+	tmplPath := filepath.Join(dir, "out.tmpl")
+	if err := os.WriteFile(tmplPath, []byte(`{{.Images.typo}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "README.md")
+	data := TemplateData{Images: map[string]string{"albums": "abc"}}
+
+	if err := renderTemplate(tmplPath, out, data, false, "", "", false, "", false); err != nil {
+		t.Fatalf("renderTemplate() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), "<no value>") {
+		t.Errorf("rendered = %q, want it to contain the stdlib's \"<no value>\" placeholder", contents)
+	}
+}
+
+func TestParseFlags_StrictTemplateDefaultsToTrue(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if !cfg.StrictTemplate {
+		t.Error("StrictTemplate default = false, want true")
+	}
+}
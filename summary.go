@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// printRunSummary implements -run-summary for any multi-item fetch path
+// (-grid-config, -stack and -sources-config all build a []fetchResult
+// alongside a matching set of per-item labels and fetch durations, the same
+// shape enforceMaxTotalBytes works against): a small table to stderr naming
+// each item, whether it succeeded, its data URI size or error, and how long
+// its fetch took. A failed item whose error was swallowed by -partial still
+// shows up here with its reason, so -partial's "ok, but degraded" runs
+// aren't silent about what was dropped. It's a no-op unless enabled
+// (-run-summary) is set.
+func printRunSummary(enabled bool, labels []string, results []fetchResult, durations []time.Duration) {
+	if !enabled {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "eagleusb: run summary:")
+	for i, label := range labels {
+		r := results[i]
+		if r.err != nil {
+			fmt.Fprintf(os.Stderr, "  %-24s FAILED  %8s  %s\n", label, durations[i].Round(time.Millisecond), r.err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  %-24s ok      %8s  %d bytes\n", label, durations[i].Round(time.Millisecond), len(r.dataURI))
+	}
+}
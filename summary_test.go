@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun_RunSummaryDoesNotAffectStackOutput(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:        srv.URL,
+		Stack:      "albums,artists",
+		Out:        filepath.Join(t.TempDir(), "README.md"),
+		Timeout:    5 * time.Second,
+		UserAgent:  defaultUserAgent,
+		RunSummary: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	data, err := p.fetchTemplateData()
+	if err != nil {
+		t.Fatalf("fetchTemplateData() error = %v", err)
+	}
+	if len(data.StackImages) != 2 {
+		t.Fatalf("StackImages = %v, want 2 entries", data.StackImages)
+	}
+}
+
+func TestPrintRunSummary_NoopWhenDisabled(t *testing.T) {
+	// Disabled is the only behavior this pure function can usefully be
+	// asserted on without capturing os.Stderr (see budget_test.go for the
+	// same reasoning around enforceMaxTotalBytes's stderr line): it must
+	// not panic or otherwise misbehave on results containing an error.
+	printRunSummary(false, []string{"a"}, []fetchResult{{err: ErrFetch}}, []time.Duration{time.Second})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+)
+
+// maxTargetBytesAttempts caps the binary search in encodeToTargetBytes, so a
+// target far below what even quality 1 produces fails after a bounded
+// number of re-encodes instead of converging step by step down to 1.
+const maxTargetBytesAttempts = 7
+
+// encodeToTargetBytes implements -target-bytes: it re-encodes data as JPEG,
+// binary-searching its quality (1-100) for the highest value whose output
+// still fits within targetBytes, and reports that quality alongside the
+// result. There's no WebP encoder in this tree to binary-search instead
+// (see webp.go), so -target-bytes always re-encodes to JPEG - the same
+// format -og-jpeg-quality already tunes by hand for Open Graph images, just
+// with the quality picked automatically here instead of fixed. A source
+// that was already JPEG is simply re-compressed at the found quality; any
+// other decodable raster format is converted to JPEG along the way.
+//
+// ErrTargetBytes is returned if even quality 1 doesn't fit within
+// maxTargetBytesAttempts re-encodes, which also bounds a generously large
+// targetBytes from wastefully climbing all the way to quality 100 one step
+// at a time.
+func encodeToTargetBytes(data []byte, targetBytes int64) (encoded []byte, quality int, err error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("decoding image for -target-bytes: %w", err)
+	}
+
+	lo, hi := 1, 100
+	for attempt := 0; attempt < maxTargetBytesAttempts && lo <= hi; attempt++ {
+		mid := (lo + hi) / 2
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: mid}); err != nil {
+			return nil, 0, fmt.Errorf("encoding image for -target-bytes: %w", err)
+		}
+		if int64(buf.Len()) <= targetBytes {
+			encoded, quality = buf.Bytes(), mid
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	if encoded == nil {
+		return nil, 0, fmt.Errorf("%w: %d bytes, not even quality 1 fits", ErrTargetBytes, targetBytes)
+	}
+
+	fmt.Fprintf(os.Stderr, "eagleusb: -target-bytes found quality %d (%d bytes, budget %d)\n", quality, len(encoded), targetBytes)
+	return encoded, quality, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEncodeToTargetBytes_FindsQualityWithinBudget(t *testing.T) {
+	encoded, quality, err := encodeToTargetBytes(makeNoisyPNG(64, 64), 4000)
+	if err != nil {
+		t.Fatalf("encodeToTargetBytes() error = %v", err)
+	}
+	if int64(len(encoded)) > 4000 {
+		t.Errorf("len(encoded) = %d, want <= 4000", len(encoded))
+	}
+	if quality < 1 || quality > 100 {
+		t.Errorf("quality = %d, want between 1 and 100", quality)
+	}
+}
+
+func TestEncodeToTargetBytes_ErrorsWhenBudgetUnreachable(t *testing.T) {
+	_, _, err := encodeToTargetBytes(makeNoisyPNG(256, 256), 1)
+	if !errors.Is(err, ErrTargetBytes) {
+		t.Fatalf("error = %v, want it to wrap ErrTargetBytes", err)
+	}
+}
+
+func TestPipelineRun_TargetBytesShrinksEmbeddedImage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(makeNoisyPNG(64, 64))
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:         srv.URL,
+		Out:         filepath.Join(t.TempDir(), "README.md"),
+		Timeout:     5 * time.Second,
+		UserAgent:   defaultUserAgent,
+		TargetBytes: 4000,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+}
+
+func TestParseFlags_TargetBytesRejectsNegative(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-target-bytes", "-1"})
+	if err == nil {
+		t.Fatal("expected an error for a negative -target-bytes")
+	}
+}
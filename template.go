@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// stdioPlaceholder is the conventional "-" argument meaning "use stdin" (for
+// -template) or "use stdout" (for -out). Using the same placeholder for
+// both is safe: they read/write different streams, so piping one tool's
+// stdout into another's stdin via -template - -out - cannot deadlock on its
+// own.
+const stdioPlaceholder = "-"
+
+// TemplateData is the set of values made available to README templates.
+type TemplateData struct {
+	ImageURL string
+
+	// CompareImageURL is set when -compare-url is used, letting a template
+	// render two collage variants side by side. This is primarily an
+	// experimentation aid for tuning layout and is not populated otherwise.
+	CompareImageURL string
+
+	// AlbumCount is the number of album cells populated in the collage, so
+	// templates can flag a thin listening history. It comes from an
+	// X-Album-Count response header when the source provides one, or is
+	// otherwise approximated as -rows * -columns.
+	AlbumCount int
+
+	// Animated is true when the collage is an animated WebP, so templates
+	// can warn that some renderers (including GitHub) may not animate it.
+	Animated bool
+
+	// ThumbnailURL and FullImageURL are both set when -thumbnail-width is
+	// used: the thumbnail is the small embedded preview, and FullImageURL
+	// is what it should link to (the -image-out path, or the full data URI
+	// if -image-out wasn't given). Otherwise both are empty and templates
+	// should just use ImageURL directly.
+	ThumbnailURL string
+	FullImageURL string
+
+	// Picture is true when -picture produced at least one of WebPImageURL
+	// or PNGImageURL, so the template can emit a <picture> element instead
+	// of a plain markdown image. Only one of the two is ever populated in
+	// practice, since converting between WebP and PNG requires a codec this
+	// package doesn't have (see picture.go) — the <picture> element still
+	// degrades correctly to a single <img> in that case.
+	Picture      bool
+	WebPImageURL string
+	PNGImageURL  string
+
+	// Images holds one entry per -sources-config source, keyed by its
+	// "name", so a template can lay several collages out in a grid with
+	// e.g. {{.Images.albums}} and {{.Images.artists}}. -parallel-periods
+	// populates the same map keyed by period instead (e.g.
+	// {{.Images.7day}}). It's nil otherwise.
+	Images map[string]string
+
+	// CacheBustHash is set when -cache-bust is used: a short content hash
+	// of the fetched image, for templates to embed as a comment so tooling
+	// that caches the surrounding markup (not just the data URI) notices
+	// when the image changes. Empty otherwise.
+	CacheBustHash string
+
+	// ImageFormat and ImageHash are the collage's resolved MIME type (e.g.
+	// "image/png") and content hash, set when -embed-buildinfo is used.
+	// ImageHash is computed the same way as CacheBustHash but kept
+	// separate, since the two flags serve different purposes and either
+	// can be used without the other. ImageFormat is also set when
+	// -stats-file is used, without needing -embed-buildinfo too.
+	ImageFormat string
+	ImageHash   string
+
+	// ImageBytes is the fetched image's encoded size in bytes, set only
+	// when -stats-file is used. FetchDurationMs is the primary fetch's
+	// wall-clock duration, always measured (it's cheap) but, like
+	// ImageBytes, only consumed by -stats-file (see statsfile.go) - the
+	// built-in template doesn't reference either.
+	ImageBytes      int
+	FetchDurationMs int64
+
+	// AltText is the localized alt text/caption for the collage, per -lang
+	// (English by default).
+	AltText string
+
+	// Grid holds the -grid-config matrix, one row per period and one column
+	// per method (Grid[row][col]), so a template can lay out a 2D table of
+	// collages with nested {{range}}. It's nil otherwise.
+	Grid [][]string
+
+	// SourceURL is -url with any userinfo and sensitive-looking query
+	// parameters redacted (see redactURL), set only when -fallback-link is
+	// used. The built-in default template links to it beneath the embedded
+	// image, so viewers that don't render inline data URIs (some RSS
+	// readers, for instance) still have a way to reach the live collage.
+	SourceURL string
+
+	// Responsive mirrors -responsive, so the built-in default template
+	// knows to render an <img style="max-width:100%;height:auto"> instead
+	// of plain markdown image syntax. Custom templates can ignore it and
+	// use ImageWidth/ImageHeight directly instead.
+	Responsive bool
+
+	// ImageWidth and ImageHeight are the collage's intrinsic pixel
+	// dimensions, set when -responsive or -embed-buildinfo is used and the
+	// source format decodes to pixels (see canDecodeToPixels); both are 0
+	// for formats this build can only container-validate, like WebP and
+	// SVG.
+	ImageWidth  int
+	ImageHeight int
+
+	// StackImages holds one entry per -stack method, in the order given on
+	// the command line, for the built-in default template to render
+	// vertically stacked without a custom template (the common "top albums
+	// over top artists" layout). Custom templates can range over it
+	// directly, or ignore it and use .Images (keyed the same way, for
+	// random access) instead. Nil when -stack isn't used.
+	StackImages []StackImage
+
+	// Hour is the current local hour (0-23) at generation time, and
+	// Greeting is a short English bucket of it ("Good morning", "Good
+	// afternoon", "Good evening", "Good night") - see greetingForHour. Both
+	// are always populated, primarily for -time-template-config's
+	// time-of-day templates, but available to any template for a lightweight
+	// personalized touch.
+	Hour     int
+	Greeting string
+
+	// Srcset is the img srcset attribute value set when -srcset is used:
+	// a 1x (half-width) and 2x (full-resolution) data URI pair, e.g.
+	// `data:...;base64,AAA... 1x, data:...;base64,BBB... 2x`. Embedding
+	// both roughly 1.25x's the total payload size over a single
+	// full-resolution image, in exchange for crisp rendering on both
+	// standard and Retina displays. Empty when -srcset isn't used or the
+	// source format can't be decoded to pixels (WebP, SVG).
+	Srcset string
+
+	// RunID correlates this run's structured log lines and run-report JSON
+	// (see -run-id and runid.go); templates can surface it too, e.g. as an
+	// HTML comment, for matching a rendered output back to the logs that
+	// produced it.
+	RunID string
+
+	// UpdatedBadgeURL is set when -updated-badge is used: a shields.io
+	// "updated | <date>" badge URL (see updatedBadgeURL in badge.go) a
+	// template can render as e.g. ![updated]({{.UpdatedBadgeURL}}). Empty
+	// otherwise.
+	UpdatedBadgeURL string
+
+	// Custom holds the JSON object read from -data-file, for templates that
+	// need a value this package has no dedicated field for (a tagline, a
+	// project name, a list of links) without recompiling. Accessed like
+	// {{.Custom.tagline}}; nil when -data-file isn't used. See customdata.go.
+	Custom map[string]any
+
+	// Placeholder is always true in the TemplateData passed to
+	// -placeholder-template (see playcountBelowThreshold in playcount.go and
+	// resolveActiveTemplate): -min-playcount found the source reporting
+	// fewer scrobbles than the threshold, so every image-derived field above
+	// is left unset. It's only useful to a -template-dir placeholder that
+	// also serves as a fallback for other conditions; the built-in default
+	// placeholder template doesn't reference it.
+	Placeholder bool
+}
+
+// StackImage is one entry of TemplateData.StackImages: a -stack method
+// (e.g. "albums") and the data URI fetched for it.
+type StackImage struct {
+	Method   string
+	ImageURL string
+
+	// DuplicateOf holds an earlier StackImage's Method when -dedupe finds
+	// this one's ImageURL byte-identical to it, so the default template can
+	// note the duplication instead of re-embedding the same data URI. Empty
+	// when -dedupe isn't set, or this is the first image with that content.
+	// See dedupeStackImages.
+	DuplicateOf string
+}
+
+const defaultTemplate = `### Hi there 👋
+
+{{if .StackImages}}{{range .StackImages}}{{if .DuplicateOf}}_{{.Method}} is identical to {{.DuplicateOf}}_
+
+{{else}}![{{.Method}}]({{.ImageURL}})
+
+{{end}}{{end}}{{else}}{{if .CacheBustHash}}<!-- cache-bust: {{.CacheBustHash}} -->
+{{end}}{{if .Picture}}<picture>
+{{if .WebPImageURL}}  <source srcset="{{.WebPImageURL}}" type="image/webp">
+{{end}}  <img alt="{{.AltText}}" src="{{if .PNGImageURL}}{{.PNGImageURL}}{{else}}{{.ImageURL}}{{end}}"{{if .Srcset}} srcset="{{.Srcset}}"{{end}}{{if .Responsive}} style="max-width:100%;height:auto"{{if .ImageWidth}} width="{{.ImageWidth}}" height="{{.ImageHeight}}"{{end}}{{end}}>
+</picture>
+{{else if .Responsive}}<img alt="{{.AltText}}" src="{{.ImageURL}}"{{if .Srcset}} srcset="{{.Srcset}}"{{end}} style="max-width:100%;height:auto"{{if .ImageWidth}} width="{{.ImageWidth}}" height="{{.ImageHeight}}"{{end}}>
+{{else if .Srcset}}<img alt="{{.AltText}}" src="{{.ImageURL}}" srcset="{{.Srcset}}">
+{{else}}![{{.AltText}}]({{.ImageURL}})
+{{end}}{{end}}{{if .SourceURL}}
+[View the live collage]({{.SourceURL}})
+{{end}}`
+
+// defaultPlaceholderTemplate is rendered by -placeholder-template's built-in
+// fallback (an empty -placeholder-template) when -min-playcount triggers.
+const defaultPlaceholderTemplate = `### Hi there 👋
+
+_Nothing scrobbled yet - check back once there's some listening history!_
+`
+
+// renderTemplate executes the template at templatePath (or the built-in
+// default if templatePath is empty) with data, writing the result to out.
+// Template parsing/loading and execution failures are wrapped in
+// ErrTemplate; failures opening out are wrapped in ErrWrite. strictTemplate
+// sets the "missingkey=error" template option (-strict-template, on by
+// default) so referencing an undefined key of a map field like .Images
+// fails the render instead of silently writing "<no value>".
+func renderTemplate(templatePath, out string, data TemplateData, normalize bool, lineEnding, postCommand string, checkMarkdown bool, buildInfo string, strictTemplate bool) error {
+	tmpl, err := loadTemplate(templatePath)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTemplate, err)
+	}
+	applyStrictTemplateOption(tmpl, strictTemplate)
+	return execTemplate(tmpl, "", out, data, normalize, lineEnding, postCommand, checkMarkdown, buildInfo)
+}
+
+// applyStrictTemplateOption sets tmpl's "missingkey" behavior per
+// -strict-template: "error" so indexing a map field (e.g. .Images.foo for a
+// name absent from -sources-config) fails the render with a clear error,
+// or template/text's own default ("invalid", which renders "<no value>")
+// when strictTemplate is false. A struct field that doesn't exist at all,
+// like a plain typo'd .Foo, is always a hard error regardless of this
+// option - "missingkey" only governs map lookups.
+func applyStrictTemplateOption(tmpl *template.Template, strictTemplate bool) {
+	if strictTemplate {
+		tmpl.Option("missingkey=error")
+	}
+}
+
+// execTemplate executes an already-parsed tmpl, writing the result to out.
+// name selects which associated template to run, for -template-dir sets
+// where the entry point (-template-name) is just one of several parsed
+// templates; an empty name executes tmpl itself, as for a single -template
+// file. *template.Template is safe for concurrent Execute/ExecuteTemplate
+// calls, so a tmpl shared across goroutines (see Pipeline.compiledTemplate)
+// only needs to be parsed once. When normalize is true (-normalize), the
+// rendered output is passed through normalizeText before being written;
+// lineEnding ("lf" or "crlf", see -line-ending) is then applied to the
+// result. When postCommand is non-empty (-post-command), the result is
+// piped through it and its stdout used instead. When buildInfo is non-empty
+// (-embed-buildinfo, see buildInfoComment), it's appended as a trailing
+// line after postCommand runs, so a post-processing script can't strip or
+// rewrite the provenance block it's meant to let tooling verify against.
+// When checkMarkdown is true (-validate-markdown), the final result
+// (including any appended buildInfo) is checked with validateMarkdown
+// before being written.
+//
+// Every step through validateMarkdown runs against an in-memory buffer, and
+// out itself is only ever written once the whole of rendered is known good
+// and complete - via a temp file and rename (see writeFileAtomic), the same
+// pattern replaceTokenInFile uses - so a template error, a failing
+// post-command, or a crash partway through the final write can never leave
+// out holding truncated or partial content.
+func execTemplate(tmpl *template.Template, name, out string, data TemplateData, normalize bool, lineEnding, postCommand string, checkMarkdown bool, buildInfo string) error {
+	rendered, err := renderBytes(tmpl, name, data, normalize, lineEnding, postCommand, checkMarkdown, buildInfo)
+	if err != nil {
+		return err
+	}
+
+	if out == stdioPlaceholder {
+		if _, err := os.Stdout.Write(rendered); err != nil {
+			return fmt.Errorf("%w: %w", ErrWrite, err)
+		}
+		return nil
+	}
+
+	return writeFileAtomic(out, rendered)
+}
+
+// renderBytes executes an already-parsed tmpl and runs it through the same
+// normalize/line-ending/post-command/buildInfo/validate-markdown pipeline
+// execTemplate writes to out with, but returns the bytes instead of writing
+// them anywhere - the shared core behind execTemplate's single -out write
+// and -sink's fan-out to several destinations off one render (see sink.go).
+func renderBytes(tmpl *template.Template, name string, data TemplateData, normalize bool, lineEnding, postCommand string, checkMarkdown bool, buildInfo string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if name == "" {
+		err = tmpl.Execute(&buf, data)
+	} else {
+		err = tmpl.ExecuteTemplate(&buf, name, data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTemplate, err)
+	}
+
+	rendered := buf.Bytes()
+	if normalize {
+		rendered = normalizeText(rendered)
+	}
+	rendered = applyLineEnding(rendered, lineEnding)
+
+	if postCommand != "" {
+		rendered, err = runPostCommand(rendered, postCommand)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if buildInfo != "" {
+		rendered = append(rendered, '\n')
+		rendered = append(rendered, []byte(buildInfo)...)
+		rendered = append(rendered, '\n')
+	}
+
+	if checkMarkdown {
+		if err := validateMarkdown(rendered); err != nil {
+			return nil, err
+		}
+	}
+
+	return rendered, nil
+}
+
+// writeFileAtomic writes data to path via a temp file in the same directory
+// followed by a rename, so a reader can never observe - and a crash or
+// write error can never leave behind - a truncated or partially-written
+// path; the previous contents of path, if any, stay intact until the full
+// write has succeeded. This mirrors replaceTokenInFile's atomic update of
+// an existing file, just without that function's "must already exist with
+// this content" precondition.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		if err := os.Chmod(tmp.Name(), info.Mode()); err != nil {
+			return fmt.Errorf("%w: %w", ErrWrite, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	return nil
+}
+
+func loadTemplate(templatePath string) (*template.Template, error) {
+	if templatePath == "" {
+		return template.New("readme").Parse(defaultTemplate)
+	}
+	return loadTemplateFile(templatePath)
+}
+
+// loadPlaceholderTemplate is loadTemplate's counterpart for
+// -placeholder-template: an empty templatePath falls back to
+// defaultPlaceholderTemplate instead of defaultTemplate.
+func loadPlaceholderTemplate(templatePath string) (*template.Template, error) {
+	if templatePath == "" {
+		return template.New("readme").Parse(defaultPlaceholderTemplate)
+	}
+	return loadTemplateFile(templatePath)
+}
+
+// loadTemplateFile reads and parses the template at templatePath, the
+// shared non-default-case body of loadTemplate and loadPlaceholderTemplate.
+func loadTemplateFile(templatePath string) (*template.Template, error) {
+	var (
+		contents []byte
+		err      error
+	)
+	if templatePath == stdioPlaceholder {
+		contents, err = io.ReadAll(os.Stdin)
+	} else {
+		contents, err = os.ReadFile(templatePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return template.New("readme").Parse(string(contents))
+}
+
+// loadTemplateForConfig resolves cfg's template the way the default
+// fetch+render path and -gist-token do: -template-dir, when set, parses
+// every file in that directory as one associated template.Template set (so
+// one can {{ template "footer" . }} another), and templateName names the
+// entry point to execute, e.g. -template-name's value; otherwise it falls
+// back to single-file loadTemplate(cfg.Template), and templateName is "" to
+// mean "execute tmpl itself". Either way, cfg.StrictTemplate is applied via
+// applyStrictTemplateOption before returning.
+func loadTemplateForConfig(cfg Config) (tmpl *template.Template, templateName string, err error) {
+	if cfg.TemplateDir == "" {
+		tmpl, err = loadTemplate(cfg.Template)
+		if err != nil {
+			return nil, "", err
+		}
+		applyStrictTemplateOption(tmpl, cfg.StrictTemplate)
+		return tmpl, "", nil
+	}
+
+	tmpl, err = template.ParseGlob(filepath.Join(cfg.TemplateDir, "*"))
+	if err != nil {
+		return nil, "", err
+	}
+	if tmpl.Lookup(cfg.TemplateName) == nil {
+		return nil, "", fmt.Errorf("-template-name %q not found in -template-dir %q", cfg.TemplateName, cfg.TemplateDir)
+	}
+	applyStrictTemplateOption(tmpl, cfg.StrictTemplate)
+	return tmpl, cfg.TemplateName, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRenderTemplate_MidRenderErrorLeavesOutUntouched guards against the
+// most dangerous shape of template failure: one that happens after some
+// output has already been produced (here, a typo'd field reference partway
+// through the template, a realistic -template authoring mistake), not at
+// the very first action. execTemplate must still buffer the whole render
+// before touching disk, so an existing out file is left exactly as it was
+// rather than truncated or partially overwritten.
+func TestRenderTemplate_MidRenderErrorLeavesOutUntouched(t *testing.T) {
+	dir := t.TempDir()
+	tmplPath := filepath.Join(dir, "out.tmpl")
+	tmplSrc := "line one\n{{.ImageURL}}\n{{.NoSuchField}}\nline never reached\n"
+	if err := os.WriteFile(tmplPath, []byte(tmplSrc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "README.md")
+	const sentinel = "pre-existing README contents"
+	if err := os.WriteFile(out, []byte(sentinel), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := TemplateData{ImageURL: "data:image/png;base64,AA=="}
+	err := renderTemplate(tmplPath, out, data, false, "", "", false, "", false)
+	if err == nil {
+		t.Fatal("expected an error for a template referencing a nonexistent field")
+	}
+
+	contents, readErr := os.ReadFile(out)
+	if readErr != nil {
+		t.Fatalf("README.md disappeared: %v", readErr)
+	}
+	if string(contents) != sentinel {
+		t.Fatalf("README.md = %q, want it untouched at %q", contents, sentinel)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "out.tmpl" && e.Name() != "README.md" {
+			t.Errorf("leftover temp file %q after a failed render", e.Name())
+		}
+	}
+}
+
+func TestRenderTemplate_MidRenderErrorWritesNothingToFreshOut(t *testing.T) {
+	dir := t.TempDir()
+	tmplPath := filepath.Join(dir, "out.tmpl")
+	tmplSrc := "line one\n{{.NoSuchField}}\n"
+	if err := os.WriteFile(tmplPath, []byte(tmplSrc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "README.md")
+	err := renderTemplate(tmplPath, out, TemplateData{}, false, "", "", false, "", false)
+	if err == nil {
+		t.Fatal("expected an error for a template referencing a nonexistent field")
+	}
+
+	if _, statErr := os.Stat(out); !os.IsNotExist(statErr) {
+		t.Fatalf("expected %s to not exist after a failed render, stat err = %v", out, statErr)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadTemplateForConfig_TemplateDirComposesPartials(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tmpl"), []byte(`{{define "main"}}hi {{template "footer" .}}{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "footer.tmpl"), []byte(`{{define "footer"}}bye{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, name, err := loadTemplateForConfig(Config{TemplateDir: dir, TemplateName: "main"})
+	if err != nil {
+		t.Fatalf("loadTemplateForConfig() error = %v", err)
+	}
+	if name != "main" {
+		t.Errorf("name = %q, want %q", name, "main")
+	}
+
+	var buf strings.Builder
+	if err := tmpl.ExecuteTemplate(&buf, name, TemplateData{}); err != nil {
+		t.Fatalf("ExecuteTemplate() error = %v", err)
+	}
+	if buf.String() != "hi bye" {
+		t.Errorf("rendered = %q, want %q", buf.String(), "hi bye")
+	}
+}
+
+func TestLoadTemplateForConfig_MissingTemplateNameErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tmpl"), []byte(`{{define "main"}}hi{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := loadTemplateForConfig(Config{TemplateDir: dir, TemplateName: "missing"}); err == nil {
+		t.Fatal("expected an error for an unknown -template-name")
+	}
+}
+
+func TestLoadTemplateForConfig_FallsBackToSingleFile(t *testing.T) {
+	tmpl, name, err := loadTemplateForConfig(Config{})
+	if err != nil {
+		t.Fatalf("loadTemplateForConfig() error = %v", err)
+	}
+	if name != "" {
+		t.Errorf("name = %q, want empty for single-file mode", name)
+	}
+	if tmpl == nil {
+		t.Fatal("expected a non-nil default template")
+	}
+}
+
+func TestParseFlags_TemplateNameRequiresTemplateDir(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-template-name", "main"})
+	if err == nil {
+		t.Fatal("expected an error when -template-name is set without -template-dir")
+	}
+}
+
+func TestParseFlags_TemplateDirRequiresTemplateName(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-template-dir", t.TempDir()})
+	if err == nil {
+		t.Fatal("expected an error when -template-dir is set without -template-name")
+	}
+}
+
+func TestParseFlags_TemplateDirAndTemplateAreMutuallyExclusive(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-template-dir", t.TempDir(), "-template-name", "main", "-template", "x.tmpl"})
+	if err == nil {
+		t.Fatal("expected an error when both -template-dir and -template are set")
+	}
+}
+
+func TestPipelineRun_TemplateDirRendersComposedOutput(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tmpl"), []byte(`{{define "main"}}![{{.AltText}}]({{.ImageURL}}) {{template "footer" .}}{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "footer.tmpl"), []byte(`{{define "footer"}}-- generated{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:          srv.URL,
+		Out:          out,
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+		TemplateDir:  dir,
+		TemplateName: "main",
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("reading -out: %v", err)
+	}
+	if !strings.Contains(string(contents), "-- generated") {
+		t.Errorf("rendered output %q, want it to contain the footer partial's text", contents)
+	}
+}
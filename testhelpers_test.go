@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// This file generates small valid fixture images in memory, so tests don't
+// need checked-in binary files. Each function returns a real, decodable
+// image of the given format at the given size, plus a couple of "make it
+// fail" variants for error-path coverage.
+
+// makePNG returns a w x h PNG, a single opaque color.
+func makePNG(w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: 0x20, G: 0x40, B: 0x80, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err) // encoding an in-memory RGBA image never fails
+	}
+	return buf.Bytes()
+}
+
+// makeNoisyPNG returns a w x h PNG with a pseudo-random color per pixel, so
+// it resists PNG's own compression and quantizeToPalette actually shrinks
+// it noticeably, unlike makePNG's single-color fixture.
+func makeNoisyPNG(w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	seed := uint32(1)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			seed = seed*1664525 + 1013904223
+			img.Set(x, y, color.RGBA{R: byte(seed), G: byte(seed >> 8), B: byte(seed >> 16), A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// makeJPEG returns a w x h baseline JPEG, a single opaque color.
+func makeJPEG(w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: 0x80, G: 0x20, B: 0x40, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// makeGIF returns a w x h single-frame GIF, a single opaque color.
+func makeGIF(w, h int) []byte {
+	img := image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.RGBA{R: 0x40, G: 0x80, B: 0x20, A: 0xff}})
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetColorIndex(x, y, 0)
+		}
+	}
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, img, nil); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// makeWebP returns a minimal well-formed RIFF/WEBP container with a single
+// VP8 chunk of dummy payload. This tree has no WebP codec (see webp.go), so
+// it only needs to pass validateWebP, not decode to real pixels.
+func makeWebP() []byte {
+	return buildWebP(riffChunk("VP8 ", []byte{0, 1, 2, 3}))
+}
+
+// makeAnimatedWebP returns a RIFF/WEBP container containing an ANIM chunk,
+// for exercising isAnimatedWebP.
+func makeAnimatedWebP() []byte {
+	return buildWebP(riffChunk("VP8X", make([]byte, 10)), riffChunk("ANIM", []byte{0, 0, 0, 0, 0, 0}))
+}
+
+// corruptPNG returns bytes with a valid PNG signature but truncated/garbage
+// chunk data, for decode error-path tests.
+func corruptPNG() []byte {
+	sig := []byte{0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a}
+	return append(sig, []byte("not actually a valid chunk stream")...)
+}
+
+// corruptJPEG returns bytes with a valid JPEG SOI marker but no further
+// structure, for decode error-path tests.
+func corruptJPEG() []byte {
+	return []byte{0xff, 0xd8, 0xff, 0x00, 0x00, 0x00}
+}
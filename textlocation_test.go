@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuildCollageURL_SetsTextLocation(t *testing.T) {
+	got, err := buildCollageURL("https://example.com/collage", "albums", "7day", "topleft")
+	if err != nil {
+		t.Fatalf("buildCollageURL() error = %v", err)
+	}
+	want := "https://example.com/collage?method=albums&period=7day&textlocation=topleft"
+	if got != want {
+		t.Fatalf("buildCollageURL() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildCollageURL_OmitsTextLocationWhenEmpty(t *testing.T) {
+	got, err := buildCollageURL("https://example.com/collage", "albums", "7day", "")
+	if err != nil {
+		t.Fatalf("buildCollageURL() error = %v", err)
+	}
+	if u, _ := url.Parse(got); u.Query().Has("textlocation") {
+		t.Fatalf("buildCollageURL() = %q, want no textlocation parameter", got)
+	}
+}
+
+func TestValidateTextLocation(t *testing.T) {
+	if err := validateTextLocation(""); err != nil {
+		t.Errorf("validateTextLocation(\"\") error = %v, want nil", err)
+	}
+	if err := validateTextLocation("bottomcentre"); err != nil {
+		t.Errorf("validateTextLocation(\"bottomcentre\") error = %v, want nil", err)
+	}
+	if err := validateTextLocation("sideways"); err == nil {
+		t.Error("validateTextLocation(\"sideways\") error = nil, want an error")
+	}
+}
+
+func TestResolvedTextLocation(t *testing.T) {
+	if got := resolvedTextLocation(Config{TextLocation: "topleft"}); got != "topleft" {
+		t.Errorf("resolvedTextLocation() = %q, want %q", got, "topleft")
+	}
+	if got := resolvedTextLocation(Config{TextLocation: "topleft", NoText: true}); got != "" {
+		t.Errorf("resolvedTextLocation() = %q, want empty with -no-text", got)
+	}
+}
+
+func TestParseFlags_TextLocationAndNoTextAreMutuallyExclusive(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-text-location", "topleft", "-no-text"})
+	if err == nil {
+		t.Fatal("expected an error for -text-location combined with -no-text")
+	}
+}
+
+func TestParseFlags_RejectsUnconventionalTextLocation(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-text-location", "sideways"})
+	if err == nil {
+		t.Fatal("expected an error for an unconventional -text-location")
+	}
+}
+
+func TestPipelineRun_StackSetsTextLocationOnEachURL(t *testing.T) {
+	var gotTextLocation string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTextLocation = r.URL.Query().Get("textlocation")
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(makePNG(1, 1))
+	}))
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:          srv.URL,
+		Stack:        "albums",
+		TextLocation: "topleft",
+		Out:          filepath.Join(t.TempDir(), "README.md"),
+		Timeout:      5 * time.Second,
+		UserAgent:    defaultUserAgent,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if gotTextLocation != "topleft" {
+		t.Errorf("textlocation query param = %q, want %q", gotTextLocation, "topleft")
+	}
+}
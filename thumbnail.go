@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+)
+
+// makeThumbnail decodes body, downscales it to width pixels wide (preserving
+// aspect ratio) and returns it re-encoded as a PNG data URI. SVG and WebP
+// are skipped by the caller, since they aren't stdlib-decodable.
+//
+// Ideally this would use golang.org/x/image/draw's CatmullRom scaler for
+// quality, but that's an extra module dependency this tree can't fetch;
+// nearest-neighbor sampling is the stdlib-only fallback.
+func makeThumbnail(body []byte, width int) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	thumb := resizeNearest(img, width)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, thumb); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+// resizeNearest scales img down to dstWidth pixels wide, preserving aspect
+// ratio, using nearest-neighbor sampling.
+func resizeNearest(img image.Image, dstWidth int) image.Image {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if dstWidth >= srcWidth || dstWidth <= 0 {
+		return img
+	}
+
+	dstHeight := srcHeight * dstWidth / srcWidth
+	if dstHeight <= 0 {
+		dstHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		srcY := bounds.Min.Y + y*srcHeight/dstHeight
+		for x := 0; x < dstWidth; x++ {
+			srcX := bounds.Min.X + x*srcWidth/dstWidth
+			dst.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+	return dst
+}
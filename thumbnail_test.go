@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestResizeNearest_PreservesAspectRatio(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	dst := resizeNearest(src, 20)
+
+	if w := dst.Bounds().Dx(); w != 20 {
+		t.Fatalf("width = %d, want 20", w)
+	}
+	if h := dst.Bounds().Dy(); h != 10 {
+		t.Fatalf("height = %d, want 10", h)
+	}
+}
+
+func TestResizeNearest_NoUpscale(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	dst := resizeNearest(src, 50)
+
+	if dst.Bounds().Dx() != 10 {
+		t.Fatalf("expected no upscale, got width %d", dst.Bounds().Dx())
+	}
+}
+
+func TestMakeThumbnail_ReturnsPNGDataURI(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	img.Set(0, 0, color.White)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	uri, err := makeThumbnail(buf.Bytes(), 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	const wantPrefix = "data:image/png;base64,"
+	if len(uri) <= len(wantPrefix) || uri[:len(wantPrefix)] != wantPrefix {
+		t.Fatalf("data URI = %q, want prefix %q", uri, wantPrefix)
+	}
+}
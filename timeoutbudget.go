@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTimeoutBudget is -timeout-budget's default: the large majority of
+// -timeout goes to the network fetch, since that's the stage most exposed to
+// a slow or hanging remote, leaving a reserve so a fetch that ran right up
+// to its own slice of the deadline can't also starve the time needed to
+// write -out.
+const defaultTimeoutBudget = "fetch=70,write=30"
+
+// timeoutBudget is -timeout-budget parsed into percentages of -timeout,
+// keyed by stage name.
+type timeoutBudget map[string]int
+
+// parseTimeoutBudget parses a "stage=percent,..." string like
+// "fetch=70,write=30" into a timeoutBudget. Only "fetch" and "write" are
+// recognized: decode, encode, and template rendering happen synchronously in
+// between and have no context to cancel, so there's nothing for a separate
+// budget entry to bound.
+func parseTimeoutBudget(s string) (timeoutBudget, error) {
+	budget := make(timeoutBudget)
+	total := 0
+	for _, part := range strings.Split(s, ",") {
+		stage, pctStr, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("-timeout-budget entry %q must be stage=percent", part)
+		}
+		stage = strings.TrimSpace(stage)
+		if stage != "fetch" && stage != "write" {
+			return nil, fmt.Errorf("-timeout-budget stage %q must be \"fetch\" or \"write\"", stage)
+		}
+		if _, dup := budget[stage]; dup {
+			return nil, fmt.Errorf("-timeout-budget stage %q given more than once", stage)
+		}
+		pct, err := strconv.Atoi(strings.TrimSpace(pctStr))
+		if err != nil || pct <= 0 {
+			return nil, fmt.Errorf("-timeout-budget percent for %q must be a positive integer, got %q", stage, pctStr)
+		}
+		budget[stage] = pct
+		total += pct
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("-timeout-budget percentages must sum to 100, got %d", total)
+	}
+	return budget, nil
+}
+
+// fetchTimeout is the slice of overall allocated to the fetch stage, e.g.
+// 70% of a 10s -timeout is 7s for the default budget.
+func (b timeoutBudget) fetchTimeout(overall time.Duration) time.Duration {
+	return overall * time.Duration(b["fetch"]) / 100
+}
+
+// budget parses p.Config.TimeoutBudget, falling back to the default split if
+// it's somehow invalid despite parseFlags validating it up front (e.g. a
+// Config built directly by a test or library caller, bypassing parseFlags).
+func (p *Pipeline) budget() timeoutBudget {
+	if b, err := parseTimeoutBudget(p.Config.TimeoutBudget); err == nil {
+		return b
+	}
+	b, _ := parseTimeoutBudget(defaultTimeoutBudget)
+	return b
+}
+
+// exceeded reports whether overall's full budget (fetch and write combined)
+// has already elapsed since start. It's checked once, right before the
+// write stage, so a fetch that consumed its own slice plus whatever slack
+// was left over doesn't also silently eat into -git-commit or -post-command
+// with no deadline in sight; a write is comparatively fast local I/O with no
+// natural cancellation point, so the best this package can offer is failing
+// fast instead of attempting it at all once the overall budget is spent.
+func (b timeoutBudget) exceeded(start time.Time, overall time.Duration) bool {
+	return time.Since(start) >= overall
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseTimeoutBudget_Default(t *testing.T) {
+	budget, err := parseTimeoutBudget(defaultTimeoutBudget)
+	if err != nil {
+		t.Fatalf("parseTimeoutBudget(%q) error = %v", defaultTimeoutBudget, err)
+	}
+	if budget.fetchTimeout(10*time.Second) != 7*time.Second {
+		t.Errorf("fetchTimeout(10s) = %v, want 7s", budget.fetchTimeout(10*time.Second))
+	}
+}
+
+func TestParseTimeoutBudget_RejectsUnknownStage(t *testing.T) {
+	if _, err := parseTimeoutBudget("fetch=70,decode=30"); err == nil {
+		t.Fatal("expected an error for an unrecognized stage")
+	}
+}
+
+func TestParseTimeoutBudget_RejectsNonRoundPercentages(t *testing.T) {
+	if _, err := parseTimeoutBudget("fetch=70,write=20"); err == nil {
+		t.Fatal("expected an error when percentages don't sum to 100")
+	}
+}
+
+func TestParseTimeoutBudget_RejectsDuplicateStage(t *testing.T) {
+	if _, err := parseTimeoutBudget("fetch=50,fetch=50"); err == nil {
+		t.Fatal("expected an error for a stage listed twice")
+	}
+}
+
+func TestParseTimeoutBudget_RejectsMalformedEntry(t *testing.T) {
+	if _, err := parseTimeoutBudget("fetch"); err == nil {
+		t.Fatal("expected an error for an entry missing '='")
+	}
+}
+
+func TestParseFlags_TimeoutBudgetRejectsInvalidSplit(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "https://example.com", "-timeout-budget", "fetch=50"}); err == nil {
+		t.Fatal("expected -timeout-budget validation to fail at config-parse time")
+	}
+}
+
+func TestTimeoutBudget_Exceeded(t *testing.T) {
+	budget, err := parseTimeoutBudget(defaultTimeoutBudget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(-20 * time.Millisecond)
+	if !budget.exceeded(start, 10*time.Millisecond) {
+		t.Error("exceeded() = false, want true once overall has fully elapsed")
+	}
+	if budget.exceeded(time.Now(), time.Minute) {
+		t.Error("exceeded() = true, want false well within the overall budget")
+	}
+}
+
+func TestPipelineRun_TimeoutBudgetExhaustedBeforeWriteFailsFast(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:           srv.URL,
+		Out:           out,
+		Timeout:       1 * time.Nanosecond,
+		UserAgent:     defaultUserAgent,
+		TimeoutBudget: defaultTimeoutBudget,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail once the overall -timeout is effectively zero")
+	}
+}
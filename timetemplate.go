@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// TimeTemplateWindow names the -template to use during one time-of-day
+// window, for -time-template-config. Start and End are 24h local clock
+// times formatted "HH:MM"; End may be earlier than Start for a window that
+// wraps past midnight (e.g. "22:00" to "06:00" for a night template).
+type TimeTemplateWindow struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Template string `json:"template"`
+}
+
+// loadTimeTemplateConfig reads a JSON array of TimeTemplateWindow from path,
+// e.g.:
+//
+//	[{"start": "06:00", "end": "12:00", "template": "morning.tmpl"},
+//	 {"start": "18:00", "end": "23:00", "template": "evening.tmpl"}]
+func loadTimeTemplateConfig(path string) ([]TimeTemplateWindow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var windows []TimeTemplateWindow
+	if err := json.Unmarshal(data, &windows); err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// selectTimeTemplate returns the Template of the first window in windows
+// that contains now's local time, or "" if none does (meaning the caller
+// should fall back to -template). Windows are checked in order, so an
+// overlap is resolved by whichever window was listed first.
+func selectTimeTemplate(windows []TimeTemplateWindow, now time.Time) (string, error) {
+	nowMinutes := now.Hour()*60 + now.Minute()
+	for _, w := range windows {
+		start, err := parseClockMinutes(w.Start)
+		if err != nil {
+			return "", fmt.Errorf("invalid start %q: %w", w.Start, err)
+		}
+		end, err := parseClockMinutes(w.End)
+		if err != nil {
+			return "", fmt.Errorf("invalid end %q: %w", w.End, err)
+		}
+		if clockInWindow(nowMinutes, start, end) {
+			return w.Template, nil
+		}
+	}
+	return "", nil
+}
+
+// clockInWindow reports whether now (minutes since local midnight) falls in
+// [start, end), handling a window that wraps past midnight (start > end).
+func clockInWindow(now, start, end int) bool {
+	if start <= end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// parseClockMinutes parses a 24h "HH:MM" clock time into minutes since
+// midnight.
+func parseClockMinutes(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// greetingForHour buckets hour (0-23, local time) into a short English
+// greeting, exposed to templates as TemplateData.Greeting. It's not
+// localized via captionCatalog since it's meant as a casual, personalized
+// touch rather than the collage's formal alt text.
+func greetingForHour(hour int) string {
+	switch {
+	case hour >= 5 && hour < 12:
+		return "Good morning"
+	case hour >= 12 && hour < 17:
+		return "Good afternoon"
+	case hour >= 17 && hour < 21:
+		return "Good evening"
+	default:
+		return "Good night"
+	}
+}
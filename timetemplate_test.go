@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectTimeTemplate_PicksMatchingWindow(t *testing.T) {
+	windows := []TimeTemplateWindow{
+		{Start: "06:00", End: "12:00", Template: "morning.tmpl"},
+		{Start: "12:00", End: "18:00", Template: "afternoon.tmpl"},
+	}
+	now := time.Date(2026, 1, 1, 9, 30, 0, 0, time.UTC)
+	got, err := selectTimeTemplate(windows, now)
+	if err != nil {
+		t.Fatalf("selectTimeTemplate() error = %v", err)
+	}
+	if got != "morning.tmpl" {
+		t.Errorf("selectTimeTemplate() = %q, want %q", got, "morning.tmpl")
+	}
+}
+
+func TestSelectTimeTemplate_NoMatchReturnsEmpty(t *testing.T) {
+	windows := []TimeTemplateWindow{{Start: "06:00", End: "12:00", Template: "morning.tmpl"}}
+	now := time.Date(2026, 1, 1, 15, 0, 0, 0, time.UTC)
+	got, err := selectTimeTemplate(windows, now)
+	if err != nil {
+		t.Fatalf("selectTimeTemplate() error = %v", err)
+	}
+	if got != "" {
+		t.Errorf("selectTimeTemplate() = %q, want empty outside every window", got)
+	}
+}
+
+func TestSelectTimeTemplate_HandlesWraparoundWindow(t *testing.T) {
+	windows := []TimeTemplateWindow{{Start: "22:00", End: "06:00", Template: "night.tmpl"}}
+	for _, hour := range []int{23, 2, 5} {
+		now := time.Date(2026, 1, 1, hour, 0, 0, 0, time.UTC)
+		got, err := selectTimeTemplate(windows, now)
+		if err != nil {
+			t.Fatalf("selectTimeTemplate() error = %v", err)
+		}
+		if got != "night.tmpl" {
+			t.Errorf("hour %d: selectTimeTemplate() = %q, want %q", hour, got, "night.tmpl")
+		}
+	}
+}
+
+func TestSelectTimeTemplate_InvalidClockErrors(t *testing.T) {
+	windows := []TimeTemplateWindow{{Start: "not-a-time", End: "06:00", Template: "night.tmpl"}}
+	if _, err := selectTimeTemplate(windows, time.Now()); err == nil {
+		t.Fatal("expected an error for an invalid start time")
+	}
+}
+
+func TestGreetingForHour(t *testing.T) {
+	cases := map[int]string{
+		6:  "Good morning",
+		13: "Good afternoon",
+		19: "Good evening",
+		2:  "Good night",
+	}
+	for hour, want := range cases {
+		if got := greetingForHour(hour); got != want {
+			t.Errorf("greetingForHour(%d) = %q, want %q", hour, got, want)
+		}
+	}
+}
+
+func TestParseFlags_TimeTemplateConfigAndTemplateDirAreMutuallyExclusive(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-time-template-config", "x.json", "-template-dir", t.TempDir(), "-template-name", "main"})
+	if err == nil {
+		t.Fatal("expected an error when both -time-template-config and -template-dir are set")
+	}
+}
+
+func TestPipelineRun_TimeTemplateConfigSelectsWindowAndExposesGreeting(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	tmplPath := filepath.Join(dir, "custom.tmpl")
+	if err := os.WriteFile(tmplPath, []byte(`{{.Greeting}}, hour {{.Hour}}: ![{{.AltText}}]({{.ImageURL}})`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	configPath := filepath.Join(dir, "time-template.json")
+	windows := []TimeTemplateWindow{{
+		Start:    "00:00",
+		End:      "23:59",
+		Template: tmplPath,
+	}}
+	body, err := json.Marshal(windows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:                srv.URL,
+		Out:                out,
+		Timeout:            5 * time.Second,
+		UserAgent:          defaultUserAgent,
+		TimeTemplateConfig: configPath,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("reading -out: %v", err)
+	}
+	wantHour := greetingForHour(now.Hour())
+	if !strings.Contains(string(contents), wantHour) {
+		t.Errorf("rendered output %q, want it to contain %q", contents, wantHour)
+	}
+}
+
+func TestPipelineRun_TimeTemplateConfigFallsBackOutsideEveryWindow(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "time-template.json")
+	// An impossible window (start == end selects nothing) so every run
+	// falls back to the built-in default template.
+	windows := []TimeTemplateWindow{{Start: "00:00", End: "00:00", Template: filepath.Join(dir, "never.tmpl")}}
+	body, err := json.Marshal(windows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:                srv.URL,
+		Out:                out,
+		Timeout:            5 * time.Second,
+		UserAgent:          defaultUserAgent,
+		TimeTemplateConfig: configPath,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want the built-in default template used as a fallback", err)
+	}
+
+	if _, err := os.Stat(out); err != nil {
+		t.Fatalf("expected -out to be written: %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoredToken_Expired(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		tok  storedToken
+		want bool
+	}{
+		{"no access token", storedToken{}, true},
+		{"no expiry set", storedToken{AccessToken: "a"}, false},
+		{"expires later", storedToken{AccessToken: "a", ExpiresAt: now.Add(time.Hour)}, false},
+		{"within skew", storedToken{AccessToken: "a", ExpiresAt: now.Add(30 * time.Second)}, true},
+		{"already expired", storedToken{AccessToken: "a", ExpiresAt: now.Add(-time.Minute)}, true},
+	}
+	for _, c := range cases {
+		if got := c.tok.expired(now, tokenRefreshSkew); got != c.want {
+			t.Errorf("%s: expired() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSaveLoadTokenStore_RoundTripsAndRestrictsPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "token.json")
+	tok := storedToken{AccessToken: "abc", RefreshToken: "def", ExpiresAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	if err := saveTokenStore(path, tok); err != nil {
+		t.Fatalf("saveTokenStore() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("token file mode = %o, want 0600", perm)
+	}
+
+	got, err := loadTokenStore(path)
+	if err != nil {
+		t.Fatalf("loadTokenStore() error = %v", err)
+	}
+	if got != tok {
+		t.Errorf("loadTokenStore() = %+v, want %+v", got, tok)
+	}
+}
+
+func TestResolveAccessToken_EmptyPathReturnsNoToken(t *testing.T) {
+	token, err := resolveAccessToken("", "", time.Now())
+	if err != nil || token != "" {
+		t.Errorf("resolveAccessToken() = (%q, %v), want (\"\", nil)", token, err)
+	}
+}
+
+func TestResolveAccessToken_ReturnsStoredTokenWhenStillValid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := saveTokenStore(path, storedToken{AccessToken: "fresh", ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := resolveAccessToken(path, "", now)
+	if err != nil {
+		t.Fatalf("resolveAccessToken() error = %v", err)
+	}
+	if token != "fresh" {
+		t.Errorf("resolveAccessToken() = %q, want %q", token, "fresh")
+	}
+}
+
+func TestResolveAccessToken_MissingFileWithoutRefreshCommandErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+
+	_, err := resolveAccessToken(path, "", time.Now())
+	if err == nil {
+		t.Fatal("expected an error for a missing token with no -auth-refresh-command")
+	}
+}
+
+func TestResolveAccessToken_RefreshesExpiredTokenAndPersistsResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := saveTokenStore(path, storedToken{AccessToken: "stale", RefreshToken: "refresh-me", ExpiresAt: now.Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	refreshCommand := `printf '{"access_token":"new-%s","expires_in":3600}' "$1"`
+	token, err := resolveAccessToken(path, refreshCommand, now)
+	if err != nil {
+		t.Fatalf("resolveAccessToken() error = %v", err)
+	}
+	if token != "new-refresh-me" {
+		t.Errorf("resolveAccessToken() = %q, want %q", token, "new-refresh-me")
+	}
+
+	persisted, err := loadTokenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if persisted.AccessToken != "new-refresh-me" {
+		t.Errorf("persisted access token = %q, want %q", persisted.AccessToken, "new-refresh-me")
+	}
+	if persisted.RefreshToken != "refresh-me" {
+		t.Errorf("persisted refresh token = %q, want the refresh token preserved since the command didn't rotate it", persisted.RefreshToken)
+	}
+	if !persisted.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("persisted expiry = %v, want %v", persisted.ExpiresAt, now.Add(time.Hour))
+	}
+}
+
+func TestResolveAccessToken_RefreshCommandFailureReturnsError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+
+	_, err := resolveAccessToken(path, `echo 'not json' >&2; exit 1`, time.Now())
+	if err == nil {
+		t.Fatal("expected an error when -auth-refresh-command exits non-zero")
+	}
+}
+
+func TestPipelineRun_AuthTokenFileSendsBearerHeader(t *testing.T) {
+	png := makePNG(1, 1)
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token.json")
+	if err := saveTokenStore(tokenPath, storedToken{AccessToken: "s3cr3t"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:           srv.URL,
+		Out:           out,
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+		AuthTokenFile: tokenPath,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if gotAuth != "Bearer s3cr3t" {
+		t.Errorf("Authorization header = %q, want %q", gotAuth, "Bearer s3cr3t")
+	}
+}
+
+func TestPipelineRun_ExpiredAuthTokenWithoutRefreshCommandFails(t *testing.T) {
+	png := makePNG(1, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token.json")
+	if err := saveTokenStore(tokenPath, storedToken{AccessToken: "stale", ExpiresAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:           srv.URL,
+		Out:           out,
+		Timeout:       5 * time.Second,
+		UserAgent:     defaultUserAgent,
+		AuthTokenFile: tokenPath,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail with an expired token and no -auth-refresh-command")
+	}
+}
+
+func TestParseFlags_AuthRefreshCommandRequiresTokenFile(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-auth-refresh-command", "echo hi"})
+	if err == nil {
+		t.Fatal("expected an error for -auth-refresh-command without -auth-token-file")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"time"
+)
+
+// fetchTiming breaks down where time went during a single HTTP round trip,
+// for -trace. Zero value means tracing wasn't requested (or a phase was
+// skipped, e.g. DNS for an address already cached by the resolver).
+type fetchTiming struct {
+	DNS          time.Duration `json:"dnsMs,omitempty"`
+	Connect      time.Duration `json:"connectMs,omitempty"`
+	TLSHandshake time.Duration `json:"tlsHandshakeMs,omitempty"`
+	TTFB         time.Duration `json:"ttfbMs,omitempty"`
+}
+
+// withClientTrace attaches an httptrace.ClientTrace to ctx that records each
+// phase's duration into timing as the request progresses. The caller must
+// keep timing alive until the round trip completes.
+func withClientTrace(ctx context.Context, timing *fetchTiming) context.Context {
+	var start, dnsStart, connectStart, tlsStart time.Time
+	start = time.Now()
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			timing.DNS = time.Since(dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			connectStart = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			timing.Connect = time.Since(connectStart)
+		},
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			timing.TLSHandshake = time.Since(tlsStart)
+		},
+		GotFirstResponseByte: func() {
+			timing.TTFB = time.Since(start)
+		},
+	}
+	return httptrace.WithClientTrace(ctx, trace)
+}
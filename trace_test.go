@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithClientTrace_RecordsTTFB(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var timing fetchTiming
+	ctx := withClientTrace(context.Background(), &timing)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if timing.TTFB < 5*time.Millisecond {
+		t.Fatalf("TTFB = %v, want at least 5ms", timing.TTFB)
+	}
+}
+
+func TestDoFetch_TraceFalseLeavesTimingZero(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	_, _, _, timing, _, err := doFetch(context.Background(), srv.Client(), srv.URL, defaultUserAgent, "", "", "", false, 0, "", nil, "")
+	if err != nil {
+		t.Fatalf("doFetch() error = %v", err)
+	}
+	if timing != (fetchTiming{}) {
+		t.Fatalf("timing = %+v, want zero value when trace is false", timing)
+	}
+}
+
+func TestPipelineRun_TraceIncludesTimingInRunReport(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:              srv.URL,
+		Out:              out,
+		Timeout:          5 * time.Second,
+		UserAgent:        defaultUserAgent,
+		OutputStdoutJSON: true,
+		Trace:            true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	runErr := p.Run()
+	w.Close()
+	os.Stdout = oldStdout
+	if runErr != nil {
+		t.Fatalf("Run() error = %v", runErr)
+	}
+
+	var captured bytes.Buffer
+	if _, err := captured.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+
+	var report RunReport
+	if err := json.Unmarshal(captured.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal stdout: %v; stdout = %q", err, captured.String())
+	}
+	if report.Trace == nil {
+		t.Fatal("expected a non-nil Trace field when -trace is set")
+	}
+}
+
+func TestPipelineRun_NoTraceOmitsTimingFromRunReport(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "README.md")
+	p, err := NewPipeline(Config{
+		URL:              srv.URL,
+		Out:              out,
+		Timeout:          5 * time.Second,
+		UserAgent:        defaultUserAgent,
+		OutputStdoutJSON: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	runErr := p.Run()
+	w.Close()
+	os.Stdout = oldStdout
+	if runErr != nil {
+		t.Fatalf("Run() error = %v", runErr)
+	}
+
+	var captured bytes.Buffer
+	if _, err := captured.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(captured.Bytes(), []byte("\"trace\"")) {
+		t.Fatalf("expected no \"trace\" key without -trace, got %q", captured.String())
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// unixURLPrefix marks a source URL that should be fetched over a Unix
+// domain socket, e.g. "unix:///var/run/collage.sock:/collage?rows=3". The
+// path after the socket path (and its own leading ":") is the HTTP request
+// path sent once connected.
+const unixURLPrefix = "unix://"
+
+// resolveSourceURL rewrites a "unix://" URL into a plain http:// URL
+// (suitable for http.NewRequest) plus the socket path to dial, so the rest
+// of the fetch pipeline never needs to know the transport differs. Any
+// other scheme is returned unchanged with ok=false.
+func resolveSourceURL(rawURL string) (httpURL, socketPath string, ok bool) {
+	if !strings.HasPrefix(rawURL, unixURLPrefix) {
+		return rawURL, "", false
+	}
+
+	rest := strings.TrimPrefix(rawURL, unixURLPrefix)
+	socketPath, path, _ := strings.Cut(rest, ":")
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return "http://unix" + path, socketPath, true
+}
+
+// unixTransport returns an http.Transport whose connections are dialed
+// against the given Unix domain socket regardless of the request's host.
+func unixTransport(socketPath string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestResolveSourceURL(t *testing.T) {
+	httpURL, sock, ok := resolveSourceURL("unix:///var/run/collage.sock:/collage?rows=3")
+	if !ok {
+		t.Fatal("expected ok=true for a unix:// URL")
+	}
+	if sock != "/var/run/collage.sock" {
+		t.Fatalf("sock = %q, want /var/run/collage.sock", sock)
+	}
+	if httpURL != "http://unix/collage?rows=3" {
+		t.Fatalf("httpURL = %q, want http://unix/collage?rows=3", httpURL)
+	}
+}
+
+func TestResolveSourceURL_NonUnix(t *testing.T) {
+	_, _, ok := resolveSourceURL("https://example.com/collage")
+	if ok {
+		t.Fatal("expected ok=false for a non-unix URL")
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// urlTemplatePlaceholder matches a `{name}` placeholder in -url-template.
+var urlTemplatePlaceholder = regexp.MustCompile(`\{([a-zA-Z0-9_]+)\}`)
+
+// resolveURLTemplate substitutes every `{name}` placeholder in tmpl with
+// values[name], URL-query-escaped so a value containing "&", "?" or spaces
+// (a username with a space, say) can't reshape the surrounding query
+// string. It's how -url-template generalizes buildCollageURL beyond
+// songstitch's exact query parameter names to an arbitrary provider: the
+// caller supplies the whole URL shape, this just fills it in. Returns an
+// error naming the placeholder if tmpl references one not present in
+// values, so a typo'd or unsupported placeholder fails the run instead of
+// being sent to the source literally.
+func resolveURLTemplate(tmpl string, values map[string]string) (string, error) {
+	var unknown string
+	resolved := urlTemplatePlaceholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		name := m[1 : len(m)-1]
+		v, ok := values[name]
+		if !ok {
+			unknown = name
+			return m
+		}
+		return url.QueryEscape(v)
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("-url-template references unsupported placeholder {%s}; supported placeholders: username, period, rows, columns", unknown)
+	}
+	return resolved, nil
+}
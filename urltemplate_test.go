@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestResolveURLTemplate_SubstitutesAndEscapesPlaceholders(t *testing.T) {
+	got, err := resolveURLTemplate("https://example.com/collage/{username}?rows={rows}&columns={columns}&period={period}", map[string]string{
+		"username": "jane doe",
+		"period":   "7day",
+		"rows":     "3",
+		"columns":  "4",
+	})
+	if err != nil {
+		t.Fatalf("resolveURLTemplate() error = %v", err)
+	}
+	want := "https://example.com/collage/jane+doe?rows=3&columns=4&period=7day"
+	if got != want {
+		t.Errorf("resolveURLTemplate() = %q, want %q", got, want)
+	}
+}
+
+func TestResolveURLTemplate_RejectsUnsupportedPlaceholder(t *testing.T) {
+	_, err := resolveURLTemplate("https://example.com/{notsupported}", map[string]string{"username": "jane"})
+	if err == nil {
+		t.Fatal("expected an error for an unsupported placeholder")
+	}
+}
+
+func TestParseFlags_URLTemplateBuildsURL(t *testing.T) {
+	cfg, err := parseFlags([]string{
+		"-url-template", "https://example.com/collage/{username}?period={period}",
+		"-username", "jane",
+		"-period", "7day",
+	})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	want := "https://example.com/collage/jane?period=7day"
+	if cfg.URL != want {
+		t.Errorf("cfg.URL = %q, want %q", cfg.URL, want)
+	}
+}
+
+func TestParseFlags_URLAndURLTemplateAreMutuallyExclusive(t *testing.T) {
+	_, err := parseFlags([]string{"-url", "https://example.com", "-url-template", "https://example.com/{username}"})
+	if err == nil {
+		t.Fatal("expected an error when both -url and -url-template are set")
+	}
+}
+
+func TestParseFlags_URLTemplateRejectsUnsupportedPlaceholder(t *testing.T) {
+	_, err := parseFlags([]string{"-url-template", "https://example.com/{bogus}"})
+	if err == nil {
+		t.Fatal("expected an error for an unsupported -url-template placeholder")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// userAgentRotator cycles through a list of User-Agent strings round-robin,
+// one per fetch, to avoid being fingerprinted by a single fixed value.
+// Falling back to a single fixed string (the common case) is just a
+// one-element rotator.
+type userAgentRotator struct {
+	agents []string
+	next   uint32
+}
+
+// newUserAgentRotator builds a rotator from file (one User-Agent per line),
+// falling back to single if the file is empty.
+func newUserAgentRotator(file, single string) (*userAgentRotator, error) {
+	if file == "" {
+		return &userAgentRotator{agents: []string{single}}, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading -user-agent-file: %w", err)
+	}
+	defer f.Close()
+
+	var agents []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			agents = append(agents, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading -user-agent-file: %w", err)
+	}
+	if len(agents) == 0 {
+		agents = []string{single}
+	}
+
+	return &userAgentRotator{agents: agents}, nil
+}
+
+// next returns the next User-Agent in round-robin order.
+func (r *userAgentRotator) Next() string {
+	i := atomic.AddUint32(&r.next, 1) - 1
+	return r.agents[i%uint32(len(r.agents))]
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUserAgentRotator_FallsBackToSingle(t *testing.T) {
+	r, err := newUserAgentRotator("", "fixed-ua")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if got := r.Next(); got != "fixed-ua" {
+			t.Fatalf("Next() = %q, want %q", got, "fixed-ua")
+		}
+	}
+}
+
+func TestUserAgentRotator_RoundRobinsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uas.txt")
+	if err := os.WriteFile(path, []byte("ua-a\nua-b\n\nua-c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := newUserAgentRotator(path, "fixed-ua")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"ua-a", "ua-b", "ua-c", "ua-a"}
+	for i, w := range want {
+		if got := r.Next(); got != w {
+			t.Fatalf("Next() #%d = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestNewUserAgentRotator_MissingFileErrors(t *testing.T) {
+	if _, err := newUserAgentRotator(filepath.Join(t.TempDir(), "missing.txt"), "fixed-ua"); err == nil {
+		t.Fatal("expected error for missing -user-agent-file")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunValidateOnly_ValidImage(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	if !runValidateOnly(Config{URL: srv.URL, UserAgent: defaultUserAgent, Accept: "image/png", Timeout: 5 * time.Second}) {
+		t.Fatal("expected a valid result")
+	}
+}
+
+func TestRunValidateOnly_TruncatedBodyIsInvalid(t *testing.T) {
+	png := makePNG(4, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", "99999")
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	if runValidateOnly(Config{URL: srv.URL, UserAgent: defaultUserAgent, Accept: "image/png", Timeout: 5 * time.Second}) {
+		t.Fatal("expected an invalid result for a body shorter than its declared Content-Length")
+	}
+}
+
+func TestRunValidateOnly_NonImageContentIsInvalid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	}))
+	defer srv.Close()
+
+	if runValidateOnly(Config{URL: srv.URL, UserAgent: defaultUserAgent, Accept: "image/png", Timeout: 5 * time.Second}) {
+		t.Fatal("expected an invalid result for non-image content")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// watermarkPositions are -watermark-position's valid values; watermarkOrigin
+// maps each to a corner (or the center) of the image.
+var watermarkPositions = map[string]bool{
+	"top-left":     true,
+	"top-right":    true,
+	"bottom-left":  true,
+	"bottom-right": true,
+	"center":       true,
+}
+
+// watermarkMargin is how far, in pixels, a corner-anchored watermark sits
+// from the edges it's anchored to.
+const watermarkMargin = 8
+
+// WatermarkProcessor returns an ImageProcessor (see processor.go) that
+// overlays text onto the image using font5x7, the same bundled bitmap font
+// -og-out's text overlay uses (see ogimage.go) - this tree has no
+// golang.org/x/image/font dependency to render anything richer. opacity
+// (0-1) alpha-blends each lit glyph pixel into the existing image instead
+// of overwriting it outright, so the watermark reads as faint rather than
+// stamped; position is one of watermarkPositions, anchored watermarkMargin
+// pixels from the edge (or centered).
+//
+// This is meant to deter casual scraping of a generated profile image by
+// marking it as generated (e.g. a handle or URL), not as a robust
+// anti-tampering measure - font5x7's handful of blocky glyphs are
+// trivially cropped or inpainted out by anyone motivated enough.
+func WatermarkProcessor(text string, opacity float64, position string, fontSize int) ImageProcessor {
+	return func(src image.Image) (image.Image, error) {
+		if fontSize <= 0 {
+			fontSize = 1
+		}
+		b := src.Bounds()
+		dst := image.NewRGBA(b)
+		draw.Draw(dst, b, src, b.Min, draw.Src)
+
+		width := glyphAdvance * fontSize * len([]rune(strings.ToUpper(text)))
+		height := glyphRows * fontSize
+		x, y := watermarkOrigin(position, b.Dx(), b.Dy(), width, height)
+
+		drawTextBlended(dst, text, b.Min.X+x, b.Min.Y+y, fontSize, color.White, opacity)
+		return dst, nil
+	}
+}
+
+// watermarkOrigin returns the top-left pixel, relative to the image's
+// bounds, to draw a width x height block of text at for position, keeping
+// watermarkMargin from whichever edges it's anchored to. An unrecognized
+// position falls back to "bottom-right", same as -watermark-position's
+// flag default.
+func watermarkOrigin(position string, imgWidth, imgHeight, width, height int) (x, y int) {
+	switch position {
+	case "top-left":
+		return watermarkMargin, watermarkMargin
+	case "top-right":
+		return imgWidth - width - watermarkMargin, watermarkMargin
+	case "bottom-left":
+		return watermarkMargin, imgHeight - height - watermarkMargin
+	case "center":
+		return (imgWidth - width) / 2, (imgHeight - height) / 2
+	default:
+		return imgWidth - width - watermarkMargin, imgHeight - height - watermarkMargin
+	}
+}
+
+// drawTextBlended is drawText's (see ogimage.go) sibling for translucent
+// text: each lit glyph pixel is alpha-blended into dst's existing color
+// (see blend) instead of overwriting it, so opacity < 1 reads as faint
+// rather than stamped. opacity is clamped to [0, 1].
+func drawTextBlended(dst draw.Image, text string, x, y, scale int, ink color.Color, opacity float64) {
+	if scale <= 0 {
+		scale = 1
+	}
+	if opacity < 0 {
+		opacity = 0
+	} else if opacity > 1 {
+		opacity = 1
+	}
+
+	cursor := x
+	for _, r := range strings.ToUpper(text) {
+		glyph, ok := font5x7[r]
+		if ok {
+			for row := 0; row < glyphRows; row++ {
+				for col := 0; col < glyphColumns; col++ {
+					if glyph[row][col] != '1' {
+						continue
+					}
+					px0, py0 := cursor+col*scale, y+row*scale
+					for py := py0; py < py0+scale; py++ {
+						for px := px0; px < px0+scale; px++ {
+							dst.Set(px, py, blend(dst.At(px, py), ink, opacity))
+						}
+					}
+				}
+			}
+		}
+		cursor += glyphAdvance * scale
+	}
+}
+
+// blend linearly interpolates from base toward over by t (0 = base, 1 =
+// over) in 8-bit RGB, keeping base's own alpha - this tree doesn't deal
+// with partial source transparency mid-blend anywhere else either (see
+// BorderProcessor's solid-fill draw.Src), so a straight per-channel lerp is
+// enough.
+func blend(base, over color.Color, t float64) color.Color {
+	br, bg, bb, ba := base.RGBA()
+	or, og, ob, _ := over.RGBA()
+	lerp := func(b, o uint32) uint8 {
+		return uint8((float64(b)*(1-t) + float64(o)*t) / 257)
+	}
+	return color.RGBA{R: lerp(br, or), G: lerp(bg, og), B: lerp(bb, ob), A: uint8(ba / 257)}
+}
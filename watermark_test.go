@@ -0,0 +1,139 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatermarkOrigin_AnchorsToEachCorner(t *testing.T) {
+	cases := []struct {
+		position string
+		wantX    int
+		wantY    int
+	}{
+		{"top-left", watermarkMargin, watermarkMargin},
+		{"top-right", 100 - 20 - watermarkMargin, watermarkMargin},
+		{"bottom-left", watermarkMargin, 50 - 10 - watermarkMargin},
+		{"bottom-right", 100 - 20 - watermarkMargin, 50 - 10 - watermarkMargin},
+		{"center", (100 - 20) / 2, (50 - 10) / 2},
+		{"nonsense", 100 - 20 - watermarkMargin, 50 - 10 - watermarkMargin},
+	}
+	for _, c := range cases {
+		x, y := watermarkOrigin(c.position, 100, 50, 20, 10)
+		if x != c.wantX || y != c.wantY {
+			t.Errorf("watermarkOrigin(%q) = (%d, %d), want (%d, %d)", c.position, x, y, c.wantX, c.wantY)
+		}
+	}
+}
+
+func TestBlend_ZeroOpacityLeavesBaseUnchanged(t *testing.T) {
+	base := color.RGBA{R: 10, G: 20, B: 30, A: 255}
+	got := blend(base, color.White, 0)
+	r, g, b, _ := got.RGBA()
+	if r>>8 != 10 || g>>8 != 20 || b>>8 != 30 {
+		t.Errorf("blend(opacity=0) = %v, want base unchanged", got)
+	}
+}
+
+func TestBlend_FullOpacityMatchesOverColor(t *testing.T) {
+	base := color.RGBA{R: 10, G: 20, B: 30, A: 255}
+	got := blend(base, color.White, 1)
+	r, g, b, _ := got.RGBA()
+	if r>>8 != 0xff || g>>8 != 0xff || b>>8 != 0xff {
+		t.Errorf("blend(opacity=1) = %v, want over color", got)
+	}
+}
+
+func TestWatermarkProcessor_DrawsFaintTextWithoutFullyOverwritingPixels(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	black := color.RGBA{A: 255}
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			src.Set(x, y, black)
+		}
+	}
+
+	proc := WatermarkProcessor("HI", 0.5, "bottom-right", 2)
+	out, err := proc(src)
+	if err != nil {
+		t.Fatalf("WatermarkProcessor() error = %v", err)
+	}
+
+	var litPartially, fullyWhite bool
+	bounds := out.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, _, _, _ := out.At(x, y).RGBA()
+			switch {
+			case r>>8 == 0xff:
+				fullyWhite = true
+			case r>>8 > 0:
+				litPartially = true
+			}
+		}
+	}
+	if fullyWhite {
+		t.Error("expected no fully-white pixel from a 0.5 opacity watermark over a black image")
+	}
+	if !litPartially {
+		t.Error("expected some partially-lit pixels from the watermark")
+	}
+}
+
+func TestWatermarkProcessor_ZeroFontSizeDoesNotPanic(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	proc := WatermarkProcessor("HI", 0.2, "center", 0)
+	if _, err := proc(src); err != nil {
+		t.Fatalf("WatermarkProcessor() error = %v", err)
+	}
+}
+
+func TestPipelineRun_WatermarkAppliesImageProcessor(t *testing.T) {
+	srv := tinyPNGServer(t)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:               srv.URL,
+		Out:               filepath.Join(t.TempDir(), "README.md"),
+		Timeout:           5 * time.Second,
+		UserAgent:         defaultUserAgent,
+		Watermark:         "HI",
+		WatermarkOpacity:  0.2,
+		WatermarkPosition: "bottom-right",
+		WatermarkFontSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if p.ImageProcessor == nil {
+		t.Fatal("expected NewPipeline to install a WatermarkProcessor when -watermark is set")
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestParseFlags_WatermarkRejectsInvalidPosition(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "https://example.com", "-watermark", "HI", "-watermark-position", "middle"}); err == nil {
+		t.Fatal("expected an error for an unrecognized -watermark-position")
+	}
+}
+
+func TestParseFlags_WatermarkRejectsOutOfRangeOpacity(t *testing.T) {
+	if _, err := parseFlags([]string{"-url", "https://example.com", "-watermark", "HI", "-watermark-opacity", "1.5"}); err == nil {
+		t.Fatal("expected an error for -watermark-opacity > 1")
+	}
+}
+
+func TestParseFlags_WatermarkDisabledSkipsValidation(t *testing.T) {
+	cfg, err := parseFlags([]string{"-url", "https://example.com", "-watermark-position", "middle"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v, want an invalid -watermark-position to be ignored when -watermark is unset", err)
+	}
+	if cfg.Watermark != "" {
+		t.Errorf("Watermark = %q, want empty", cfg.Watermark)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// The standard image package has no WebP decoder, so WebP is validated at
+// the RIFF container level (magic + well-formed chunk walk) rather than by
+// fully decoding pixels - the same trade-off SVG makes against image.Decode.
+
+// errTruncatedWebP indicates a WebP's RIFF container declares a length that
+// doesn't match the actual byte count. A connection that drops mid-download
+// can still return a 200 with what looks like a complete, decodable frame,
+// so this check catches truncation that plain decoding misses.
+var errTruncatedWebP = errors.New("truncated webp: RIFF declared length does not match actual byte count")
+
+// webpLosslessSignature is the single required first byte of a VP8L
+// (lossless) chunk's payload, per the WebP Lossless Bitstream
+// Specification. A VP8L chunk missing it is unambiguously corrupt, not a
+// subcase any decoder could make sense of.
+const webpLosslessSignature = 0x2f
+
+// errMalformedLosslessWebP indicates a VP8L chunk was found without its
+// required 0x2f signature byte (or with no payload at all) - a narrower,
+// clearer signal than the generic "invalid WebP" errors below for this
+// specific real-world failure mode.
+var errMalformedLosslessWebP = errors.New("malformed lossless webp: VP8L chunk missing its 0x2f signature byte")
+
+// validateWebP confirms data starts with a well-formed RIFF/WEBP header,
+// that the container's declared length matches len(data) (per
+// errTruncatedWebP), and that any VP8L (lossless) chunk present starts with
+// its required signature byte (per errMalformedLosslessWebP).
+//
+// This package has no WebP pixel decoder - the standard image package
+// doesn't ship one, and adding golang.org/x/image/webp would be a third-
+// party dependency this zero-dependency tree doesn't take (see thumbnail.go
+// for the same trade-off elsewhere) - so WebP is validated at the RIFF
+// container level throughout, the same way SVG is validated as XML rather
+// than rasterized. The VP8L signature check below is the most a lossless
+// WebP can be hardened against corruption without actually decoding its
+// bitstream: it still catches a truncated or bit-flipped lossless chunk
+// before it's embedded, turning what would otherwise be a silently broken
+// image into a clear error.
+func validateWebP(data []byte) error {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return fmt.Errorf("invalid WebP: missing RIFF/WEBP header")
+	}
+	declared := binary.LittleEndian.Uint32(data[4:8])
+	actual := len(data) - 8
+	if int(declared) != actual {
+		return fmt.Errorf("%w: RIFF declares %d bytes, got %d", errTruncatedWebP, declared, actual)
+	}
+
+	for offset := 12; offset+8 <= len(data); {
+		fourCC := string(data[offset : offset+4])
+		size := binary.LittleEndian.Uint32(data[offset+4 : offset+8])
+		payloadStart := offset + 8
+		payloadEnd := payloadStart + int(size)
+		if fourCC == "VP8L" && (payloadEnd > len(data) || size < 1 || data[payloadStart] != webpLosslessSignature) {
+			return errMalformedLosslessWebP
+		}
+		offset = payloadEnd
+		if size%2 == 1 {
+			offset++ // chunks are padded to an even number of bytes
+		}
+	}
+	return nil
+}
+
+// isAnimatedWebP reports whether data contains an ANIM chunk, which the
+// WebP spec requires for animated images. It assumes data already passed
+// validateWebP.
+func isAnimatedWebP(data []byte) bool {
+	for offset := 12; offset+8 <= len(data); {
+		fourCC := string(data[offset : offset+4])
+		size := binary.LittleEndian.Uint32(data[offset+4 : offset+8])
+		if fourCC == "ANIM" {
+			return true
+		}
+		offset += 8 + int(size)
+		if size%2 == 1 {
+			offset++ // chunks are padded to an even number of bytes
+		}
+	}
+	return false
+}
+
+// warnIfAnimated logs a warning that GitHub may not animate the embedded
+// WebP, since renderer support for animated WebP is inconsistent. It
+// reports whether it warned, so callers can track it for -fail-on-warning.
+func warnIfAnimated(animated bool) bool {
+	if animated {
+		fmt.Fprintln(os.Stderr, "eagleusb: collage is an animated WebP; some renderers (including GitHub) may only show the first frame")
+	}
+	return animated
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func riffChunk(fourCC string, payload []byte) []byte {
+	size := len(payload)
+	chunk := append([]byte(fourCC), byte(size), byte(size>>8), byte(size>>16), byte(size>>24))
+	chunk = append(chunk, payload...)
+	if size%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func buildWebP(chunks ...[]byte) []byte {
+	var body []byte
+	body = append(body, []byte("WEBP")...)
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	riffSize := len(body)
+	out := append([]byte("RIFF"), byte(riffSize), byte(riffSize>>8), byte(riffSize>>16), byte(riffSize>>24))
+	return append(out, body...)
+}
+
+func TestIsAnimatedWebP(t *testing.T) {
+	static := buildWebP(riffChunk("VP8 ", []byte{0, 1, 2, 3}))
+	if isAnimatedWebP(static) {
+		t.Fatal("expected static WebP to not be detected as animated")
+	}
+
+	animated := buildWebP(riffChunk("VP8X", make([]byte, 10)), riffChunk("ANIM", []byte{0, 0, 0, 0, 0, 0}))
+	if !isAnimatedWebP(animated) {
+		t.Fatal("expected WebP with an ANIM chunk to be detected as animated")
+	}
+}
+
+func TestValidateWebP_RejectsNonRIFF(t *testing.T) {
+	if err := validateWebP([]byte("not a webp file")); err == nil {
+		t.Fatal("expected error for non-RIFF data")
+	}
+}
+
+func TestValidateWebP_AcceptsWellFormedLossless(t *testing.T) {
+	lossless := buildWebP(riffChunk("VP8L", append([]byte{webpLosslessSignature}, make([]byte, 8)...)))
+	if err := validateWebP(lossless); err != nil {
+		t.Fatalf("validateWebP() error = %v, want a well-formed lossless WebP to pass through unchanged", err)
+	}
+}
+
+func TestValidateWebP_RejectsLosslessWithoutSignatureByte(t *testing.T) {
+	corrupt := buildWebP(riffChunk("VP8L", append([]byte{0x00}, make([]byte, 8)...)))
+	err := validateWebP(corrupt)
+	if !errors.Is(err, errMalformedLosslessWebP) {
+		t.Fatalf("validateWebP() error = %v, want errMalformedLosslessWebP", err)
+	}
+}
+
+func TestValidateWebP_RejectsEmptyLosslessChunk(t *testing.T) {
+	empty := buildWebP(riffChunk("VP8L", nil))
+	err := validateWebP(empty)
+	if !errors.Is(err, errMalformedLosslessWebP) {
+		t.Fatalf("validateWebP() error = %v, want errMalformedLosslessWebP", err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestValidateWebP_DetectsLengthMismatch(t *testing.T) {
+	good := makeWebP()
+	truncated := good[:len(good)-2]
+	if err := validateWebP(truncated); err == nil {
+		t.Fatal("expected error for a RIFF container shorter than its declared length")
+	} else if !errors.Is(err, errTruncatedWebP) {
+		t.Errorf("error = %v, want errTruncatedWebP", err)
+	}
+}
+
+func TestValidateWebP_AcceptsWellFormedContainer(t *testing.T) {
+	if err := validateWebP(makeWebP()); err != nil {
+		t.Errorf("validateWebP() error = %v, want nil for a well-formed container", err)
+	}
+}
+
+// truncatingWebPServer serves a RIFF container truncated by a couple of
+// bytes on the first failCount requests, then the real, well-formed one.
+func truncatingWebPServer(t *testing.T, failCount int) *httptest.Server {
+	t.Helper()
+	good := makeWebP()
+	truncated := good[:len(good)-2]
+	var attempts int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/webp")
+		n := atomic.AddInt32(&attempts, 1)
+		if int(n) <= failCount {
+			w.Write(truncated)
+			return
+		}
+		w.Write(good)
+	}))
+}
+
+func TestPipelineRun_RetriesTruncatedWebPWithinBudget(t *testing.T) {
+	srv := truncatingWebPServer(t, 2)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:            srv.URL,
+		Out:            filepath.Join(t.TempDir(), "README.md"),
+		Timeout:        5 * time.Second,
+		UserAgent:      defaultUserAgent,
+		ConnectRetries: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want success once the truncated attempts are exhausted", err)
+	}
+}
+
+func TestPipelineRun_TruncatedWebPExhaustsRetryBudget(t *testing.T) {
+	srv := truncatingWebPServer(t, 100)
+	defer srv.Close()
+
+	p, err := NewPipeline(Config{
+		URL:            srv.URL,
+		Out:            filepath.Join(t.TempDir(), "README.md"),
+		Timeout:        5 * time.Second,
+		UserAgent:      defaultUserAgent,
+		ConnectRetries: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	if err := p.Run(); err == nil {
+		t.Fatal("expected Run() to fail once the connect-retry budget is exhausted on a persistently truncated WebP")
+	}
+}